@@ -0,0 +1,205 @@
+// Package auth issues access tokens together with rotating refresh tokens
+// and keeps track of revoked ones.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"neobank-lite/config"
+	"neobank-lite/models"
+	"neobank-lite/repository"
+	"neobank-lite/utils"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// TokenPair is what a client receives on login and on every refresh.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int    `json:"expires_in" example:"900"` // access token lifetime in seconds
+}
+
+// Service issues access tokens signed with the configured key together with
+// refresh tokens kept in a store, and revokes them.
+type Service struct {
+	store repository.Store
+	cfg   config.Auth
+}
+
+// NewService returns a Service that signs tokens with cfg.JWTSecret and
+// keeps them in store.
+func NewService(store repository.Store, cfg config.Auth) *Service {
+	return &Service{store: store, cfg: cfg}
+}
+
+// AccessTokenTTL is the lifetime of access tokens.
+func (s *Service) AccessTokenTTL() time.Duration {
+	return s.cfg.AccessTokenTTL
+}
+
+// RefreshTokenTTL is the lifetime of refresh tokens.
+func (s *Service) RefreshTokenTTL() time.Duration {
+	return s.cfg.RefreshTokenTTL
+}
+
+// Login starts a new token family for user.
+func (s *Service) Login(user models.User) (TokenPair, error) {
+	return s.issue(s.store.Tokens(), user, uuid.New().String())
+}
+
+// Refresh exchanges a refresh token for a new pair in the same family. Each
+// refresh token works once; presenting one that was already exchanged means
+// it was copied, so the whole family is revoked and ErrRefreshTokenReused
+// returned.
+func (s *Service) Refresh(refreshToken string) (TokenPair, error) {
+	var pair TokenPair
+	var reused string
+	err := s.store.Atomic(func(tx repository.Store) error {
+		current, err := tx.Tokens().LockByHash(hashToken(refreshToken))
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidRefreshToken
+		} else if err != nil {
+			return err
+		}
+
+		if current.RevokedAt != nil || time.Now().After(current.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+		if current.UsedAt != nil {
+			reused = current.FamilyID
+			return ErrRefreshTokenReused
+		}
+
+		now := time.Now()
+		current.UsedAt = &now
+		if err := tx.Tokens().MarkUsed(&current); err != nil {
+			return err
+		}
+
+		user, err := tx.Users().Get(current.UserID)
+		if err != nil {
+			return ErrInvalidRefreshToken
+		}
+		pair, err = s.issue(tx.Tokens(), user, current.FamilyID)
+		return err
+	})
+
+	// Revoke outside the rolled back unit of work so it sticks
+	if reused != "" {
+		if err := s.RevokeFamily(reused); err != nil {
+			return TokenPair{}, err
+		}
+	}
+	return pair, err
+}
+
+// Logout revokes the token family that the access token jti belongs to,
+// including jti itself.
+func (s *Service) Logout(jti string) error {
+	if err := revokeAccessToken(s.store.Tokens(), jti, time.Now().Add(s.AccessTokenTTL())); err != nil {
+		return err
+	}
+	token, err := s.store.Tokens().GetByAccessJTI(jti)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return s.RevokeFamily(token.FamilyID)
+}
+
+// LogoutAll revokes every token family of a user, signing out all devices.
+func (s *Service) LogoutAll(userID uint) error {
+	families, err := s.store.Tokens().ActiveFamilies(userID)
+	if err != nil {
+		return err
+	}
+	for _, family := range families {
+		if err := s.RevokeFamily(family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeFamily revokes all refresh tokens of a family and the access tokens
+// issued with them.
+func (s *Service) RevokeFamily(familyID string) error {
+	return s.store.Atomic(func(tx repository.Store) error {
+		tokens, err := tx.Tokens().Family(familyID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, t := range tokens {
+			expiresAt := t.CreatedAt.Add(s.AccessTokenTTL())
+			if t.AccessJTI == "" || expiresAt.Before(now) {
+				continue
+			}
+			if err := revokeAccessToken(tx.Tokens(), t.AccessJTI, expiresAt); err != nil {
+				return err
+			}
+		}
+		return tx.Tokens().RevokeFamily(familyID, now)
+	})
+}
+
+// IsRevoked reports whether the access token with this jti was revoked.
+func (s *Service) IsRevoked(jti string) (bool, error) {
+	return s.store.Tokens().IsAccessRevoked(jti)
+}
+
+func revokeAccessToken(tokens repository.TokenRepository, jti string, expiresAt time.Time) error {
+	return tokens.RevokeAccess(models.RevokedToken{JTI: jti, ExpiresAt: expiresAt, CreatedAt: time.Now()})
+}
+
+// issue signs a new access token and stores a new refresh token in family.
+func (s *Service) issue(tokens repository.TokenRepository, user models.User, familyID string) (TokenPair, error) {
+	jti := uuid.New().String()
+	ttl := s.AccessTokenTTL()
+	access, err := utils.GenerateJWT([]byte(s.cfg.JWTSecret.Reveal()), user.ID, user.Role, jti, ttl)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return TokenPair{}, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(raw)
+
+	err = tokens.Create(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refresh),
+		AccessJTI: jti,
+		ExpiresAt: time.Now().Add(s.RefreshTokenTTL()),
+	})
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(ttl.Seconds()),
+	}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"neobank-lite/config"
+	"neobank-lite/models"
+	"neobank-lite/repository"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var testConfig = config.Auth{
+	JWTSecret:       "0123456789abcdef0123456789abcdef",
+	AccessTokenTTL:  time.Minute,
+	RefreshTokenTTL: time.Hour,
+}
+
+func newTestService(t *testing.T, cfg config.Auth) (*Service, models.User) {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	user := models.User{Email: "a@example.com", Role: models.RoleUser}
+	if err := store.Users().Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	return NewService(store, cfg), user
+}
+
+// tokenID returns the jti of an access token.
+func tokenID(t *testing.T, pair TokenPair) string {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(pair.AccessToken, claims); err != nil {
+		t.Fatal(err)
+	}
+	jti, _ := claims["jti"].(string)
+	return jti
+}
+
+func assertRevoked(t *testing.T, s *Service, pair TokenPair, want bool) {
+	t.Helper()
+	revoked, err := s.IsRevoked(tokenID(t, pair))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked != want {
+		t.Errorf("access token revoked = %v, want %v", revoked, want)
+	}
+}
+
+func TestRefreshRotates(t *testing.T) {
+	s, user := newTestService(t, testConfig)
+	first, err := s.Login(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := s.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || tokenID(t, second) == tokenID(t, first) {
+		t.Fatal("refresh returned the same tokens")
+	}
+	if _, err := s.Refresh(second.RefreshToken); err != nil {
+		t.Errorf("refreshing the rotated token: %v", err)
+	}
+	assertRevoked(t, s, second, false)
+
+	if _, err := s.Refresh("not-a-token"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("unknown token: got %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshExpired(t *testing.T) {
+	cfg := testConfig
+	cfg.RefreshTokenTTL = -time.Second
+	s, user := newTestService(t, cfg)
+	pair, err := s.Login(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh(pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("got %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+// Presenting a refresh token twice means it leaked, so the whole family goes,
+// while other logins of the same user keep working.
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	s, user := newTestService(t, testConfig)
+	first, err := s.Login(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := s.Login(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Refresh(first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reusing a refresh token: got %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := s.Refresh(second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refreshing the rest of the family: got %v, want ErrInvalidRefreshToken", err)
+	}
+	assertRevoked(t, s, first, true)
+	assertRevoked(t, s, second, true)
+
+	assertRevoked(t, s, other, false)
+	if _, err := s.Refresh(other.RefreshToken); err != nil {
+		t.Errorf("refreshing another login: %v", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	s, user := newTestService(t, testConfig)
+	pair, err := s.Login(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := s.Login(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Logout(tokenID(t, pair)); err != nil {
+		t.Fatal(err)
+	}
+	assertRevoked(t, s, pair, true)
+	if _, err := s.Refresh(pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refreshing after logout: got %v, want ErrInvalidRefreshToken", err)
+	}
+	assertRevoked(t, s, other, false)
+
+	if err := s.LogoutAll(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	assertRevoked(t, s, other, true)
+}
@@ -1,14 +1,260 @@
+// Package config loads the settings of the server.
+//
+// Every setting has an environment variable name and a matching flag, e.g.
+// DB_HOST and -db-host. Values are taken, from lowest to highest precedence,
+// from the defaults below, a KEY=VALUE config file, the environment and the
+// command line flags. The config file is the one named by -config or
+// CONFIG_FILE, or .env in the working directory if it exists.
 package config
 
 import (
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"neobank-lite/money"
 
 	"github.com/joho/godotenv"
 )
 
-func LoadEnvVariables() {
-	err := godotenv.Load()
+// defaultFile is read when no config file is named, if it exists.
+const defaultFile = ".env"
+
+// Config is the whole configuration of the server.
+type Config struct {
+	Server       Server
+	Database     Database
+	Auth         Auth
+	Transactions Transactions
+	Documents    Documents
+	KYC          KYC
+	Health       Health
+
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL" default:"24h" help:"how long idempotency keys are remembered"`
+	FXRatesFile    string        `env:"FX_RATES_FILE" help:"CSV of FX rates loaded at startup"`
+}
+
+// Server configures the HTTP listener.
+type Server struct {
+	Addr            string        `env:"HTTP_ADDR" default:":8080" help:"address the HTTP server listens on"`
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" default:"30s" help:"how long shutdown waits for requests and queued transactions"`
+}
+
+// Database configures the PostgreSQL connection.
+type Database struct {
+	Host     string `env:"DB_HOST" default:"localhost" help:"PostgreSQL host"`
+	Port     int    `env:"DB_PORT" default:"5432" help:"PostgreSQL port"`
+	User     string `env:"DB_USER" help:"PostgreSQL user"`
+	Password Secret `env:"DB_PASSWORD" help:"PostgreSQL password"`
+	Name     string `env:"DB_NAME" help:"PostgreSQL database"`
+	SSLMode  string `env:"DB_SSLMODE" default:"disable" help:"disable, allow, prefer, require, verify-ca or verify-full"`
+}
+
+// Auth configures access and refresh tokens.
+type Auth struct {
+	JWTSecret       Secret        `env:"JWT_SECRET" help:"HMAC key of access tokens, at least 32 bytes"`
+	AccessTokenTTL  time.Duration `env:"ACCESS_TOKEN_TTL" default:"15m" help:"lifetime of access tokens"`
+	RefreshTokenTTL time.Duration `env:"REFRESH_TOKEN_TTL" default:"720h" help:"lifetime of refresh tokens"`
+}
+
+// Transactions configures the transaction workers.
+type Transactions struct {
+	Workers   int `env:"TRANSACTION_WORKERS" default:"8" help:"number of transaction workers"`
+	QueueSize int `env:"TRANSACTION_QUEUE_SIZE" default:"100" help:"transactions that may wait for a worker before requests block"`
+	// MaxAmount is in a base currency. Transactions in other currencies are
+	// converted into it at the configured FX rates.
+	MaxAmount *money.Money `env:"MAX_TRANSACTION_AMOUNT" help:"largest amount of one transaction, such as 10000 or 500.00 USD; no limit if empty, ETB if no currency is given"`
+}
+
+// Documents configures the document store, see package storage.
+type Documents struct {
+	EncryptionKey Secret `env:"DOCUMENT_ENCRYPTION_KEY" help:"base64 encoded 32 byte key documents are encrypted with"`
+	Store         string `env:"DOCUMENT_STORE" default:"local" help:"local or s3"`
+	Dir           string `env:"DOCUMENT_DIR" default:"./uploads" help:"directory of the local document store"`
+	S3Endpoint    string `env:"S3_ENDPOINT" help:"S3 endpoint URL"`
+	S3Region      string `env:"S3_REGION" default:"us-east-1" help:"S3 region"`
+	S3Bucket      string `env:"S3_BUCKET" help:"S3 bucket"`
+	S3AccessKey   string `env:"S3_ACCESS_KEY" help:"S3 access key"`
+	S3SecretKey   Secret `env:"S3_SECRET_KEY" help:"S3 secret key"`
+}
+
+// KYC configures the KYC provider, see package kyc.
+type KYC struct {
+	Provider      string        `env:"KYC_PROVIDER" default:"manual" help:"manual, mock or http"`
+	ProviderURL   string        `env:"KYC_PROVIDER_URL" help:"base URL of the http provider"`
+	APIKey        Secret        `env:"KYC_PROVIDER_API_KEY" help:"API key of the http provider"`
+	WebhookSecret Secret        `env:"KYC_WEBHOOK_SECRET" help:"secret webhook calls are signed with; without it webhooks are rejected"`
+	PollInterval  time.Duration `env:"KYC_POLL_INTERVAL" default:"30s" help:"how often pending checks are polled"`
+}
+
+// Health configures the readiness checks.
+type Health struct {
+	Timeout       time.Duration `env:"HEALTH_CHECK_TIMEOUT" default:"2s" help:"how long each readiness check may take"`
+	MaxQueueDepth int           `env:"HEALTH_MAX_QUEUE_DEPTH" default:"80" help:"queued transactions above which the instance reports not ready; below TRANSACTION_QUEUE_SIZE"`
+}
+
+// Secret is a setting that must not be logged. It prints as [redacted].
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+func (s Secret) GoString() string { return strconv.Quote(s.String()) }
+
+func (s Secret) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// Reveal returns the actual value.
+func (s Secret) Reveal() string { return string(s) }
+
+// setting is one field of Config together with its names.
+type setting struct {
+	env   string
+	flag  string
+	def   string
+	help  string
+	field reflect.Value
+}
+
+func settings(c *Config) []setting {
+	var out []setting
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			env, ok := f.Tag.Lookup("env")
+			if !ok {
+				walk(v.Field(i))
+				continue
+			}
+			out = append(out, setting{
+				env:   env,
+				flag:  strings.ReplaceAll(strings.ToLower(env), "_", "-"),
+				def:   f.Tag.Get("default"),
+				help:  f.Tag.Get("help"),
+				field: v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem())
+	return out
+}
+
+func (s setting) set(v string) error {
+	switch s.field.Interface().(type) {
+	case string, Secret:
+		s.field.SetString(v)
+	case int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not an integer", s.env, v)
+		}
+		s.field.SetInt(int64(n))
+	case time.Duration:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a duration such as 30s or 24h", s.env, v)
+		}
+		s.field.SetInt(int64(d))
+	case *money.Money:
+		if v == "" {
+			s.field.Set(reflect.Zero(s.field.Type()))
+			return nil
+		}
+		amount, currency, _ := strings.Cut(v, " ")
+		if currency == "" {
+			currency = money.DefaultCurrency
+		}
+		m, err := money.Parse(amount, strings.ToUpper(currency))
+		if err != nil {
+			return fmt.Errorf("%s: %q is not an amount such as 10000 or 500.00 USD: %v", s.env, v, err)
+		}
+		s.field.Set(reflect.ValueOf(&m))
+	}
+	return nil
+}
+
+// Load builds the configuration from args, normally os.Args[1:], and the
+// sources described in the package comment. It returns the arguments left
+// after the flags, such as a subcommand. The caller validates what it needs:
+// the server all of it with Validate, the migrate subcommand only Database.
+func Load(args []string) (*Config, []string, error) {
+	c := &Config{}
+	all := settings(c)
+
+	fs := flag.NewFlagSet("neobank-lite", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "KEY=VALUE file to read settings from (CONFIG_FILE)")
+	flags := make(map[string]*string, len(all))
+	for _, s := range all {
+		flags[s.flag] = fs.String(s.flag, s.def, s.help+" ("+s.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	file, err := readFile(*configFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	var errs []error
+	for _, s := range all {
+		v := s.def
+		if fv, ok := file[s.env]; ok {
+			v = fv
+		}
+		if ev, ok := os.LookupEnv(s.env); ok {
+			v = ev
+		}
+		if given[s.flag] {
+			v = *flags[s.flag]
+		}
+		if err := s.set(v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+	return c, fs.Args(), nil
+}
+
+// readFile reads a KEY=VALUE config file. Without a name it reads .env if
+// there is one.
+func readFile(name string) (map[string]string, error) {
+	if name == "" {
+		if _, err := os.Stat(defaultFile); err != nil {
+			return nil, nil
+		}
+		name = defaultFile
+	}
+	values, err := godotenv.Read(name)
 	if err != nil {
-		log.Fatal("❌ Error loading .env file")
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	return values, nil
+}
+
+// String lists every setting as KEY=value, with secrets redacted, so the
+// configuration can be logged.
+func (c *Config) String() string {
+	var b strings.Builder
+	for _, s := range settings(c) {
+		v := s.field.Interface()
+		if s.field.Kind() == reflect.Pointer && s.field.IsNil() {
+			v = ""
+		}
+		fmt.Fprintf(&b, "%s=%v\n", s.env, v)
 	}
+	return b.String()
 }
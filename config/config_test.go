@@ -0,0 +1,222 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"neobank-lite/money"
+)
+
+const (
+	testJWTSecret = "0123456789abcdef0123456789abcdef"
+	testDocKey    = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=" // bytes 0 to 31
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "test.env")
+	if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "DB_HOST=file-host\nDB_USER=file-user\nDB_NAME=file-db\nDB_PORT=6543\n")
+	t.Setenv("DB_USER", "env-user")
+	t.Setenv("DB_NAME", "env-db")
+
+	cfg, args, err := Load([]string{"-config", file, "-db-name", "flag-db", "migrate", "status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		setting   string
+		got, want interface{}
+	}{
+		{"default", cfg.Database.SSLMode, "disable"},
+		{"file over default", cfg.Database.Port, 6543},
+		{"file", cfg.Database.Host, "file-host"},
+		{"env over file", cfg.Database.User, "env-user"},
+		{"flag over env", cfg.Database.Name, "flag-db"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.setting, tt.got, tt.want)
+		}
+	}
+	if strings.Join(args, " ") != "migrate status" {
+		t.Errorf("remaining args %q, want migrate status", args)
+	}
+}
+
+func TestLoadConfigFileFromEnv(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeFile(t, "HTTP_ADDR=:9090\n"))
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Addr != ":9090" {
+		t.Errorf("HTTP_ADDR from CONFIG_FILE not used, got %q", cfg.Server.Addr)
+	}
+}
+
+func TestLoadRejectsMalformedValues(t *testing.T) {
+	file := writeFile(t, "DB_PORT=five\nSHUTDOWN_TIMEOUT=10\n")
+	_, _, err := Load([]string{"-config", file})
+	if err == nil {
+		t.Fatal("Load accepted malformed values")
+	}
+	for _, want := range []string{"DB_PORT", "SHUTDOWN_TIMEOUT"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+	if _, _, err := Load([]string{"-config", filepath.Join(t.TempDir(), "missing.env")}); err == nil {
+		t.Error("Load accepted a missing config file")
+	}
+}
+
+func TestLoadMaxAmount(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string // empty for no limit
+		ok    bool
+	}{
+		{"", "", true},
+		{"10000", "10000.00 ETB", true},
+		{"500.50 usd", "500.50 USD", true},
+		{"10000 JPY", "10000 JPY", true},
+		{"10000.50 JPY", "", false},
+		{"10,000", "", false},
+		{"100 XYZ", "", false},
+	}
+	for _, tt := range tests {
+		cfg, _, err := Load([]string{"-max-transaction-amount", tt.value})
+		if !tt.ok {
+			if err == nil || !strings.Contains(err.Error(), "MAX_TRANSACTION_AMOUNT") {
+				t.Errorf("%q: got error %v, want one mentioning MAX_TRANSACTION_AMOUNT", tt.value, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.value, err)
+			continue
+		}
+		got := ""
+		if cfg.Transactions.MaxAmount != nil {
+			got = cfg.Transactions.MaxAmount.String()
+		}
+		if got != tt.want {
+			t.Errorf("%q: limit %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	file := writeFile(t, fmt.Sprintf("DB_USER=bank\nDB_NAME=bank\nDB_PASSWORD=hunter2\nJWT_SECRET=%s\nDOCUMENT_ENCRYPTION_KEY=%s\n", testJWTSecret, testDocKey))
+	cfg, _, err := Load([]string{"-config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   string // in the error, none if empty
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"short JWT secret", func(c *Config) { c.Auth.JWTSecret = "short" }, "JWT_SECRET"},
+		{"unknown sslmode", func(c *Config) { c.Database.SSLMode = "sometimes" }, "DB_SSLMODE"},
+		{"bad document key", func(c *Config) { c.Documents.EncryptionKey = "c2hvcnQ=" }, "DOCUMENT_ENCRYPTION_KEY"},
+		{"s3 without bucket", func(c *Config) { c.Documents.Store = "s3"; c.Documents.S3Endpoint = "https://s3.example.com" }, "S3_BUCKET"},
+		{"http provider without URL", func(c *Config) { c.KYC.Provider = "http" }, "KYC_PROVIDER_URL"},
+		{"no workers", func(c *Config) { c.Transactions.Workers = 0 }, "TRANSACTION_WORKERS"},
+		{"no queue", func(c *Config) { c.Transactions.QueueSize = 0 }, "TRANSACTION_QUEUE_SIZE"},
+		{"queue depth at capacity", func(c *Config) { c.Health.MaxQueueDepth = c.Transactions.QueueSize }, "HEALTH_MAX_QUEUE_DEPTH"},
+		{"larger queue", func(c *Config) { c.Transactions.QueueSize = 500; c.Health.MaxQueueDepth = 400 }, ""},
+		{"zero limit", func(c *Config) { zero := money.Zero("ETB"); c.Transactions.MaxAmount = &zero }, "MAX_TRANSACTION_AMOUNT"},
+		{"zero timeout", func(c *Config) { c.Server.ShutdownTimeout = 0 }, "SHUTDOWN_TIMEOUT"},
+	}
+	for _, tt := range tests {
+		cfg := validConfig(t)
+		tt.change(cfg)
+		err := cfg.Validate()
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: got error %v, want one mentioning %s", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Auth.JWTSecret = ""
+	cfg.Database.User = ""
+	cfg.Health.Timeout = -time.Second
+	err := cfg.Validate()
+	for _, want := range []string{"JWT_SECRET", "DB_USER", "HEALTH_CHECK_TIMEOUT"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error %v does not mention %s", err, want)
+		}
+	}
+}
+
+// The migrate subcommand needs the database settings only.
+func TestDatabaseValidateIgnoresOtherSections(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Auth.JWTSecret = ""
+	cfg.Documents.EncryptionKey = ""
+	if err := cfg.Database.Validate(); err != nil {
+		t.Errorf("Database.Validate: %v", err)
+	}
+	cfg.Database.Host = ""
+	if err := cfg.Database.Validate(); err == nil || !strings.Contains(err.Error(), "DB_HOST") {
+		t.Errorf("Database.Validate without DB_HOST: got %v", err)
+	}
+}
+
+func TestSecretRedaction(t *testing.T) {
+	const value = "hunter2-hunter2"
+	s := Secret(value)
+	text, _ := json.Marshal(struct{ Password Secret }{s})
+	for name, out := range map[string]string{
+		"%s":   fmt.Sprintf("%s", s),
+		"%v":   fmt.Sprintf("%v", s),
+		"%+v":  fmt.Sprintf("%+v", struct{ Password Secret }{s}),
+		"%#v":  fmt.Sprintf("%#v", s),
+		"json": string(text),
+	} {
+		if strings.Contains(out, value) || !strings.Contains(out, "[redacted]") {
+			t.Errorf("%s prints %q", name, out)
+		}
+	}
+	if s.Reveal() != value {
+		t.Errorf("Reveal = %q, want %q", s.Reveal(), value)
+	}
+	if Secret("").String() != "" {
+		t.Error("an unset secret should print as empty")
+	}
+
+	cfg := validConfig(t)
+	out := cfg.String()
+	for _, secret := range []string{"hunter2", testJWTSecret, testDocKey} {
+		if strings.Contains(out, secret) {
+			t.Errorf("Config.String leaks %q", secret)
+		}
+	}
+	if !strings.Contains(out, "JWT_SECRET=[redacted]") || !strings.Contains(out, "DB_USER=bank") {
+		t.Errorf("Config.String does not list the settings:\n%s", out)
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// MinJWTSecretLength is the shortest JWT_SECRET accepted, in bytes. HS256
+// keys shorter than the hash they feed are easier to brute force.
+const MinJWTSecretLength = 32
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// Validate reports every setting that is missing or unusable, so the server
+// refuses to start instead of failing on the first request that needs it.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		fail("HTTP_ADDR %q is not a host:port address", c.Server.Addr)
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		fail("SHUTDOWN_TIMEOUT must be positive")
+	}
+
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(c.Auth.JWTSecret) < MinJWTSecretLength {
+		fail("JWT_SECRET must be at least %d bytes", MinJWTSecretLength)
+	}
+	if c.Auth.AccessTokenTTL <= 0 {
+		fail("ACCESS_TOKEN_TTL must be positive")
+	}
+	if c.Auth.RefreshTokenTTL <= 0 {
+		fail("REFRESH_TOKEN_TTL must be positive")
+	}
+	if c.IdempotencyTTL <= 0 {
+		fail("IDEMPOTENCY_TTL must be positive")
+	}
+
+	if c.Transactions.Workers < 1 {
+		fail("TRANSACTION_WORKERS must be at least 1")
+	}
+	if c.Transactions.QueueSize < 1 {
+		fail("TRANSACTION_QUEUE_SIZE must be at least 1")
+	}
+	if c.Transactions.MaxAmount != nil && !c.Transactions.MaxAmount.IsPositive() {
+		fail("MAX_TRANSACTION_AMOUNT must be positive")
+	}
+
+	if key, err := base64.StdEncoding.DecodeString(c.Documents.EncryptionKey.Reveal()); err != nil || len(key) != 32 {
+		fail("DOCUMENT_ENCRYPTION_KEY must be 32 bytes, base64 encoded")
+	}
+	switch c.Documents.Store {
+	case "local":
+		if c.Documents.Dir == "" {
+			fail("DOCUMENT_DIR is required by the local document store")
+		}
+	case "s3":
+		if !absoluteURL(c.Documents.S3Endpoint) {
+			fail("S3_ENDPOINT %q is not an absolute URL", c.Documents.S3Endpoint)
+		}
+		if c.Documents.S3Bucket == "" || c.Documents.S3AccessKey == "" || c.Documents.S3SecretKey == "" {
+			fail("S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are required by the s3 document store")
+		}
+	default:
+		fail("DOCUMENT_STORE %q must be local or s3", c.Documents.Store)
+	}
+
+	switch c.KYC.Provider {
+	case "manual", "mock":
+	case "http":
+		if !absoluteURL(c.KYC.ProviderURL) {
+			fail("KYC_PROVIDER_URL %q is not an absolute URL", c.KYC.ProviderURL)
+		}
+	default:
+		fail("KYC_PROVIDER %q must be manual, mock or http", c.KYC.Provider)
+	}
+	if c.KYC.PollInterval <= 0 {
+		fail("KYC_POLL_INTERVAL must be positive")
+	}
+
+	if c.Health.Timeout <= 0 {
+		fail("HEALTH_CHECK_TIMEOUT must be positive")
+	}
+	// The queue never holds more than its capacity, so a limit at or above
+	// it would never report the instance as busy.
+	if c.Health.MaxQueueDepth < 1 {
+		fail("HEALTH_MAX_QUEUE_DEPTH must be at least 1")
+	} else if c.Health.MaxQueueDepth >= c.Transactions.QueueSize {
+		fail("HEALTH_MAX_QUEUE_DEPTH %d must be below TRANSACTION_QUEUE_SIZE %d", c.Health.MaxQueueDepth, c.Transactions.QueueSize)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate reports every database setting that is missing or unusable. It
+// is all the migrate subcommand needs.
+func (d Database) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if d.Host == "" {
+		fail("DB_HOST is required")
+	}
+	if d.Port < 1 || d.Port > 65535 {
+		fail("DB_PORT %d is not a valid port", d.Port)
+	}
+	if d.User == "" {
+		fail("DB_USER is required")
+	}
+	if d.Name == "" {
+		fail("DB_NAME is required")
+	}
+	if !oneOf(d.SSLMode, sslModes) {
+		fail("DB_SSLMODE %q must be one of %v", d.SSLMode, sslModes)
+	}
+	return errors.Join(errs...)
+}
+
+func oneOf(v string, values []string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func absoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
@@ -2,77 +2,144 @@ package controllers
 
 import (
 	"encoding/json"
-	"neobank-lite/database"
+	"errors"
 	"neobank-lite/dto"
 	"neobank-lite/middleware"
 	"neobank-lite/models"
+	"neobank-lite/money"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
-	"gorm.io/gorm"
+	"github.com/gorilla/mux"
 )
 
-type CreateAccountRequest struct {
-	Balance     float64 `json:"balance"`
-	AccountType string  `json:"account_type"`
-	PhoneNumber int     `json:"phone_number"`
+var (
+	errAccountNotFound = errors.New("account not found")
+	errAccountNotOwned = errors.New("account does not belong to user")
+)
+
+// AccountHandler serves the endpoints that open, list and describe a
+// customer's own accounts.
+type AccountHandler struct {
+	store repository.Store
+}
+
+func NewAccountHandler(store repository.Store) *AccountHandler {
+	return &AccountHandler{store: store}
+}
+
+// resolveAccount returns the user's account with the given number, or the
+// user's default account when accountNumber is empty.
+func resolveAccount(accounts repository.AccountRepository, userID int, accountNumber string) (models.Account, error) {
+	var account models.Account
+	var err error
+	if accountNumber == "" {
+		account, err = accounts.GetDefault(userID)
+	} else {
+		account, err = accounts.Get(accountNumber)
+	}
+
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.Account{}, errAccountNotFound
+	} else if err != nil {
+		return models.Account{}, err
+	}
+	if account.UserID != userID {
+		return models.Account{}, errAccountNotOwned
+	}
+	return account, nil
+}
+
+// writeAccountError turns a resolveAccount error into a response.
+func writeAccountError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case errAccountNotFound:
+		problem.Error(w, r, http.StatusNotFound, models.ReasonAccountNotFound, "Account not found")
+	case errAccountNotOwned:
+		problem.Error(w, r, http.StatusForbidden, codeAccountNotOwned, "Account does not belong to user")
+	default:
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve account")
+	}
 }
 
 // CreateAccount godoc
 // @Summary Create a new account
-// @Description Allows a user to create a new bank account
+// @Description Allows a user to create a new bank account in any supported ISO 4217 currency (ETB if omitted). Accounts open with a zero balance and are funded by deposits or transfers. The user's first account becomes their default account.
 // @Tags Account
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param account body dto.CreateAccountRequest true "Account creation data"
 // @Success 201 {object} models.Account
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST, VALIDATION_FAILED or PHONE_NUMBER_TAKEN"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
 // @Router /account/create [post]
-func CreateAccount(w http.ResponseWriter, r *http.Request) {
+func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 
 	userIDStr := middleware.GetUserIDFromContext(r)
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID in context", http.StatusInternalServerError)
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Invalid user ID in context")
 		return
 	}
 
 	var req dto.CreateAccountRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	if req.Balance < 0 {
-		http.Error(w, "Initial balance cannot be negative", http.StatusBadRequest)
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	if _, err := money.Exponent(currency); err != nil {
+		writeError(w, r, invalidField("currency", fieldInvalid, "Unsupported currency: "+req.Currency))
 		return
 	}
 
-	// Check if phone number already has an account
-	var existing models.Account
-	result := database.DB.Where("phone_number = ?", req.PhoneNumber).First(&existing)
+	accountType := req.AccountType
+	if accountType == "" {
+		accountType = models.AccountChecking
+	}
 
-	if result.Error == nil {
-		http.Error(w, "Phone number already has an account", http.StatusBadRequest)
+	// Check if phone number already has an account owned by someone else
+	taken, err := h.store.Accounts().PhoneTaken(req.PhoneNumber, userID)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Database error while checking existing account")
 		return
-	} else if result.Error != gorm.ErrRecordNotFound {
-		http.Error(w, "Database error while checking existing account", http.StatusInternalServerError)
+	} else if taken {
+		problem.Error(w, r, http.StatusBadRequest, codePhoneNumberTaken, "Phone number already has an account")
 		return
 	}
 
 	account := models.Account{
 		AccountNumber: uuid.New().String(),
 		UserID:        userID,
-		Balance:       req.Balance,
-		AccountType:   req.AccountType,
+		Balance:       money.Zero(currency),
+		AccountType:   accountType,
 		PhoneNumber:   req.PhoneNumber,
+		Status:        models.AccountActive,
 	}
 
-	if err := database.DB.Create(&account).Error; err != nil {
-		http.Error(w, "Failed to create account", http.StatusInternalServerError)
+	err = h.store.Atomic(func(s repository.Store) error {
+		owned, err := s.Accounts().CountByUser(userID)
+		if err != nil {
+			return err
+		}
+		account.IsDefault = owned == 0
+
+		if err := s.Accounts().Create(&account); err != nil {
+			return err
+		}
+		return s.Ledger().OpenCustomerAccount(account.AccountNumber)
+	})
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to create account")
 		return
 	}
 
@@ -81,32 +148,114 @@ func CreateAccount(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(account)
 }
 
+// ListAccounts godoc
+// @Summary List accounts
+// @Description Returns all accounts owned by the authenticated user, default account first
+// @Tags Account
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Account
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/accounts [get]
+func (h *AccountHandler) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	userIDStr := middleware.GetUserIDFromContext(r)
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Invalid user ID in context")
+		return
+	}
+
+	accounts, err := h.store.Accounts().ListByUser(userID)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve accounts")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// SetDefaultAccount godoc
+// @Summary Set default account
+// @Description Makes the given account the user's default for requests that don't name an account
+// @Tags Account
+// @Security BearerAuth
+// @Produce json
+// @Param number path string true "Account number"
+// @Success 200 {object} models.Account
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "ACCOUNT_NOT_OWNED"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/account/{number}/default [put]
+func (h *AccountHandler) SetDefaultAccount(w http.ResponseWriter, r *http.Request) {
+	userIDStr := middleware.GetUserIDFromContext(r)
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Invalid user ID in context")
+		return
+	}
+
+	account, err := resolveAccount(h.store.Accounts(), userID, mux.Vars(r)["number"])
+	if err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	if err := h.store.Accounts().SetDefault(userID, account.AccountNumber); err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to set default account")
+		return
+	}
+	account.IsDefault = true
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
+
 // GetBalance godoc
 // @Summary Get account balance
-// @Description Returns the account balance for the authenticated user
+// @Description Returns the balance of the authenticated user's default account
 // @Tags Account
 // @Security BearerAuth
 // @Produce json
 // @Success 200 {object} map[string]interface{}
-// @Failure 404 {string} string "Account not found"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
 // @Router /account/balance [get]
-func GetBalance(w http.ResponseWriter, r *http.Request) {
+func (h *AccountHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
+	h.writeBalance(w, r, "")
+}
+
+// GetAccountBalance godoc
+// @Summary Get balance of a specific account
+// @Description Returns the balance of one of the authenticated user's accounts
+// @Tags Account
+// @Security BearerAuth
+// @Produce json
+// @Param number path string true "Account number"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "ACCOUNT_NOT_OWNED"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/account/{number}/balance [get]
+func (h *AccountHandler) GetAccountBalance(w http.ResponseWriter, r *http.Request) {
+	h.writeBalance(w, r, mux.Vars(r)["number"])
+}
+
+func (h *AccountHandler) writeBalance(w http.ResponseWriter, r *http.Request, accountNumber string) {
 	userIDStr := middleware.GetUserIDFromContext(r)
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID in context", http.StatusInternalServerError)
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Invalid user ID in context")
 		return
 	}
 
-	var account models.Account
-	result := database.DB.Where("user_id = ?", userID).First(&account)
-
-	if result.Error == gorm.ErrRecordNotFound {
-		http.Error(w, "Account not found", http.StatusNotFound)
-		return
-	} else if result.Error != nil {
-		http.Error(w, "Failed to retrieve account balance", http.StatusInternalServerError)
+	account, err := resolveAccount(h.store.Accounts(), userID, accountNumber)
+	if err != nil {
+		writeAccountError(w, r, err)
 		return
 	}
 
@@ -114,5 +263,6 @@ func GetBalance(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"account_number": account.AccountNumber,
 		"balance":        account.Balance,
+		"is_default":     account.IsDefault,
 	})
 }
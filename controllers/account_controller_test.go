@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"neobank-lite/middleware"
+	"neobank-lite/models"
+	"neobank-lite/repository"
+)
+
+// asUser returns a request as if JWTAuth had accepted a token of userID.
+func asUser(method, target, body, userID string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	return r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, userID))
+}
+
+func TestCreateAccount(t *testing.T) {
+	store := repository.NewMemoryStore()
+	h := NewAccountHandler(store)
+
+	tests := []struct {
+		name, body string
+		status     int
+	}{
+		{"default currency", `{"phone_number":"+251911234567"}`, http.StatusCreated},
+		{"second account", `{"phone_number":"+251911234567","currency":"usd","account_type":"savings"}`, http.StatusCreated},
+		{"initial balance", `{"phone_number":"+251911234567","balance":"1000000"}`, http.StatusBadRequest},
+		{"unknown currency", `{"phone_number":"+251911234567","currency":"XYZ"}`, http.StatusBadRequest},
+		{"invalid phone number", `{"phone_number":"0911234567"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.CreateAccount(w, asUser("POST", "/api/account/create", tt.body, "1"))
+		if w.Code != tt.status {
+			t.Errorf("%s: got %d %s, want %d", tt.name, w.Code, w.Body, tt.status)
+		}
+	}
+
+	accounts, err := store.Accounts().ListByUser(1)
+	if err != nil || len(accounts) != 2 {
+		t.Fatalf("ListByUser = %v, %v; want the two created accounts", accounts, err)
+	}
+	for _, account := range accounts {
+		if !account.Balance.IsZero() || account.Status != models.AccountActive {
+			t.Errorf("%s opened %s with %s", account.AccountNumber, account.Status, account.Balance)
+		}
+	}
+	if !accounts[0].IsDefault || accounts[0].Balance.Currency != "ETB" || accounts[1].Balance.Currency != "USD" {
+		t.Errorf("accounts %+v: want the first, ETB account as default and a USD account", accounts)
+	}
+
+	// Another user cannot open an account on the same phone number.
+	w := httptest.NewRecorder()
+	h.CreateAccount(w, asUser("POST", "/api/account/create", `{"phone_number":"+251911234567"}`, "2"))
+	var body struct{ Code string }
+	json.NewDecoder(w.Body).Decode(&body)
+	if w.Code != http.StatusBadRequest || body.Code != codePhoneNumberTaken {
+		t.Errorf("phone number of another user: got %d %s", w.Code, body.Code)
+	}
+
+	if m, err := store.Ledger().Reconcile(); err != nil || len(m) > 0 {
+		t.Errorf("balances disagree with the ledger: %+v, %v", m, err)
+	}
+	if _, err := store.Transactions().Get(1); err != repository.ErrNotFound {
+		t.Errorf("opening an account recorded a transaction: %v", err)
+	}
+}
@@ -0,0 +1,350 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"neobank-lite/auth"
+	"neobank-lite/dto"
+	"neobank-lite/models"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler serves the staff endpoints for managing users and accounts.
+type AdminHandler struct {
+	store  repository.Store
+	tokens *auth.Service
+}
+
+func NewAdminHandler(store repository.Store, tokens *auth.Service) *AdminHandler {
+	return &AdminHandler{store: store, tokens: tokens}
+}
+
+// UserSummary is what staff see of a user.
+type UserSummary struct {
+	ID        uint      `json:"id" example:"10"`
+	Name      string    `json:"name" example:"Abebe Kebede"`
+	Email     string    `json:"email" example:"abebe@example.com"`
+	Role      string    `json:"role" example:"user"`
+	KYCStatus string    `json:"kyc_status" example:"verified"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func summarizeUser(user models.User) UserSummary {
+	return UserSummary{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		KYCStatus: user.KYCStatus,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// UserDetails is a user together with all of their accounts.
+type UserDetails struct {
+	User     UserSummary      `json:"user"`
+	Accounts []models.Account `json:"accounts"`
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description Lists users, newest first. Requires the users:read permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param role query string false "Only users with this role" Enums(user, admin, support, compliance, auditor)
+// @Param kyc_status query string false "Only users with this KYC status"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Number of users to skip"
+// @Success 200 {array} UserSummary
+// @Failure 400 {object} problem.Problem "VALIDATION_FAILED: invalid limit or offset"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/users [get]
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, err := parseHistoryLimit(q.Get("limit"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	offset := 0
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			writeError(w, r, invalidField("offset", fieldInvalid, "offset must be a non-negative integer"))
+			return
+		}
+	}
+
+	filter := repository.UserFilter{Role: q.Get("role"), KYCStatus: q.Get("kyc_status")}
+	users, err := h.store.Users().List(filter, limit, offset)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to list users")
+		return
+	}
+	summaries := make([]UserSummary, 0, len(users))
+	for _, user := range users {
+		summaries = append(summaries, summarizeUser(user))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summaries)
+}
+
+// GetUser godoc
+// @Summary Get a user
+// @Description Returns any user with all of their accounts. Requires the users:read permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} UserDetails
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 404 {object} problem.Problem "USER_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/users/{id} [get]
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := loadUser(w, r, h.store.Users(), mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	accounts, err := h.store.Accounts().ListByUser(int(user.ID))
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve accounts")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UserDetails{User: summarizeUser(user), Accounts: accounts})
+}
+
+// SetUserRole godoc
+// @Summary Change a user's role
+// @Description Grants a role to a user. The user's tokens are revoked, so the new role takes effect at their next login and a demoted user loses the old rights right away. Requires the users:roles permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body dto.SetRoleRequest true "New role"
+// @Success 200 {object} UserSummary
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST or VALIDATION_FAILED"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 404 {object} problem.Problem "USER_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/users/{id}/role [put]
+func (h *AdminHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetRoleRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	user, ok := loadUser(w, r, h.store.Users(), mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	user.Role = req.Role
+	if err := h.store.Users().SetRole(&user); err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to update role")
+		return
+	}
+	if err := h.tokens.LogoutAll(user.ID); err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Role updated, but revoking the user's tokens failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summarizeUser(user))
+}
+
+// GetAnyAccount godoc
+// @Summary Get any account
+// @Description Returns any customer account. Requires the accounts:read permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param number path string true "Account number"
+// @Success 200 {object} models.Account
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/accounts/{number} [get]
+func (h *AdminHandler) GetAnyAccount(w http.ResponseWriter, r *http.Request) {
+	account, ok := loadAccount(w, r, h.store.Accounts(), mux.Vars(r)["number"])
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
+
+// GetAnyAccountHistory godoc
+// @Summary View the history of any account
+// @Description Same filters and pagination as the customer history endpoint, for any account. Requires the accounts:read permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param number path string true "Account number"
+// @Param from query string false "Earliest timestamp, YYYY-MM-DD or RFC 3339"
+// @Param to query string false "Latest timestamp, YYYY-MM-DD (whole day included) or RFC 3339 (exclusive)"
+// @Param type query string false "Transaction type" Enums(deposit, withdraw, transfer, reversal, opening_balance)
+// @Param status query string false "Transaction status" Enums(pending, success, failed, reversed)
+// @Param direction query string false "Money into or out of the account" Enums(in, out)
+// @Param min_amount query string false "Minimum amount, e.g. 10.00"
+// @Param max_amount query string false "Maximum amount, e.g. 500.00"
+// @Param counterparty query string false "Only transactions with this account number on the other side"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} TransactionPage
+// @Failure 400 {object} problem.Problem "VALIDATION_FAILED: invalid filter or cursor"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/accounts/{number}/history [get]
+func (h *TransactionHandler) GetAnyAccountHistory(w http.ResponseWriter, r *http.Request) {
+	account, err := h.store.Accounts().Get(mux.Vars(r)["number"])
+	if errors.Is(err, repository.ErrNotFound) {
+		err = errAccountNotFound
+	}
+	if err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+	h.writeHistoryPage(w, r, account)
+}
+
+// FreezeAccount godoc
+// @Summary Freeze an account
+// @Description Stops all money movement into and out of an account until it is unfrozen, and signs its owner out of every device. Requires the accounts:freeze permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param number path string true "Account number"
+// @Param freeze body dto.FreezeAccountRequest true "Reason for the freeze"
+// @Success 200 {object} models.Account
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST or VALIDATION_FAILED"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/accounts/{number}/freeze [post]
+func (h *AdminHandler) FreezeAccount(w http.ResponseWriter, r *http.Request) {
+	var req dto.FreezeAccountRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	now := time.Now()
+	account, ok := h.setAccountStatus(w, r, mux.Vars(r)["number"], func(account *models.Account) {
+		account.Status = models.AccountFrozen
+		account.FrozenReason = req.Reason
+		account.FrozenAt = &now
+	})
+	if !ok {
+		return
+	}
+	if err := h.tokens.LogoutAll(uint(account.UserID)); err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Account frozen, but revoking the owner's tokens failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
+
+// UnfreezeAccount godoc
+// @Summary Unfreeze an account
+// @Description Allows money to move into and out of a frozen account again. Requires the accounts:freeze permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param number path string true "Account number"
+// @Success 200 {object} models.Account
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/accounts/{number}/unfreeze [post]
+func (h *AdminHandler) UnfreezeAccount(w http.ResponseWriter, r *http.Request) {
+	account, ok := h.setAccountStatus(w, r, mux.Vars(r)["number"], func(account *models.Account) {
+		account.Status = models.AccountActive
+		account.FrozenReason = ""
+		account.FrozenAt = nil
+	})
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
+
+// setAccountStatus changes an account under lock, so that a transaction
+// running at the same time cannot have its balance change overwritten. It
+// writes an error and returns false if that fails.
+func (h *AdminHandler) setAccountStatus(w http.ResponseWriter, r *http.Request, number string, change func(account *models.Account)) (models.Account, bool) {
+	var account models.Account
+	err := h.store.Atomic(func(s repository.Store) error {
+		locked, err := s.Accounts().Lock(number)
+		if err != nil {
+			return err
+		}
+		account = *locked[number]
+		change(&account)
+		return s.Accounts().Save(&account)
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, models.ReasonAccountNotFound, "Account not found")
+		return account, false
+	} else if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to update account")
+		return account, false
+	}
+	return account, true
+}
+
+// loadUser writes a 404 and returns false if there is no user with id.
+func loadUser(w http.ResponseWriter, r *http.Request, users repository.UserRepository, id string) (models.User, bool) {
+	var user models.User
+	userID, err := strconv.Atoi(id)
+	if err == nil {
+		user, err = users.Get(uint(userID))
+	} else {
+		err = repository.ErrNotFound
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, codeUserNotFound, "User not found")
+		return user, false
+	} else if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve user")
+		return user, false
+	}
+	return user, true
+}
+
+// loadAccount writes a 404 and returns false if there is no such account.
+func loadAccount(w http.ResponseWriter, r *http.Request, accounts repository.AccountRepository, number string) (models.Account, bool) {
+	account, err := accounts.Get(number)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, models.ReasonAccountNotFound, "Account not found")
+		return account, false
+	} else if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve account")
+		return account, false
+	}
+	return account, true
+}
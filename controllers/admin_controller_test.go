@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"neobank-lite/auth"
+	"neobank-lite/config"
+	"neobank-lite/models"
+	"neobank-lite/money"
+	"neobank-lite/repository"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/gorilla/mux"
+)
+
+var testAuth = config.Auth{
+	JWTSecret:       "0123456789abcdef0123456789abcdef",
+	AccessTokenTTL:  time.Minute,
+	RefreshTokenTTL: time.Hour,
+}
+
+// tokenID returns the jti of an access token.
+func tokenID(t *testing.T, accessToken string) string {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(accessToken, claims); err != nil {
+		t.Fatal(err)
+	}
+	jti, _ := claims["jti"].(string)
+	return jti
+}
+
+// Changing a role or freezing an account signs the user out everywhere, so
+// that nobody keeps the rights of a token issued before the change.
+func TestAdminChangesRevokeTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(h *AdminHandler, w http.ResponseWriter)
+	}{
+		{"role change", func(h *AdminHandler, w http.ResponseWriter) {
+			r := asUser("PUT", "/admin/users/1/role", `{"role":"user"}`, "9")
+			h.SetUserRole(w, mux.SetURLVars(r, map[string]string{"id": "1"}))
+		}},
+		{"freeze", func(h *AdminHandler, w http.ResponseWriter) {
+			r := asUser("POST", "/admin/accounts/etb-a/freeze", `{"reason":"stolen phone"}`, "9")
+			h.FreezeAccount(w, mux.SetURLVars(r, map[string]string{"number": "etb-a"}))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := repository.NewMemoryStore()
+			tokens := auth.NewService(store, testAuth)
+			user := models.User{Email: "admin@example.com", Role: models.RoleAdmin}
+			if err := store.Users().Create(&user); err != nil {
+				t.Fatal(err)
+			}
+			account := models.Account{AccountNumber: "etb-a", UserID: int(user.ID), Balance: money.Zero("ETB")}
+			if err := store.Accounts().Create(&account); err != nil {
+				t.Fatal(err)
+			}
+			var pairs []auth.TokenPair
+			for i := 0; i < 2; i++ { // two devices
+				pair, err := tokens.Login(user)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pairs = append(pairs, pair)
+			}
+
+			w := httptest.NewRecorder()
+			tt.call(NewAdminHandler(store, tokens), w)
+			if w.Code != http.StatusOK {
+				t.Fatalf("got %d %s", w.Code, w.Body)
+			}
+
+			for i, pair := range pairs {
+				if _, err := tokens.Refresh(pair.RefreshToken); !errors.Is(err, auth.ErrInvalidRefreshToken) {
+					t.Errorf("refresh token of device %d: got %v, want it revoked", i+1, err)
+				}
+				if revoked, err := tokens.IsRevoked(tokenID(t, pair.AccessToken)); err != nil || !revoked {
+					t.Errorf("access token of device %d: revoked = %v, %v; want it revoked", i+1, revoked, err)
+				}
+			}
+		})
+	}
+}
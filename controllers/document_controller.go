@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"neobank-lite/problem"
+	"neobank-lite/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// documentURLTTL is how long a signed document URL stays valid.
+const documentURLTTL = 10 * time.Minute
+
+// DocumentHandler serves stored documents through signed URLs.
+type DocumentHandler struct {
+	documents *storage.Vault
+}
+
+func NewDocumentHandler(documents *storage.Vault) *DocumentHandler {
+	return &DocumentHandler{documents: documents}
+}
+
+// DocumentURL is a signed, time-limited link to a stored document.
+type DocumentURL struct {
+	URL       string    `json:"url" example:"/documents/national_ids/5f0c2b9e-7d3a-4a55-9a59-4d1f2e7c8b10.jpg?expires=1751539200&signature=9c1e..."`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NationalIDURL godoc
+// @Summary Get a link to a user's national ID
+// @Description Returns a signed URL that serves the user's national ID document for 10 minutes. Requires the kyc:review permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} DocumentURL
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 404 {object} problem.Problem "USER_NOT_FOUND or DOCUMENT_NOT_FOUND"
+// @Router /admin/kyc/{id}/document [get]
+func (h *KYCHandler) NationalIDURL(w http.ResponseWriter, r *http.Request) {
+	user, ok := loadUser(w, r, h.store.Users(), mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	if user.NationalID == "" {
+		problem.Error(w, r, http.StatusNotFound, codeDocumentNotFound, "User has no national ID document")
+		return
+	}
+
+	url, expiresAt := h.documents.SignURL(user.NationalID, documentURLTTL)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DocumentURL{URL: url, ExpiresAt: expiresAt})
+}
+
+// DownloadDocument godoc
+// @Summary Download a document
+// @Description Serves a stored document through a signed URL obtained from the API. The URL needs no other authentication and stops working when it expires.
+// @Tags Documents
+// @Produce image/jpeg
+// @Produce image/png
+// @Produce application/pdf
+// @Param key path string true "Document key"
+// @Param expires query int true "Expiry as a Unix timestamp"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 403 {object} problem.Problem "DOCUMENT_URL_INVALID"
+// @Failure 404 {object} problem.Problem "DOCUMENT_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /documents/{key} [get]
+func (h *DocumentHandler) DownloadDocument(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	q := r.URL.Query()
+	if err := h.documents.VerifyURL(key, q.Get("expires"), q.Get("signature")); err != nil {
+		problem.Error(w, r, http.StatusForbidden, codeDocumentURLInvalid, "Invalid or expired document URL")
+		return
+	}
+
+	data, err := h.documents.Open(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, codeDocumentNotFound, "Document not found")
+		return
+	} else if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to read document")
+		return
+	}
+
+	w.Header().Set("Content-Type", storage.ContentType(key))
+	w.Header().Set("Content-Disposition", "inline")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(data)
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"neobank-lite/problem"
+)
+
+// Error codes of the non-transaction endpoints. Like the transaction codes
+// they are part of the API and must not change.
+const (
+	codeUserExists               = "USER_EXISTS"
+	codeInvalidCredentials       = "INVALID_CREDENTIALS"
+	codeRefreshTokenInvalid      = "REFRESH_TOKEN_INVALID"
+	codeRefreshTokenReused       = "REFRESH_TOKEN_REUSED"
+	codePhoneNumberTaken         = "PHONE_NUMBER_TAKEN"
+	codeDocumentNotFound         = "DOCUMENT_NOT_FOUND"
+	codeDocumentURLInvalid       = "DOCUMENT_URL_INVALID"
+	codeDocumentTooLarge         = "DOCUMENT_TOO_LARGE"
+	codeUnsupportedDocumentType  = "UNSUPPORTED_DOCUMENT_TYPE"
+	codeIdentityDocumentRequired = "IDENTITY_DOCUMENT_REQUIRED"
+	codeKYCInvalidTransition     = "KYC_INVALID_TRANSITION"
+	codeKYCDocumentsLocked       = "KYC_DOCUMENTS_LOCKED"
+	codeKYCOwnReview             = "KYC_OWN_REVIEW"
+	codeKYCNotReviewer           = "KYC_NOT_REVIEWER"
+	codeKYCProviderNotConfigured = "KYC_PROVIDER_NOT_CONFIGURED"
+	codeKYCCheckNotFound         = "KYC_CHECK_NOT_FOUND"
+	codeInvalidSignature         = "INVALID_SIGNATURE"
+)
+
+// Codes of a FieldError.
+const (
+	fieldRequired = "required"
+	fieldInvalid  = "invalid"
+)
+
+// invalidField is a VALIDATION_FAILED problem about a single field or query
+// parameter.
+func invalidField(field, code, message string) *problem.Problem {
+	return problem.Invalid(problem.FieldError{Field: field, Code: code, Message: message})
+}
+
+// writeError sends err as a problem. Anything that is not a *problem.Problem
+// yet is logged and reported as an internal error.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var p *problem.Problem
+	if !errors.As(err, &p) {
+		log.Printf("❌ %s %s failed: %v", r.Method, r.URL.Path, err)
+		p = problem.New(http.StatusInternalServerError, problem.CodeInternalError, "Internal Server Error")
+	}
+	problem.Write(w, r, p)
+}
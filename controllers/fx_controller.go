@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"neobank-lite/dto"
+	"neobank-lite/fx"
+	"neobank-lite/money"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+)
+
+// FXHandler serves the exchange rate table.
+type FXHandler struct {
+	store repository.Store
+}
+
+func NewFXHandler(store repository.Store) *FXHandler {
+	return &FXHandler{store: store}
+}
+
+// ListFXRates godoc
+// @Summary List FX rates
+// @Description Returns the exchange rates used to convert transfers between accounts of different currencies
+// @Tags FX
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.FXRate
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/fx/rates [get]
+func (h *FXHandler) ListFXRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.store.Rates().List()
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to load FX rates")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
+
+// SetFXRate godoc
+// @Summary Set an FX rate
+// @Description Creates or replaces the rate for converting base into quote. Each direction is set separately. Requires the fx:write permission.
+// @Tags FX
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param rate body dto.FXRateRequest true "Currency pair and rate"
+// @Success 200 {object} models.FXRate
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST, or VALIDATION_FAILED for an invalid currency or rate"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/fx/rates [put]
+func (h *FXHandler) SetFXRate(w http.ResponseWriter, r *http.Request) {
+	var req dto.FXRateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	base, quote := strings.ToUpper(req.Base), strings.ToUpper(req.Quote)
+	var invalid []problem.FieldError
+	if _, err := money.Exponent(base); err != nil {
+		invalid = append(invalid, problem.FieldError{Field: "base", Code: fieldInvalid, Message: err.Error()})
+	}
+	if _, err := money.Exponent(quote); err != nil {
+		invalid = append(invalid, problem.FieldError{Field: "quote", Code: fieldInvalid, Message: err.Error()})
+	}
+	if len(invalid) > 0 {
+		problem.Write(w, r, problem.Invalid(invalid...))
+		return
+	}
+
+	rate, err := fx.NewRate(base, quote, req.Rate)
+	if err == nil {
+		err = h.store.Rates().Put(rate)
+	}
+	switch {
+	case errors.Is(err, fx.ErrSameCurrency):
+		writeError(w, r, invalidField("quote", fieldInvalid, err.Error()))
+		return
+	case errors.Is(err, money.ErrInvalidRate):
+		writeError(w, r, invalidField("rate", fieldInvalid, err.Error()))
+		return
+	case err != nil:
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to save FX rate")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rate)
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"neobank-lite/config"
+	"neobank-lite/database"
+)
+
+const (
+	healthOK   = "ok"
+	healthFail = "fail"
+)
+
+// HealthReport is the body of /healthz and /readyz. Status is ok only if
+// every check passed.
+type HealthReport struct {
+	Status string                 `json:"status" example:"ok"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+// HealthCheck is the outcome of checking one dependency.
+type HealthCheck struct {
+	Status     string `json:"status" example:"ok"`
+	Message    string `json:"message,omitempty" example:"3 of 80 jobs queued"`
+	DurationMS int64  `json:"duration_ms" example:"2"`
+}
+
+// healthCheck returns a short description of what it found, or why the
+// dependency is unusable.
+type healthCheck func(ctx context.Context) (string, error)
+
+// HealthDatabase is what the readiness probe checks of the database, see
+// database.Status.
+type HealthDatabase interface {
+	Ping(ctx context.Context) error
+	Pending(ctx context.Context) ([]database.Migration, error)
+}
+
+// DocumentProbe checks that documents can be stored, see storage.Vault.
+type DocumentProbe interface {
+	Probe(ctx context.Context) error
+}
+
+// TransactionQueue reports on the transaction workers, see
+// TransactionHandler.
+type TransactionQueue interface {
+	QueueStatus() (workers, queued int, stopping bool)
+}
+
+// HealthHandler serves the liveness and readiness probes.
+type HealthHandler struct {
+	db           HealthDatabase
+	documents    DocumentProbe
+	transactions TransactionQueue
+	cfg          config.Health
+}
+
+func NewHealthHandler(db HealthDatabase, documents DocumentProbe, transactions TransactionQueue, cfg config.Health) *HealthHandler {
+	return &HealthHandler{db: db, documents: documents, transactions: transactions, cfg: cfg}
+}
+
+// Liveness godoc
+// @Summary Liveness probe
+// @Description Reports whether the process works at all. It does not check the database or other dependencies, so an outage there does not get the instance restarted; see /readyz for that.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} HealthReport
+// @Failure 503 {object} HealthReport
+// @Router /healthz [get]
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	h.report(w, r, map[string]healthCheck{
+		"transaction_workers": h.workersAlive,
+	})
+}
+
+// Readiness godoc
+// @Summary Readiness probe
+// @Description Reports whether the instance should receive traffic: the database answers, every migration is applied, transaction workers are running with a short queue and document storage is writable. Each check has its own timeout. Returns 503 as soon as shutdown begins.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} HealthReport
+// @Failure 503 {object} HealthReport
+// @Router /readyz [get]
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	h.report(w, r, map[string]healthCheck{
+		"database":            h.database,
+		"migrations":          h.migrations,
+		"transaction_workers": h.workersReady,
+		"document_storage":    h.storageWritable,
+	})
+}
+
+// report runs checks in parallel and writes the outcome, with 503 if any
+// of them failed.
+func (h *HealthHandler) report(w http.ResponseWriter, r *http.Request, checks map[string]healthCheck) {
+	report := HealthReport{Status: healthOK, Checks: make(map[string]HealthCheck, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check healthCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), h.cfg.Timeout)
+			defer cancel()
+
+			start := time.Now()
+			message, err := check(ctx)
+			result := HealthCheck{Status: healthOK, Message: message, DurationMS: time.Since(start).Milliseconds()}
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				result.Status, result.Message = healthFail, err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != healthOK {
+				report.Status = healthFail
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != healthOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *HealthHandler) database(ctx context.Context) (string, error) {
+	return "", h.db.Ping(ctx)
+}
+
+func (h *HealthHandler) migrations(ctx context.Context) (string, error) {
+	pending, err := h.db.Pending(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(pending) > 0 {
+		return "", fmt.Errorf("%d pending, newest is %s", len(pending), pending[len(pending)-1])
+	}
+	return "all applied", nil
+}
+
+// workersAlive fails only if the workers died on their own; stopping them
+// during shutdown is expected.
+func (h *HealthHandler) workersAlive(ctx context.Context) (string, error) {
+	workers, _, stopping := h.transactions.QueueStatus()
+	if workers == 0 && !stopping {
+		return "", fmt.Errorf("no transaction workers running")
+	}
+	return fmt.Sprintf("%d workers running", workers), nil
+}
+
+func (h *HealthHandler) workersReady(ctx context.Context) (string, error) {
+	workers, queued, stopping := h.transactions.QueueStatus()
+	switch {
+	case stopping:
+		return "", fmt.Errorf("shutting down")
+	case workers == 0:
+		return "", fmt.Errorf("no transaction workers running")
+	case queued >= h.cfg.MaxQueueDepth:
+		return "", fmt.Errorf("%d jobs queued, limit is %d", queued, h.cfg.MaxQueueDepth)
+	}
+	return fmt.Sprintf("%d of %d jobs queued", queued, h.cfg.MaxQueueDepth), nil
+}
+
+func (h *HealthHandler) storageWritable(ctx context.Context) (string, error) {
+	return "", h.documents.Probe(ctx)
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"neobank-lite/config"
+	"neobank-lite/database"
+)
+
+type fakeDatabase struct {
+	down    bool
+	pending []database.Migration
+	slow    bool // blocks until the check times out
+}
+
+func (f fakeDatabase) Ping(ctx context.Context) error {
+	if f.slow {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if f.down {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (f fakeDatabase) Pending(ctx context.Context) ([]database.Migration, error) {
+	return f.pending, nil
+}
+
+type fakeProbe struct{ err error }
+
+func (f fakeProbe) Probe(ctx context.Context) error { return f.err }
+
+type fakeQueue struct {
+	workers, queued int
+	stopping        bool
+}
+
+func (f fakeQueue) QueueStatus() (int, int, bool) { return f.workers, f.queued, f.stopping }
+
+func TestHealth(t *testing.T) {
+	healthy := fakeQueue{workers: 8, queued: 3}
+	tests := []struct {
+		name    string
+		db      fakeDatabase
+		probe   fakeProbe
+		queue   fakeQueue
+		live    bool
+		ready   bool
+		failing string // the readiness check expected to fail
+	}{
+		{"healthy", fakeDatabase{}, fakeProbe{}, healthy, true, true, ""},
+		{"workers stopped", fakeDatabase{}, fakeProbe{}, fakeQueue{}, false, false, "transaction_workers"},
+		{"queue over limit", fakeDatabase{}, fakeProbe{}, fakeQueue{workers: 8, queued: 80}, true, false, "transaction_workers"},
+		{"shutting down", fakeDatabase{}, fakeProbe{}, fakeQueue{stopping: true}, true, false, "transaction_workers"},
+		{"database down", fakeDatabase{down: true}, fakeProbe{}, healthy, true, false, "database"},
+		{"database slow", fakeDatabase{slow: true}, fakeProbe{}, healthy, true, false, "database"},
+		{"pending migration", fakeDatabase{pending: []database.Migration{{Version: 12, Name: "cards"}}}, fakeProbe{}, healthy, true, false, "migrations"},
+		{"storage unwritable", fakeDatabase{}, fakeProbe{errors.New("permission denied")}, healthy, true, false, "document_storage"},
+	}
+	for _, tt := range tests {
+		h := NewHealthHandler(tt.db, tt.probe, tt.queue, config.Health{Timeout: 50 * time.Millisecond, MaxQueueDepth: 80})
+
+		w := httptest.NewRecorder()
+		h.Liveness(w, httptest.NewRequest("GET", "/healthz", nil))
+		if live := w.Code == http.StatusOK; live != tt.live {
+			t.Errorf("%s: /healthz got %d", tt.name, w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		h.Readiness(w, httptest.NewRequest("GET", "/readyz", nil))
+		var report HealthReport
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if ready := w.Code == http.StatusOK; ready != tt.ready || (report.Status == healthOK) != tt.ready {
+			t.Errorf("%s: /readyz got %d %s", tt.name, w.Code, report.Status)
+		}
+		for name, check := range report.Checks {
+			if failed := check.Status != healthOK; failed != (name == tt.failing) {
+				t.Errorf("%s: check %s got %s %q", tt.name, name, check.Status, check.Message)
+			}
+		}
+		if len(report.Checks) != 4 {
+			t.Errorf("%s: got checks %v", tt.name, report.Checks)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"neobank-lite/models"
+	"neobank-lite/money"
+	"neobank-lite/repository"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+var (
+	transactionTypes    = []string{"deposit", "withdraw", "transfer", "reversal", "opening_balance"}
+	transactionStatuses = []string{models.TransactionPending, models.TransactionSuccess, models.TransactionFailed, models.TransactionReversed}
+)
+
+// encodeHistoryCursor turns a cursor into the opaque next_cursor of a page.
+func encodeHistoryCursor(c repository.Cursor) string {
+	raw := c.Timestamp.UTC().Format(time.RFC3339Nano) + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeHistoryCursor(s string) (repository.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return repository.Cursor{}, invalidField("cursor", fieldInvalid, "invalid cursor")
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return repository.Cursor{}, invalidField("cursor", fieldInvalid, "invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return repository.Cursor{}, invalidField("cursor", fieldInvalid, "invalid cursor")
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return repository.Cursor{}, invalidField("cursor", fieldInvalid, "invalid cursor")
+	}
+	return repository.Cursor{Timestamp: t, ID: n}, nil
+}
+
+// parseHistoryDate accepts RFC 3339 timestamps or plain dates. A plain date
+// used as an upper bound includes the whole day.
+func parseHistoryDate(field, s string, upper bool) (*time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, invalidField(field, fieldInvalid, field+" must be YYYY-MM-DD or RFC 3339")
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// parseHistoryFilter reads the filter of a history request from its query
+// string.
+func parseHistoryFilter(q url.Values, currency string) (repository.TransactionFilter, error) {
+	var f repository.TransactionFilter
+	var err error
+
+	if v := q.Get("from"); v != "" {
+		if f.From, err = parseHistoryDate("from", v, false); err != nil {
+			return f, err
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = parseHistoryDate("to", v, true); err != nil {
+			return f, err
+		}
+	}
+
+	f.Type = q.Get("type")
+	if f.Type != "" && !contains(transactionTypes, f.Type) {
+		return f, invalidField("type", fieldInvalid, "type must be one of "+strings.Join(transactionTypes, ", "))
+	}
+	f.Status = q.Get("status")
+	if f.Status != "" && !contains(transactionStatuses, f.Status) {
+		return f, invalidField("status", fieldInvalid, "status must be one of "+strings.Join(transactionStatuses, ", "))
+	}
+	f.Direction = q.Get("direction")
+	if f.Direction != "" && f.Direction != "in" && f.Direction != "out" {
+		return f, invalidField("direction", fieldInvalid, "direction must be in or out")
+	}
+
+	if v := q.Get("min_amount"); v != "" {
+		m, err := money.Parse(v, currency)
+		if err != nil {
+			return f, invalidField("min_amount", fieldInvalid, "invalid min_amount: "+err.Error())
+		}
+		f.MinAmount = &m
+	}
+	if v := q.Get("max_amount"); v != "" {
+		m, err := money.Parse(v, currency)
+		if err != nil {
+			return f, invalidField("max_amount", fieldInvalid, "invalid max_amount: "+err.Error())
+		}
+		f.MaxAmount = &m
+	}
+
+	f.Counterparty = q.Get("counterparty")
+	return f, nil
+}
+
+func parseHistoryLimit(s string) (int, error) {
+	if s == "" {
+		return defaultHistoryLimit, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, invalidField("limit", fieldInvalid, "limit must be a positive integer")
+	}
+	if n > maxHistoryLimit {
+		n = maxHistoryLimit
+	}
+	return n, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"neobank-lite/repository"
+)
+
+func TestHistoryCursor(t *testing.T) {
+	addis := time.FixedZone("EAT", 3*60*60)
+	c := repository.Cursor{Timestamp: time.Date(2024, 3, 1, 9, 30, 0, 123456789, addis), ID: 42}
+	got, err := decodeHistoryCursor(encodeHistoryCursor(c))
+	if err != nil || !got.Timestamp.Equal(c.Timestamp) || got.ID != c.ID {
+		t.Errorf("round trip of %+v = %+v, %v", c, got, err)
+	}
+
+	for _, s := range []string{
+		"",
+		"not base64!",
+		"MjAyNC0wMy0wMVQwNjozMDowMFo",    // no ID
+		"bm90IGEgdGltZXw0Mg",             // "not a time|42"
+		"MjAyNC0wMy0wMVQwNjozMDowMFp8eA", // "2024-03-01T06:30:00Z|x"
+	} {
+		if _, err := decodeHistoryCursor(s); err == nil {
+			t.Errorf("decodeHistoryCursor(%q) accepted", s)
+		}
+	}
+}
+
+func TestParseHistoryFilter(t *testing.T) {
+	q := url.Values{"from": {"2024-03-01"}, "to": {"2024-03-31"}, "min_amount": {"10.50"}, "direction": {"in"}}
+	f, err := parseHistoryFilter(q, "ETB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.From.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) || !f.To.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("dates %v to %v, want the whole of March", f.From, f.To)
+	}
+	if f.MinAmount == nil || f.MinAmount.Minor != 1050 || f.Direction != "in" {
+		t.Errorf("filter %+v", f)
+	}
+
+	for _, q := range []url.Values{
+		{"from": {"01/03/2024"}},
+		{"type": {"payment"}},
+		{"status": {"completed"}},
+		{"direction": {"sideways"}},
+		{"max_amount": {"1.001"}},
+	} {
+		if _, err := parseHistoryFilter(q, "ETB"); err == nil {
+			t.Errorf("%v accepted", q)
+		}
+	}
+}
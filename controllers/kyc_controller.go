@@ -2,68 +2,152 @@ package controllers
 
 import (
 	"encoding/json"
-	"neobank-lite/database"
+	"errors"
+	"log"
+	"neobank-lite/kyc"
 	"neobank-lite/middleware"
-	"neobank-lite/models"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+	"neobank-lite/storage"
 	"net/http"
 	"strconv"
 )
 
-type KYCRequest struct {
-	NationalID string `json:"national_id"`
+// KYCHandler serves the KYC endpoints of customers, staff and the
+// verification provider.
+type KYCHandler struct {
+	store     repository.Store
+	workflow  *kyc.Service
+	documents *storage.Vault
+}
+
+func NewKYCHandler(store repository.Store, workflow *kyc.Service, documents *storage.Vault) *KYCHandler {
+	return &KYCHandler{store: store, workflow: workflow, documents: documents}
 }
 
 // SubmitKYC godoc
-// @Summary Submit KYC verification
-// @Description Verifies the user KYC status using their registered national ID
+// @Summary Submit KYC for review
+// @Description Hands the user's current KYC documents in for review, which needs at least a national ID front or a passport. Allowed while KYC is pending or after a resubmission was requested; the user is notified of the decision.
 // @Tags KYC
 // @Security BearerAuth
 // @Produce json
 // @Success 200 {object} map[string]string
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} problem.Problem "IDENTITY_DOCUMENT_REQUIRED"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 409 {object} problem.Problem "KYC_INVALID_TRANSITION: KYC is not awaiting a submission"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
 // @Router /kyc/verify [post]
-func SubmitKYC(w http.ResponseWriter, r *http.Request) {
+func (h *KYCHandler) SubmitKYC(w http.ResponseWriter, r *http.Request) {
 	userIDStr := middleware.GetUserIDFromContext(r)
 	userID, _ := strconv.Atoi(userIDStr)
 
-	var user models.User
-	database.DB.First(&user, userID)
-
-	if user.NationalID == "" {
-		http.Error(w, "National ID not found in profile. Please update your profile.", http.StatusBadRequest)
+	user, err := h.workflow.Submit(uint(userID))
+	if err != nil {
+		writeKYCError(w, r, err)
 		return
 	}
 
-	// In real banking, we might verify this national ID via 3rd-party API here
-
-	user.KYCStatus = "verified"
-	database.DB.Save(&user)
-
 	json.NewEncoder(w).Encode(map[string]string{
-		"message":    "KYC verified using registered national ID",
+		"message":    "KYC submitted for review",
 		"kyc_status": user.KYCStatus,
 	})
 }
 
 // GetKYCStatus godoc
 // @Summary Get current KYC status
-// @Description Returns the current KYC verification status of the user
+// @Description Returns the current KYC status of the user: pending, submitted, under_review, verified, rejected or resubmission_required. The reason is included when KYC was rejected or must be resubmitted.
 // @Tags KYC
 // @Security BearerAuth
 // @Produce json
 // @Success 200 {object} map[string]string
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 404 {object} problem.Problem "USER_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
 // @Router /kyc/status [get]
-func GetKYCStatus(w http.ResponseWriter, r *http.Request) {
+func (h *KYCHandler) GetKYCStatus(w http.ResponseWriter, r *http.Request) {
 	userIDStr := middleware.GetUserIDFromContext(r)
 	userID, _ := strconv.Atoi(userIDStr)
 
-	var user models.User
-	database.DB.First(&user, userID)
+	user, err := h.store.Users().Get(uint(userID))
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, codeUserNotFound, "User not found")
+		return
+	} else if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	json.NewEncoder(w).Encode(map[string]string{
-		"kyc_status":  user.KYCStatus,
-		"national_id": user.NationalID,
-	})
+	status := map[string]string{"kyc_status": user.KYCStatus}
+	if user.KYCReason != "" {
+		status["reason"] = user.KYCReason
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// KYCWebhook godoc
+// @Summary Receive a KYC provider result
+// @Description Called by the identity verification provider when it has a result. The body must be signed with KYC_WEBHOOK_SECRET in the X-Signature header as "sha256=" followed by the hex HMAC-SHA256 of the body; unsigned calls and results for unknown checks are rejected, and repeated deliveries are ignored.
+// @Tags KYC
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST"
+// @Failure 401 {object} problem.Problem "INVALID_SIGNATURE"
+// @Failure 404 {object} problem.Problem "KYC_PROVIDER_NOT_CONFIGURED or KYC_CHECK_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /kyc/webhook [post]
+func (h *KYCHandler) KYCWebhook(w http.ResponseWriter, r *http.Request) {
+	provider := h.workflow.Provider()
+	if provider == nil {
+		problem.Error(w, r, http.StatusNotFound, codeKYCProviderNotConfigured, "No KYC provider configured")
+		return
+	}
+
+	result, err := provider.ParseWebhook(r)
+	if errors.Is(err, kyc.ErrInvalidSignature) {
+		problem.Error(w, r, http.StatusUnauthorized, codeInvalidSignature, "Invalid signature")
+		return
+	} else if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid webhook body")
+		return
+	}
+
+	err = h.workflow.ApplyResult(provider.Name(), result)
+	if errors.Is(err, kyc.ErrUnknownCheck) {
+		problem.Error(w, r, http.StatusNotFound, codeKYCCheckNotFound, "Unknown check")
+		return
+	} else if err != nil {
+		log.Printf("❌ Failed to apply KYC result %s: %v", result.Reference, err)
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to apply result")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Result received"})
+}
+
+// writeKYCError maps errors from package kyc to responses.
+func writeKYCError(w http.ResponseWriter, r *http.Request, err error) {
+	var transition *kyc.TransitionError
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		problem.Error(w, r, http.StatusNotFound, codeUserNotFound, "User not found")
+	case errors.As(err, &transition):
+		problem.Error(w, r, http.StatusConflict, codeKYCInvalidTransition, err.Error())
+	case errors.Is(err, kyc.ErrNoIdentityDocument):
+		problem.Error(w, r, http.StatusBadRequest, codeIdentityDocumentRequired, "Upload a national ID or passport before submitting")
+	case errors.Is(err, kyc.ErrUnknownDocumentType):
+		writeError(w, r, invalidField("type", fieldInvalid, "Unknown document type"))
+	case errors.Is(err, kyc.ErrDocumentNotFound):
+		problem.Error(w, r, http.StatusNotFound, codeDocumentNotFound, "Document not found")
+	case errors.Is(err, kyc.ErrDocumentsLocked):
+		problem.Error(w, r, http.StatusConflict, codeKYCDocumentsLocked, "Documents can only be changed before submitting or when asked to resubmit")
+	case errors.Is(err, kyc.ErrOwnReview):
+		problem.Error(w, r, http.StatusForbidden, codeKYCOwnReview, "You cannot review your own KYC")
+	case errors.Is(err, kyc.ErrNotReviewer):
+		problem.Error(w, r, http.StatusConflict, codeKYCNotReviewer, "Another reviewer took this review")
+	case errors.Is(err, kyc.ErrReasonRequired):
+		writeError(w, r, invalidField("reason", fieldRequired, "A reason is required"))
+	default:
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to update KYC status")
+	}
 }
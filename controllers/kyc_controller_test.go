@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"neobank-lite/kyc"
+	"neobank-lite/models"
+	"neobank-lite/repository"
+)
+
+func TestGetKYCStatus(t *testing.T) {
+	store := repository.NewMemoryStore()
+	user := models.User{Email: "a@example.com", KYCStatus: models.KYCRejected, KYCReason: "blurry photo"}
+	if err := store.Users().Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	h := NewKYCHandler(store, kyc.NewService(store, nil, nil), nil)
+
+	w := httptest.NewRecorder()
+	h.GetKYCStatus(w, asUser("GET", "/api/kyc/status", "", "1"))
+	var status map[string]string
+	json.NewDecoder(w.Body).Decode(&status)
+	if w.Code != http.StatusOK || status["kyc_status"] != models.KYCRejected || status["reason"] != "blurry photo" {
+		t.Errorf("got %d %v", w.Code, status)
+	}
+
+	// A user deleted after their token was issued.
+	w = httptest.NewRecorder()
+	h.GetKYCStatus(w, asUser("GET", "/api/kyc/status", "", "2"))
+	var body struct{ Code string }
+	json.NewDecoder(w.Body).Decode(&body)
+	if w.Code != http.StatusNotFound || body.Code != codeUserNotFound {
+		t.Errorf("missing user: got %d %s", w.Code, body.Code)
+	}
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"neobank-lite/kyc"
+	"neobank-lite/middleware"
+	"neobank-lite/models"
+	"neobank-lite/problem"
+	"neobank-lite/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// KYCDocumentLink is a KYC document with a signed URL to view it.
+type KYCDocumentLink struct {
+	models.KYCDocument
+	URL       string    `json:"url" example:"/documents/kyc_documents/5f0c2b9e-7d3a-4a55-9a59-4d1f2e7c8b10.jpg?expires=1751539200&signature=9c1e..."`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UploadKYCDocument godoc
+// @Summary Upload a KYC document
+// @Description Uploads an identity document. A document of a type the user already has replaces the old one. Documents can be changed while KYC is pending or after a resubmission was requested; submitting KYC needs at least a national ID front or a passport.
+// @Tags KYC
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param type formData string true "Document type" Enums(national_id_front, national_id_back, passport, selfie, proof_of_address)
+// @Param file formData file true "JPEG, PNG or PDF, at most 5 MB"
+// @Success 201 {object} models.KYCDocument
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST or VALIDATION_FAILED"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 409 {object} problem.Problem "KYC_DOCUMENTS_LOCKED"
+// @Failure 413 {object} problem.Problem "DOCUMENT_TOO_LARGE"
+// @Failure 415 {object} problem.Problem "UNSUPPORTED_DOCUMENT_TYPE"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/kyc/documents [post]
+func (h *KYCHandler) UploadKYCDocument(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(middleware.GetUserIDFromContext(r))
+
+	if !parseUpload(w, r) {
+		return
+	}
+	docType := r.FormValue("type")
+	if docType == "" {
+		writeError(w, r, invalidField("type", fieldRequired, "Document type is required"))
+		return
+	}
+	// Checked before anything is stored
+	if !kyc.IsDocumentType(docType) {
+		writeKYCError(w, r, kyc.ErrUnknownDocumentType)
+		return
+	}
+
+	key, ok := storeUpload(w, r, h.documents, "file", "kyc_documents")
+	if !ok {
+		return
+	}
+	document, err := h.workflow.AddDocument(uint(userID), docType, key, storage.ContentType(key))
+	if err != nil {
+		h.documents.Delete(r.Context(), key)
+		writeKYCError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(document)
+}
+
+// ReplaceKYCDocument godoc
+// @Summary Replace a KYC document
+// @Description Replaces one of the user's current documents with a new upload of the same type, for example to fix a blurry photo.
+// @Tags KYC
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Document ID"
+// @Param file formData file true "JPEG, PNG or PDF, at most 5 MB"
+// @Success 200 {object} models.KYCDocument
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST or VALIDATION_FAILED"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 404 {object} problem.Problem "DOCUMENT_NOT_FOUND"
+// @Failure 409 {object} problem.Problem "KYC_DOCUMENTS_LOCKED"
+// @Failure 413 {object} problem.Problem "DOCUMENT_TOO_LARGE"
+// @Failure 415 {object} problem.Problem "UNSUPPORTED_DOCUMENT_TYPE"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/kyc/documents/{id} [put]
+func (h *KYCHandler) ReplaceKYCDocument(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(middleware.GetUserIDFromContext(r))
+	documentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.Error(w, r, http.StatusNotFound, codeDocumentNotFound, "Document not found")
+		return
+	}
+
+	if !parseUpload(w, r) {
+		return
+	}
+	key, ok := storeUpload(w, r, h.documents, "file", "kyc_documents")
+	if !ok {
+		return
+	}
+	document, err := h.workflow.ReplaceDocument(uint(userID), uint(documentID), key, storage.ContentType(key))
+	if err != nil {
+		h.documents.Delete(r.Context(), key)
+		writeKYCError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(document)
+}
+
+// ListKYCDocuments godoc
+// @Summary List KYC documents
+// @Description Lists the user's current KYC documents, with the submission each was last part of
+// @Tags KYC
+// @Security BearerAuth
+// @Produce json
+// @Param all query bool false "Include replaced documents"
+// @Success 200 {array} models.KYCDocument
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/kyc/documents [get]
+func (h *KYCHandler) ListKYCDocuments(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(middleware.GetUserIDFromContext(r))
+
+	documents, err := h.workflow.Documents(uint(userID), r.URL.Query().Get("all") == "true")
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve documents")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(documents)
+}
+
+// UserKYCDocuments godoc
+// @Summary View a user's KYC documents
+// @Description Lists the user's KYC documents with signed URLs that are valid for 10 minutes. Requires the kyc:review permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Param all query bool false "Include replaced documents"
+// @Success 200 {array} KYCDocumentLink
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 404 {object} problem.Problem "USER_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/kyc/{id}/documents [get]
+func (h *KYCHandler) UserKYCDocuments(w http.ResponseWriter, r *http.Request) {
+	user, ok := loadUser(w, r, h.store.Users(), mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	documents, err := h.workflow.Documents(user.ID, r.URL.Query().Get("all") == "true")
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve documents")
+		return
+	}
+
+	links := make([]KYCDocumentLink, 0, len(documents))
+	for _, document := range documents {
+		url, expiresAt := h.documents.SignURL(document.StorageKey, documentURLTTL)
+		links = append(links, KYCDocumentLink{KYCDocument: document, URL: url, ExpiresAt: expiresAt})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+// maxUploadBody bounds a multipart upload: one document plus room for the
+// other form fields and the multipart framing.
+const maxUploadBody = storage.MaxDocumentSize + 1<<20
+
+// parseUpload parses a multipart form of at most maxUploadBody bytes. It
+// writes the error response and returns false if that fails. A body that is
+// not multipart is left to the checks of the form fields.
+func parseUpload(w http.ResponseWriter, r *http.Request) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBody)
+	err := r.ParseMultipartForm(maxUploadBody)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		problem.Error(w, r, http.StatusRequestEntityTooLarge, codeDocumentTooLarge, "Document is too large (at most 5 MB)")
+		return false
+	case err != nil && !errors.Is(err, http.ErrNotMultipart):
+		problem.Error(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid multipart form")
+		return false
+	}
+	return true
+}
+
+// storeUpload saves the file in the multipart field to the document store.
+// It writes the error response and returns false if that fails.
+func storeUpload(w http.ResponseWriter, r *http.Request, documents *storage.Vault, field, folder string) (string, bool) {
+	file, _, err := r.FormFile(field)
+	if err != nil {
+		writeError(w, r, invalidField(field, fieldRequired, "Failed to read "+field))
+		return "", false
+	}
+	defer file.Close()
+
+	// Stored encrypted under a generated key; the client's file name is not used
+	key, err := documents.Save(r.Context(), file, folder)
+	switch {
+	case errors.Is(err, storage.ErrTooLarge):
+		problem.Error(w, r, http.StatusRequestEntityTooLarge, codeDocumentTooLarge, "Document is too large (at most 5 MB)")
+		return "", false
+	case errors.Is(err, storage.ErrUnsupportedType):
+		problem.Error(w, r, http.StatusUnsupportedMediaType, codeUnsupportedDocumentType, "Documents must be JPEG, PNG or PDF")
+		return "", false
+	case err != nil:
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to save document")
+		return "", false
+	}
+	return key, true
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"neobank-lite/kyc"
+	"neobank-lite/middleware"
+	"neobank-lite/models"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+	"neobank-lite/storage"
+
+	"github.com/gorilla/mux"
+)
+
+var pngFile = append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+
+// uploadRequest is a multipart request by userID with the form fields and,
+// unless it is nil, file in the field "file".
+func uploadRequest(t *testing.T, method, target, userID string, fields map[string]string, file []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	if file != nil {
+		part, err := form.CreateFormFile("file", "upload")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(file)
+	}
+	form.Close()
+
+	r := httptest.NewRequest(method, target, &body)
+	r = r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, userID))
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	return r
+}
+
+// storedFiles counts the documents kept under root.
+func storedFiles(t *testing.T, root string) int {
+	t.Helper()
+	n := 0
+	err := filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func newTestKYCDocumentHandler(t *testing.T) (*KYCHandler, string) {
+	t.Helper()
+	root := t.TempDir()
+	documents, err := storage.NewVault(storage.NewLocalStore(root), bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := repository.NewMemoryStore()
+	user := models.User{Email: "a@example.com", KYCStatus: models.KYCPending}
+	if err := store.Users().Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	return NewKYCHandler(store, kyc.NewService(store, documents, nil), documents), root
+}
+
+func listKYCDocuments(t *testing.T, h *KYCHandler, target string) []models.KYCDocument {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ListKYCDocuments(w, asUser("GET", target, "", "1"))
+	var documents []models.KYCDocument
+	if err := json.NewDecoder(w.Body).Decode(&documents); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("listing documents: got %d, %v", w.Code, err)
+	}
+	return documents
+}
+
+func TestUploadKYCDocumentRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]string
+		file   []byte
+		status int
+		code   string
+	}{
+		{"missing type", nil, pngFile, http.StatusBadRequest, problem.CodeValidationFailed},
+		{"unknown type", map[string]string{"type": "diploma"}, pngFile, http.StatusBadRequest, problem.CodeValidationFailed},
+		{"unknown type checked first", map[string]string{"type": "diploma"}, []byte("just some text"), http.StatusBadRequest, problem.CodeValidationFailed},
+		{"missing file", map[string]string{"type": kyc.DocumentPassport}, nil, http.StatusBadRequest, problem.CodeValidationFailed},
+		{"unsupported file", map[string]string{"type": kyc.DocumentPassport}, []byte("just some text"), http.StatusUnsupportedMediaType, codeUnsupportedDocumentType},
+		{"file over 5 MB", map[string]string{"type": kyc.DocumentPassport}, make([]byte, storage.MaxDocumentSize+1), http.StatusRequestEntityTooLarge, codeDocumentTooLarge},
+		{"body over the limit", map[string]string{"type": kyc.DocumentPassport, "padding": strings.Repeat("x", maxUploadBody)}, pngFile, http.StatusRequestEntityTooLarge, codeDocumentTooLarge},
+	}
+	for _, tt := range tests {
+		h, root := newTestKYCDocumentHandler(t)
+		w := httptest.NewRecorder()
+		h.UploadKYCDocument(w, uploadRequest(t, "POST", "/api/kyc/documents", "1", tt.fields, tt.file))
+
+		var body struct{ Code string }
+		json.NewDecoder(w.Body).Decode(&body)
+		if w.Code != tt.status || body.Code != tt.code {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, w.Code, body.Code, tt.status, tt.code)
+		}
+		if n := storedFiles(t, root); n != 0 {
+			t.Errorf("%s: %d files left in storage", tt.name, n)
+		}
+	}
+
+	h, _ := newTestKYCDocumentHandler(t)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/kyc/documents", bytes.NewReader([]byte("--broken\r\n")))
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=broken")
+	h.UploadKYCDocument(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("malformed form: got %d", w.Code)
+	}
+}
+
+// Documents can be uploaded and replaced until KYC is submitted, which links
+// the current ones to the submission and locks them.
+func TestKYCDocuments(t *testing.T) {
+	h, root := newTestKYCDocumentHandler(t)
+
+	w := httptest.NewRecorder()
+	h.UploadKYCDocument(w, uploadRequest(t, "POST", "/api/kyc/documents", "1", map[string]string{"type": kyc.DocumentPassport}, pngFile))
+	var uploaded models.KYCDocument
+	json.NewDecoder(w.Body).Decode(&uploaded)
+	if w.Code != http.StatusCreated || uploaded.Type != kyc.DocumentPassport || uploaded.ContentType != "image/png" {
+		t.Fatalf("upload: got %d %+v", w.Code, uploaded)
+	}
+
+	replace := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := uploadRequest(t, "PUT", "/api/kyc/documents/"+id, "1", nil, pngFile)
+		h.ReplaceKYCDocument(w, mux.SetURLVars(r, map[string]string{"id": id}))
+		return w
+	}
+	w = replace("1")
+	var replacement models.KYCDocument
+	json.NewDecoder(w.Body).Decode(&replacement)
+	if w.Code != http.StatusOK || replacement.ID == uploaded.ID || replacement.Type != kyc.DocumentPassport {
+		t.Fatalf("replace: got %d %+v", w.Code, replacement)
+	}
+	if w := replace("99"); w.Code != http.StatusNotFound {
+		t.Errorf("replacing a missing document: got %d", w.Code)
+	}
+
+	if documents := listKYCDocuments(t, h, "/api/kyc/documents"); len(documents) != 1 || documents[0].ID != replacement.ID {
+		t.Errorf("current documents: %+v", documents)
+	}
+	all := listKYCDocuments(t, h, "/api/kyc/documents?all=true")
+	if len(all) != 2 {
+		t.Fatalf("all documents: %+v", all)
+	}
+	for _, document := range all {
+		if replaced := document.ReplacedAt != nil; replaced != (document.ID == uploaded.ID) {
+			t.Errorf("document %d: replaced = %v", document.ID, replaced)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	h.SubmitKYC(w, asUser("POST", "/api/kyc/verify", `{}`, "1"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("submit: got %d %s", w.Code, w.Body)
+	}
+	documents := listKYCDocuments(t, h, "/api/kyc/documents")
+	if len(documents) != 1 || documents[0].SubmissionID == nil {
+		t.Errorf("documents after submitting: %+v", documents)
+	}
+
+	stored := storedFiles(t, root)
+	w = httptest.NewRecorder()
+	h.UploadKYCDocument(w, uploadRequest(t, "POST", "/api/kyc/documents", "1", map[string]string{"type": kyc.DocumentSelfie}, pngFile))
+	locked := []*httptest.ResponseRecorder{w, replace(strconv.Itoa(int(replacement.ID)))}
+	for i, w := range locked {
+		var body struct{ Code string }
+		json.NewDecoder(w.Body).Decode(&body)
+		if w.Code != http.StatusConflict || body.Code != codeKYCDocumentsLocked {
+			t.Errorf("change %d after submitting: got %d %s", i, w.Code, body.Code)
+		}
+	}
+	if n := storedFiles(t, root); n != stored {
+		t.Errorf("refused uploads were kept: %d files, want %d", n, stored)
+	}
+}
@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"neobank-lite/dto"
+	"neobank-lite/middleware"
+	"neobank-lite/models"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+
+	"github.com/gorilla/mux"
+)
+
+// KYCQueueItem is a user waiting for, or in, KYC review.
+type KYCQueueItem struct {
+	UserSummary
+	NationalID  string     `json:"national_id" example:"uploads/national_id_10.jpg"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+	ReviewerID  *uint      `json:"reviewer_id,omitempty" example:"3"`
+}
+
+// KYCQueue godoc
+// @Summary List the KYC review queue
+// @Description Users whose KYC was submitted or is under review, longest waiting first. Requires the kyc:review permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Only submitted (unclaimed) or under_review users" Enums(submitted, under_review)
+// @Param reviewer query int false "Only users under review by this reviewer"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Success 200 {array} KYCQueueItem
+// @Failure 400 {object} problem.Problem "VALIDATION_FAILED: invalid filter"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/kyc/queue [get]
+func (h *KYCHandler) KYCQueue(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, err := parseHistoryLimit(q.Get("limit"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var filter repository.KYCQueueFilter
+	switch v := q.Get("status"); v {
+	case "", models.KYCSubmitted, models.KYCUnderReview:
+		filter.Status = v
+	default:
+		writeError(w, r, invalidField("status", fieldInvalid, "status must be submitted or under_review"))
+		return
+	}
+	if v := q.Get("reviewer"); v != "" {
+		reviewerID, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, r, invalidField("reviewer", fieldInvalid, "reviewer must be a user ID"))
+			return
+		}
+		reviewer := uint(reviewerID)
+		filter.ReviewerID = &reviewer
+	}
+
+	users, err := h.store.Users().KYCQueue(filter, limit)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to list KYC queue")
+		return
+	}
+	items := make([]KYCQueueItem, 0, len(users))
+	for _, user := range users {
+		items = append(items, KYCQueueItem{
+			UserSummary: summarizeUser(user),
+			NationalID:  user.NationalID,
+			SubmittedAt: user.KYCSubmittedAt,
+			ReviewerID:  user.KYCReviewerID,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// StartKYCReview godoc
+// @Summary Take a KYC submission under review
+// @Description Claims a submitted user for review by the caller, taking them off the unclaimed queue. Requires the kyc:review permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} UserSummary
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN, or KYC_OWN_REVIEW for the reviewer's own KYC"
+// @Failure 404 {object} problem.Problem "USER_NOT_FOUND"
+// @Failure 409 {object} problem.Problem "KYC_INVALID_TRANSITION: KYC is not submitted"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/kyc/{id}/review [post]
+func (h *KYCHandler) StartKYCReview(w http.ResponseWriter, r *http.Request) {
+	userID, reviewerID, ok := kycReviewIDs(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.workflow.StartReview(userID, reviewerID)
+	writeKYCResult(w, r, user, err)
+}
+
+// ApproveKYC godoc
+// @Summary Approve a user's KYC
+// @Description Ends a review by marking the user's KYC as verified so they can move money. The user is notified. Requires the kyc:review permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} UserSummary
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN, or KYC_OWN_REVIEW for the reviewer's own KYC"
+// @Failure 404 {object} problem.Problem "USER_NOT_FOUND"
+// @Failure 409 {object} problem.Problem "KYC_INVALID_TRANSITION: KYC is not under review, or KYC_NOT_REVIEWER: another reviewer took it"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/kyc/{id}/approve [post]
+func (h *KYCHandler) ApproveKYC(w http.ResponseWriter, r *http.Request) {
+	h.decideKYC(w, r, models.KYCVerified)
+}
+
+// RejectKYC godoc
+// @Summary Reject a user's KYC
+// @Description Ends a review by rejecting the user's KYC. The reason is shown to the user, who is notified. Requires the kyc:review permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param decision body dto.KYCDecisionRequest true "Reason for the rejection"
+// @Success 200 {object} UserSummary
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST, or VALIDATION_FAILED without a reason"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN, or KYC_OWN_REVIEW for the reviewer's own KYC"
+// @Failure 404 {object} problem.Problem "USER_NOT_FOUND"
+// @Failure 409 {object} problem.Problem "KYC_INVALID_TRANSITION: KYC is not under review, or KYC_NOT_REVIEWER: another reviewer took it"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/kyc/{id}/reject [post]
+func (h *KYCHandler) RejectKYC(w http.ResponseWriter, r *http.Request) {
+	h.decideKYC(w, r, models.KYCRejected)
+}
+
+// RequestKYCResubmission godoc
+// @Summary Ask a user to resubmit KYC
+// @Description Ends a review by asking the user to fix their details and submit again. The reason is shown to the user, who is notified. Requires the kyc:review permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param decision body dto.KYCDecisionRequest true "What the user needs to fix"
+// @Success 200 {object} UserSummary
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST, or VALIDATION_FAILED without a reason"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN, or KYC_OWN_REVIEW for the reviewer's own KYC"
+// @Failure 404 {object} problem.Problem "USER_NOT_FOUND"
+// @Failure 409 {object} problem.Problem "KYC_INVALID_TRANSITION: KYC is not under review, or KYC_NOT_REVIEWER: another reviewer took it"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/kyc/{id}/request-resubmission [post]
+func (h *KYCHandler) RequestKYCResubmission(w http.ResponseWriter, r *http.Request) {
+	h.decideKYC(w, r, models.KYCResubmissionRequired)
+}
+
+// KYCHistory godoc
+// @Summary View a user's KYC history
+// @Description Every KYC status change of a user with who made it, when and why, oldest first. Requires the kyc:review permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {array} models.KYCEvent
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 404 {object} problem.Problem "USER_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/kyc/{id}/history [get]
+func (h *KYCHandler) KYCHistory(w http.ResponseWriter, r *http.Request) {
+	user, ok := loadUser(w, r, h.store.Users(), mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	events, err := h.workflow.History(user.ID)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve KYC history")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// KYCChecks godoc
+// @Summary View a user's provider checks
+// @Description Every submission of the user's KYC to the identity verification provider and its result, oldest first. Requires the kyc:review permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {array} models.KYCCheck
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 404 {object} problem.Problem "USER_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /admin/kyc/{id}/checks [get]
+func (h *KYCHandler) KYCChecks(w http.ResponseWriter, r *http.Request) {
+	user, ok := loadUser(w, r, h.store.Users(), mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	checks, err := h.workflow.Checks(user.ID)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve KYC checks")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(checks)
+}
+
+func (h *KYCHandler) decideKYC(w http.ResponseWriter, r *http.Request, status string) {
+	var req dto.KYCDecisionRequest
+	if r.ContentLength != 0 {
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+	}
+	userID, reviewerID, ok := kycReviewIDs(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.workflow.Decide(userID, reviewerID, status, req.Reason)
+	writeKYCResult(w, r, user, err)
+}
+
+// kycReviewIDs returns the user being reviewed and the reviewer making the call.
+func kycReviewIDs(w http.ResponseWriter, r *http.Request) (userID, reviewerID uint, ok bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.Error(w, r, http.StatusNotFound, codeUserNotFound, "User not found")
+		return 0, 0, false
+	}
+	reviewer, _ := strconv.Atoi(middleware.GetUserIDFromContext(r))
+	return uint(id), uint(reviewer), true
+}
+
+func writeKYCResult(w http.ResponseWriter, r *http.Request, user models.User, err error) {
+	if err != nil {
+		writeKYCError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summarizeUser(user))
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"encoding/json"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+	"net/http"
+)
+
+// LedgerHandler serves the reports staff use to check the books.
+type LedgerHandler struct {
+	store repository.Store
+}
+
+func NewLedgerHandler(store repository.Store) *LedgerHandler {
+	return &LedgerHandler{store: store}
+}
+
+// TrialBalance godoc
+// @Summary Ledger trial balance
+// @Description Sums all ledger entries per ledger account. Total debits must always equal total credits. Requires the ledger:read permission.
+// @Tags Ledger
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} ledger.TrialBalance
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/ledger/trial-balance [get]
+func (h *LedgerHandler) TrialBalance(w http.ResponseWriter, r *http.Request) {
+	report, err := h.store.Ledger().TrialBalance()
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to compute trial balance")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// Reconciliation godoc
+// @Summary Reconcile balances against the ledger
+// @Description Lists customer accounts whose stored balance differs from the balance derived from ledger entries. Requires the ledger:read permission.
+// @Tags Ledger
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} ledger.Mismatch
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "FORBIDDEN"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/ledger/reconciliation [get]
+func (h *LedgerHandler) Reconciliation(w http.ResponseWriter, r *http.Request) {
+	mismatches, err := h.store.Ledger().Reconcile()
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to reconcile balances")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mismatches)
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"neobank-lite/middleware"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+)
+
+// NotificationHandler serves the user's notification inbox.
+type NotificationHandler struct {
+	store repository.Store
+}
+
+func NewNotificationHandler(store repository.Store) *NotificationHandler {
+	return &NotificationHandler{store: store}
+}
+
+// ListNotifications godoc
+// @Summary List notifications
+// @Description The user's notifications, such as KYC decisions, newest first
+// @Tags Notifications
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Number of notifications (default 50, max 200)"
+// @Success 200 {array} models.Notification
+// @Failure 400 {object} problem.Problem "VALIDATION_FAILED: invalid limit"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/notifications [get]
+func (h *NotificationHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(middleware.GetUserIDFromContext(r))
+	limit, err := parseHistoryLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	notifications, err := h.store.Notifications().Inbox(uint(userID), limit)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve notifications")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notifications)
+}
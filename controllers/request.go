@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"neobank-lite/problem"
+	"neobank-lite/validate"
+)
+
+// maxRequestBody limits JSON request bodies.
+const maxRequestBody = 1 << 20
+
+// fieldUnknown is the FieldError code for a field the endpoint does not take.
+const fieldUnknown = "unknown"
+
+// decodeRequest reads the JSON body into dst and validates it. Unknown
+// fields, bodies over 1 MB and trailing data after the JSON value are
+// rejected. It writes the problem and returns false if the request is not
+// acceptable.
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err == nil && dec.Decode(&json.RawMessage{}) != io.EOF {
+		err = errors.New("request body must contain a single JSON value")
+	}
+	if err != nil {
+		problem.Write(w, r, decodeProblem(err))
+		return false
+	}
+	return validRequest(w, r, dst)
+}
+
+// validRequest checks dst against its validate tags. It writes the problem
+// and returns false if a field is invalid.
+func validRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if errs := validate.Struct(dst); len(errs) > 0 {
+		problem.Write(w, r, problem.Invalid(errs...))
+		return false
+	}
+	return true
+}
+
+// decodeProblem explains why a body could not be decoded.
+func decodeProblem(err error) *problem.Problem {
+	var tooLarge *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &tooLarge):
+		return problem.New(http.StatusRequestEntityTooLarge, problem.CodeRequestTooLarge, "Request body must not exceed 1 MB")
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return invalidField(typeErr.Field, fieldInvalid, typeErr.Field+" must be a "+jsonType(typeErr.Type.Kind().String()))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return invalidField(name, fieldUnknown, "unknown field "+name)
+	case errors.Is(err, io.EOF):
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "Request body is empty")
+	}
+	return problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request body: "+err.Error())
+}
+
+// jsonType names a Go kind the way a JSON client thinks of it.
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "bool":
+		return "boolean"
+	case kind == "struct", kind == "map":
+		return "object"
+	case kind == "slice", kind == "array":
+		return "list"
+	}
+	return kind
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"neobank-lite/dto"
+	"neobank-lite/problem"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	valid := `{"phone_number":"+251911234567","account_type":"savings"}`
+	tests := []struct {
+		name, body string
+		status     int
+		code       string
+		field      string // of the first FieldError, if any
+	}{
+		{"valid", valid, http.StatusOK, "", ""},
+		{"trailing whitespace", valid + "\n\t ", http.StatusOK, "", ""},
+		{"empty", "", http.StatusBadRequest, problem.CodeInvalidRequest, ""},
+		{"malformed", `{"phone_number":`, http.StatusBadRequest, problem.CodeInvalidRequest, ""},
+		{"not an object", `["+251911234567"]`, http.StatusBadRequest, problem.CodeInvalidRequest, ""},
+		{"second value", valid + valid, http.StatusBadRequest, problem.CodeInvalidRequest, ""},
+		{"trailing data", valid + " x", http.StatusBadRequest, problem.CodeInvalidRequest, ""},
+		{"unknown field", `{"phone_number":"+251911234567","balance":"1000000"}`, http.StatusBadRequest, problem.CodeValidationFailed, "balance"},
+		{"wrong type", `{"phone_number":251911234567}`, http.StatusBadRequest, problem.CodeValidationFailed, "phone_number"},
+		{"invalid field", `{"phone_number":"0911234567"}`, http.StatusBadRequest, problem.CodeValidationFailed, "phone_number"},
+		{"over 1 MB", `{"phone_number":"` + strings.Repeat(" ", maxRequestBody) + `"}`, http.StatusRequestEntityTooLarge, problem.CodeRequestTooLarge, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		var req dto.CreateAccountRequest
+		ok := decodeRequest(w, httptest.NewRequest("POST", "/api/account/create", strings.NewReader(tt.body)), &req)
+
+		if ok != (tt.status == http.StatusOK) {
+			t.Errorf("%s: decodeRequest returned %v", tt.name, ok)
+		}
+		if ok {
+			if w.Body.Len() != 0 {
+				t.Errorf("%s: wrote %s", tt.name, w.Body)
+			}
+			if req.PhoneNumber != "+251911234567" || req.AccountType != "savings" {
+				t.Errorf("%s: decoded %+v", tt.name, req)
+			}
+			continue
+		}
+
+		var p problem.Problem
+		json.NewDecoder(w.Body).Decode(&p)
+		if w.Code != tt.status || p.Code != tt.code {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, w.Code, p.Code, tt.status, tt.code)
+		}
+		if tt.field != "" && (len(p.Errors) == 0 || p.Errors[0].Field != tt.field) {
+			t.Errorf("%s: got field errors %+v, want one for %s", tt.name, p.Errors, tt.field)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"neobank-lite/dto"
+	"neobank-lite/ledger"
+	"neobank-lite/middleware"
+	"neobank-lite/models"
+	"neobank-lite/money"
+	"neobank-lite/repository"
+
+	"github.com/gorilla/mux"
+)
+
+// ReversalJob carries the reversal-specific parts of a TransactionJob.
+// Result is filled in by the worker before it responds.
+type ReversalJob struct {
+	OriginalID int
+	Reason     string
+	Force      bool
+	Result     models.Transaction
+}
+
+// checkReversible rejects transactions that can't be reversed at all.
+func checkReversible(original models.Transaction) error {
+	switch original.Type {
+	case "deposit", "transfer", "withdraw":
+	default:
+		return txError(models.ReasonNotReversible, original.Type+" transactions cannot be reversed")
+	}
+	if original.Status != models.TransactionSuccess {
+		return txError(models.ReasonNotReversible, "only successful transactions can be reversed")
+	}
+	return nil
+}
+
+// reversedSoFar sums the successful reversals already posted against a
+// transaction, in the original's currency. Reversals of a converted transfer
+// hold that amount in to_amount.
+func reversedSoFar(transactions repository.TransactionRepository, original models.Transaction) (money.Money, error) {
+	reversals, err := transactions.Reversals(original.ID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	total := money.Zero(original.Amount.Currency)
+	for _, reversal := range reversals {
+		refunded := reversal.Amount
+		if reversal.FXRate != "" {
+			refunded = *reversal.ToAmount
+		}
+		if total, err = total.Add(refunded); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// reversalDebit is what the receiver of a converted transfer gives back when
+// amount is refunded: amount converted at the original rate, except that the
+// final refund takes whatever is left of ToAmount so rounding leaves no residue.
+func reversalDebit(transactions repository.TransactionRepository, original models.Transaction, amount, remaining money.Money) (money.Money, error) {
+	if amount != remaining {
+		return amount.Convert(original.FXRate, original.ToAmount.Currency)
+	}
+	reversals, err := transactions.Reversals(original.ID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	left := *original.ToAmount
+	for _, reversal := range reversals {
+		if left, err = left.Sub(reversal.Amount); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return left, nil
+}
+
+// reversalParties returns the account that gives the money back and the one
+// that receives it. An empty account number stands for the cash account and
+// is stored as such on the reversal transaction.
+func reversalParties(original models.Transaction) (payer, payee string) {
+	switch original.Type {
+	case "transfer":
+		return original.ToAccount, original.FromAccount
+	case "deposit":
+		return original.ToAccount, ""
+	default: // withdraw
+		return "", original.FromAccount
+	}
+}
+
+func reversalLedgerAccount(accountNumber string) string {
+	if accountNumber == "" {
+		return ledger.CashAccount
+	}
+	return ledger.CustomerAccount(accountNumber)
+}
+
+// handleReversal posts a compensating transaction for job.OriginalID. The
+// original is marked reversed once the full amount has been given back.
+func (h *TransactionHandler) handleReversal(job *ReversalJob, amount money.Money) error {
+	original, err := h.store.Transactions().Get(job.OriginalID)
+	if err != nil {
+		return txError(codeTxNotFound, "transaction not found")
+	}
+	payer, payee := reversalParties(original)
+
+	originalID := original.ID
+	reversal, err := h.beginTransaction(models.Transaction{
+		FromAccount: payer,
+		ToAccount:   payee,
+		Amount:      amount,
+		Type:        "reversal",
+		ReversalOf:  &originalID,
+		Description: job.Reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = h.store.Atomic(func(s repository.Store) error {
+		// Locking the original serializes concurrent reversals of it
+		original, err := s.Transactions().Lock(originalID)
+		if err != nil {
+			return err
+		}
+		if err := checkReversible(original); err != nil {
+			return err
+		}
+
+		reversed, err := reversedSoFar(s.Transactions(), original)
+		if err != nil {
+			return err
+		}
+		remaining, err := original.Amount.Sub(reversed)
+		if err != nil {
+			return err
+		}
+		if cmp, err := amount.Cmp(remaining); err != nil {
+			return err
+		} else if cmp > 0 {
+			return txError(models.ReasonReversalTooLarge, "only "+remaining.String()+" is left to reverse")
+		}
+
+		// A converted transfer is refunded at its original rate: the payer
+		// gives back debited in its own currency and the payee gets amount
+		debited := amount
+		if original.FXRate != "" {
+			if debited, err = reversalDebit(s.Transactions(), original, amount, remaining); err != nil {
+				return err
+			}
+			credited := amount
+			reversal.Amount, reversal.ToAmount, reversal.FXRate = debited, &credited, original.FXRate
+			if err := s.Transactions().SetConversion(&reversal); err != nil {
+				return err
+			}
+		}
+
+		var numbers []string
+		for _, n := range []string{payer, payee} {
+			if n != "" {
+				numbers = append(numbers, n)
+			}
+		}
+		locked, err := s.Accounts().Lock(numbers...)
+		var missing *repository.AccountNotFoundError
+		if errors.As(err, &missing) {
+			return txError(models.ReasonAccountNotFound, missing.Error())
+		} else if err != nil {
+			return err
+		}
+
+		// Force also overrides a freeze, e.g. to refund a victim of fraud
+		if !job.Force {
+			for _, n := range numbers {
+				if err := checkActive(locked[n]); err != nil {
+					return err
+				}
+			}
+		}
+
+		if payer != "" {
+			account := locked[payer]
+			if cmp, err := account.Balance.Cmp(debited); err != nil {
+				return err
+			} else if cmp < 0 && !job.Force {
+				return txError(models.ReasonInsufficientFunds, "account "+payer+" no longer has the funds; use force to reverse anyway")
+			}
+			account.Balance, _ = account.Balance.Sub(debited)
+			if err := s.Accounts().Save(account); err != nil {
+				return err
+			}
+		}
+		if payee != "" {
+			account := locked[payee]
+			balance, err := account.Balance.Add(amount)
+			if err != nil {
+				return err
+			}
+			account.Balance = balance
+			if err := s.Accounts().Save(account); err != nil {
+				return err
+			}
+		}
+
+		err = s.Ledger().Post(reversal.ID, ledger.TransferPostings(
+			reversalLedgerAccount(payer),
+			reversalLedgerAccount(payee),
+			debited, amount,
+		)...)
+		if err != nil {
+			return err
+		}
+
+		if amount == remaining {
+			original.Status = models.TransactionReversed
+			if err := s.Transactions().SetStatus(&original); err != nil {
+				return err
+			}
+		}
+		return finishTransaction(s.Transactions(), &reversal, nil)
+	})
+	if err != nil {
+		err = finishTransaction(h.store.Transactions(), &reversal, err)
+	}
+	job.Result = reversal
+	return err
+}
+
+// ReverseTransaction godoc
+// @Summary Reverse a transaction
+// @Description Posts a compensating transaction linked to the original through reversal_of. Omitting amount reverses whatever has not been reversed yet; a smaller amount makes a partial refund. The amount is in the original transaction's currency; a converted transfer is refunded at its original FX rate. The original is marked reversed once fully reversed. Fails with INSUFFICIENT_FUNDS if the account being debited no longer has the funds, or ACCOUNT_FROZEN if either account is frozen, unless force is set. Requires the transactions:reverse permission.
+// @Tags Transaction
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Param reversal body dto.ReversalRequest true "Reversal details"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 200 {object} models.Transaction
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST, VALIDATION_FAILED or INVALID_AMOUNT"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "ACCOUNT_FROZEN, or FORBIDDEN without the permission"
+// @Failure 404 {object} problem.Problem "TRANSACTION_NOT_FOUND"
+// @Failure 409 {object} problem.Problem "NOT_REVERSIBLE or IDEMPOTENCY_KEY_IN_PROGRESS"
+// @Failure 422 {object} problem.Problem "INSUFFICIENT_FUNDS, REVERSAL_EXCEEDS_AMOUNT or IDEMPOTENCY_KEY_REUSED"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Failure 503 {object} problem.Problem "SERVICE_UNAVAILABLE while the server shuts down"
+// @Security BearerAuth
+// @Router /api/transaction/{id}/reverse [post]
+func (h *TransactionHandler) ReverseTransaction(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(middleware.GetUserIDFromContext(r))
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeTransactionError(w, r, txError(codeInvalidRequest, "invalid transaction ID"))
+		return
+	}
+
+	var req dto.ReversalRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	original, err := h.store.Transactions().Get(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeTransactionError(w, r, txError(codeTxNotFound, "transaction not found"))
+		return
+	} else if err != nil {
+		writeTransactionError(w, r, asTransactionError(err))
+		return
+	}
+	if err := checkReversible(original); err != nil {
+		writeTransactionError(w, r, asTransactionError(err))
+		return
+	}
+
+	var amount money.Money
+	if req.Amount == "" {
+		reversed, err := reversedSoFar(h.store.Transactions(), original)
+		if err != nil {
+			writeTransactionError(w, r, asTransactionError(err))
+			return
+		}
+		amount, _ = original.Amount.Sub(reversed)
+	} else {
+		amount, err = req.Amount.Money(original.Amount.Currency)
+	}
+	if err != nil || !amount.IsPositive() {
+		writeTransactionError(w, r, txError(codeInvalidAmount, "invalid amount"))
+		return
+	}
+
+	reversal := &ReversalJob{OriginalID: original.ID, Reason: req.Reason, Force: req.Force}
+	err = h.submit(r.Context(), TransactionJob{
+		Type:     "reversal",
+		UserID:   userID,
+		Amount:   amount,
+		Reversal: reversal,
+	})
+	if err != nil {
+		writeTransactionError(w, r, asTransactionError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reversal.Result)
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"neobank-lite/middleware"
+	"neobank-lite/models"
+	"neobank-lite/problem"
+	"neobank-lite/statement"
+
+	"github.com/gorilla/mux"
+)
+
+type statementFormat struct {
+	contentType string
+	extension   string
+	write       func(io.Writer, statement.Statement) error
+}
+
+var statementFormats = map[string]statementFormat{
+	"csv": {"text/csv; charset=utf-8", "csv", statement.WriteCSV},
+	"pdf": {"application/pdf", "pdf", statement.WritePDF},
+	"ofx": {"application/x-ofx", "ofx", statement.WriteOFX},
+}
+
+// AccountStatement godoc
+// @Summary Download an account statement
+// @Description Statement of one of the user's accounts with the opening balance, every transaction with its running balance, and the closing balance. Only transactions that moved money are listed. Defaults to the current month up to now.
+// @Tags Account
+// @Security BearerAuth
+// @Produce text/csv
+// @Produce application/pdf
+// @Produce application/x-ofx
+// @Param number path string true "Account number"
+// @Param from query string false "Start of the period, YYYY-MM-DD or RFC 3339 (default: first day of this month)"
+// @Param to query string false "End of the period, YYYY-MM-DD (whole day included) or RFC 3339 (exclusive, default: now)"
+// @Param format query string false "Statement format (default csv)" Enums(csv, pdf, ofx)
+// @Success 200 {file} file
+// @Failure 400 {object} problem.Problem "VALIDATION_FAILED: invalid period or format"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "ACCOUNT_NOT_OWNED"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /api/account/{number}/statement [get]
+func (h *AccountHandler) AccountStatement(w http.ResponseWriter, r *http.Request) {
+	userIDStr := middleware.GetUserIDFromContext(r)
+	userID, _ := strconv.Atoi(userIDStr)
+
+	account, err := resolveAccount(h.store.Accounts(), userID, mux.Vars(r)["number"])
+	if err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	q := r.URL.Query()
+	name := q.Get("format")
+	if name == "" {
+		name = "csv"
+	}
+	format, ok := statementFormats[name]
+	if !ok {
+		writeError(w, r, invalidField("format", fieldInvalid, "format must be csv, pdf or ofx"))
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := now
+	if v := q.Get("from"); v != "" {
+		t, err := parseHistoryDate("from", v, false)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		from = *t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := parseHistoryDate("to", v, true)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		to = *t
+	}
+	if !from.Before(to) {
+		writeError(w, r, invalidField("from", fieldInvalid, "from must be before to"))
+		return
+	}
+
+	s, err := statement.Build(h.store.Ledger(), account, from, to)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to build statement")
+		return
+	}
+
+	// Render fully before writing so a failure can still become a 500
+	var buf bytes.Buffer
+	if err := format.write(&buf, s); err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to render statement")
+		return
+	}
+
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+statementFilename(account, from, to)+"."+format.extension+`"`)
+	buf.WriteTo(w)
+}
+
+func statementFilename(account models.Account, from, to time.Time) string {
+	// to is exclusive; name the file after the last day it covers
+	last := to.Add(-time.Nanosecond)
+	return "statement-" + account.AccountNumber + "-" + from.UTC().Format("20060102") + "-" + last.UTC().Format("20060102")
+}
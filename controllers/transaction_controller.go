@@ -1,163 +1,539 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"neobank-lite/database"
+	"neobank-lite/config"
 	"neobank-lite/dto"
+	"neobank-lite/fx"
+	"neobank-lite/ledger"
+	"neobank-lite/metrics"
 	"neobank-lite/middleware"
 	"neobank-lite/models"
-)
+	"neobank-lite/money"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
 
-var (
-	transactionChan = make(chan TransactionJob, 100) // buffered channel
-	mu              sync.Mutex                       // mutex for safe access
+	"github.com/gorilla/mux"
 )
 
 type TransactionJob struct {
-	Type      string
-	UserID    int
-	Amount    float64
-	ToAccount string
-	Response  chan error
+	Type        string
+	UserID      int
+	FromAccount string // account the job acts on; ownership is checked before queuing
+	Amount      money.Money
+	ToAccount   string
+	Reversal    *ReversalJob
+	Response    chan error
+
+	queuedAt time.Time
 }
 
+var (
+	transactionsTotal = metrics.NewCounterVec("neobank_transactions_total",
+		"Processed transactions by type and final status.", "type", "status")
+	transactionAmounts = metrics.NewCounterVec("neobank_transaction_amount_total",
+		"Sum of processed transaction amounts in major units, by type, final status and currency.", "type", "status", "currency")
+	queueWait = metrics.NewHistogramVec("neobank_transaction_queue_wait_seconds",
+		"Time transaction jobs spend queued before a worker picks them up.", metrics.DefaultBuckets, "type")
+)
+
+// metricsHandler is the handler the queue gauges report on, the one created
+// last. The server creates a single one.
+var metricsHandler atomic.Pointer[TransactionHandler]
+
 func init() {
-	go processTransactions()
+	queueGauge := func(name, help string, value func(workers, queued int) int) {
+		metrics.NewGaugeFunc(name, help, nil, func() []metrics.Sample {
+			h := metricsHandler.Load()
+			if h == nil {
+				return nil
+			}
+			workers, queued, _ := h.QueueStatus()
+			return []metrics.Sample{{Value: float64(value(workers, queued))}}
+		})
+	}
+	queueGauge("neobank_transaction_queue_depth", "Transaction jobs waiting for a worker.",
+		func(workers, queued int) int { return queued })
+	queueGauge("neobank_transaction_workers", "Running transaction workers.",
+		func(workers, queued int) int { return workers })
+}
+
+// TransactionHandler serves the endpoints that move money and list it.
+// Movements are queued and consumed by a pool of workers. Consistency comes
+// from the accounts locked inside each unit of work, not from running one job
+// at a time, so unrelated accounts are processed in parallel.
+type TransactionHandler struct {
+	store     repository.Store
+	jobs      chan TransactionJob
+	workers   int
+	maxAmount *money.Money // in a base currency, see checkTransactionLimit
+
+	mu       sync.RWMutex  // held by senders so that jobs is not closed under them
+	stop     chan struct{} // closed when shutdown begins, before jobs
+	stopping atomic.Bool
+	running  atomic.Int32 // workers that have not exited
 }
 
-func processTransactions() {
-	for job := range transactionChan {
+var (
+	// errShuttingDown refuses jobs submitted after shutdown began.
+	errShuttingDown = txError(problem.CodeUnavailable, "server is shutting down, retry shortly")
+	// errNotQueued is returned when the request ends while its job waits for
+	// room in a full queue.
+	errNotQueued = txError(problem.CodeUnavailable, "too many transactions in progress, retry shortly")
+)
+
+// NewTransactionHandler returns a handler processing movements against
+// store. Nothing is processed until Start is called. The queue metrics
+// report on the handler created last.
+func NewTransactionHandler(store repository.Store, cfg config.Transactions) *TransactionHandler {
+	h := &TransactionHandler{
+		store:     store,
+		jobs:      make(chan TransactionJob, cfg.QueueSize),
+		stop:      make(chan struct{}),
+		workers:   cfg.Workers,
+		maxAmount: cfg.MaxAmount,
+	}
+	metricsHandler.Store(h)
+	return h
+}
+
+// Start runs the workers until ctx is done. From then on new jobs are
+// refused with 503, and the workers finish the jobs already queued before
+// they exit. The returned channel is closed once they have.
+func (h *TransactionHandler) Start(ctx context.Context) <-chan struct{} {
+	var wg sync.WaitGroup
+	for i := 0; i < h.workers; i++ {
+		wg.Add(1)
+		h.running.Add(1)
+		go func() {
+			defer wg.Done()
+			defer h.running.Add(-1)
+			h.processTransactions()
+		}()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		// Senders waiting for room in the queue give up on stop, so the
+		// lock is not held up by a full queue.
+		h.stopping.Store(true)
+		close(h.stop)
+		h.mu.Lock()
+		close(h.jobs)
+		h.mu.Unlock()
+
+		wg.Wait()
+		close(drained)
+	}()
+	return drained
+}
+
+// QueueStatus reports how many workers are running, how many jobs wait in
+// the queue and whether shutdown has begun.
+func (h *TransactionHandler) QueueStatus() (workers, queued int, stopping bool) {
+	return int(h.running.Load()), len(h.jobs), h.stopping.Load()
+}
+
+// submit queues job and waits for its result. If the queue is full it waits
+// for room until ctx is done or shutdown begins; once queued, the job runs
+// to the end whatever happens to ctx.
+func (h *TransactionHandler) submit(ctx context.Context, job TransactionJob) error {
+	job.Response = make(chan error, 1)
+	job.queuedAt = time.Now()
+
+	h.mu.RLock()
+	if h.stopping.Load() {
+		h.mu.RUnlock()
+		return errShuttingDown
+	}
+	select {
+	case h.jobs <- job:
+	case <-h.stop:
+		h.mu.RUnlock()
+		return errShuttingDown
+	case <-ctx.Done():
+		h.mu.RUnlock()
+		return errNotQueued
+	}
+	h.mu.RUnlock()
+
+	return <-job.Response
+}
+
+func (h *TransactionHandler) processTransactions() {
+	for job := range h.jobs {
+		queueWait.Observe(time.Since(job.queuedAt).Seconds(), job.Type)
+
+		var err error
 		switch job.Type {
 		case "deposit":
-			err := handleDeposit(job.UserID, job.Amount)
-			job.Response <- err
+			err = h.handleDeposit(job.FromAccount, job.Amount)
 		case "transfer":
-			err := handleTransfer(job.UserID, job.ToAccount, job.Amount)
-			job.Response <- err
+			err = h.handleTransfer(job.FromAccount, job.ToAccount, job.Amount)
+		case "withdraw":
+			err = h.handleWithdraw(job.FromAccount, job.Amount)
+		case "reversal":
+			err = h.handleReversal(job.Reversal, job.Amount)
+		}
+		recordTransaction(job, err)
+		job.Response <- err
+	}
+}
+
+// recordTransaction counts a processed job. Amounts are in the currency of
+// the account the job acts on, before any FX conversion.
+func recordTransaction(job TransactionJob, err error) {
+	status := models.TransactionSuccess
+	if err != nil {
+		status = models.TransactionFailed
+	}
+	transactionsTotal.Inc(job.Type, status)
+	if amount, perr := strconv.ParseFloat(job.Amount.Decimal(), 64); perr == nil {
+		transactionAmounts.Add(amount, job.Type, status, job.Amount.Currency)
+	}
+}
+
+// checkActive rejects a movement if any of the accounts is frozen.
+func checkActive(accounts ...*models.Account) error {
+	for _, account := range accounts {
+		if account.Status == models.AccountFrozen {
+			return txError(models.ReasonAccountFrozen, "account "+account.AccountNumber+" is frozen")
 		}
 	}
+	return nil
 }
 
-func handleDeposit(userID int, amount float64) error {
-	mu.Lock()
-	defer mu.Unlock()
+// beginTransaction records an attempted movement as pending before any
+// balance is touched, so failed attempts leave a trace too.
+func (h *TransactionHandler) beginTransaction(transaction models.Transaction) (models.Transaction, error) {
+	transaction.Timestamp = time.Now()
+	transaction.Status = models.TransactionPending
+	err := h.store.Transactions().Create(&transaction)
+	return transaction, err
+}
+
+// finishTransaction marks a pending transaction success or, when err is set,
+// failed with the reason code of err. The returned error carries the
+// transaction ID. A failure must be recorded outside the unit of work that
+// failed, which is rolled back.
+func finishTransaction(transactions repository.TransactionRepository, transaction *models.Transaction, err error) error {
+	if err == nil {
+		transaction.Status = models.TransactionSuccess
+		return transactions.SetStatus(transaction)
+	}
+
+	txErr := asTransactionError(err)
+	transaction.Status = models.TransactionFailed
+	transaction.ReasonCode = txErr.Code
+	if err := transactions.SetStatus(transaction); err != nil {
+		log.Printf("❌ Failed to mark transaction %d failed with %s: %v", transaction.ID, txErr.Code, err)
+	}
+	return &TransactionError{Code: txErr.Code, Message: txErr.Message, TransactionID: transaction.ID}
+}
 
-	var account models.Account
-	if err := database.DB.First(&account, "user_id = ?", userID).Error; err != nil {
-		return fmt.Errorf("account not found")
+// reject records an attempt refused before it was queued as a failed
+// transaction with the code of err, and returns err naming that transaction.
+// Internal errors are not recorded: the store is what failed.
+func (h *TransactionHandler) reject(attempt models.Transaction, err *TransactionError) *TransactionError {
+	if err.Code == models.ReasonInternalError {
+		return err
 	}
-	tx := database.DB.Begin()
-	account.Balance += amount
-	if err := tx.Save(&account).Error; err != nil {
-		tx.Rollback()
+	attempt.Timestamp = time.Now()
+	attempt.Status = models.TransactionFailed
+	attempt.ReasonCode = err.Code
+	if cerr := h.store.Transactions().Create(&attempt); cerr != nil {
+		log.Printf("❌ Failed to record rejected %s with %s: %v", attempt.Type, err.Code, cerr)
 		return err
 	}
-	transaction := models.Transaction{
-		FromAccount: account.AccountNumber,
-		ToAccount:   account.AccountNumber,
+	return &TransactionError{Code: err.Code, Message: err.Message, TransactionID: attempt.ID}
+}
+
+// checkAttempt makes the checks of user and the requested amount that come
+// before a job is queued, filling in the amount of attempt. A refused
+// attempt is recorded and answered, and ok is false.
+func (h *TransactionHandler) checkAttempt(w http.ResponseWriter, r *http.Request, user models.User, attempt *models.Transaction, requested money.Decimal, currency string) (ok bool) {
+	amount, err := requested.Money(currency)
+	if err != nil || !amount.IsPositive() {
+		attempt.Amount = money.Zero(currency)
+		attempt.Description = "requested amount " + string(requested)
+		writeTransactionError(w, r, h.reject(*attempt, txError(codeInvalidAmount, "invalid amount")))
+		return false
+	}
+	attempt.Amount = amount
+	if user.KYCStatus != models.KYCVerified {
+		writeTransactionError(w, r, h.reject(*attempt, txError(codeKYCNotVerified, "KYC not verified")))
+		return false
+	}
+	return true
+}
+
+func (h *TransactionHandler) handleDeposit(accountNumber string, amount money.Money) error {
+	transaction, err := h.beginTransaction(models.Transaction{
+		FromAccount: accountNumber,
+		ToAccount:   accountNumber,
 		Amount:      amount,
 		Type:        "deposit",
-		Timestamp:   time.Now(),
-		Status:      "success",
-	}
-	if err := tx.Create(&transaction).Error; err != nil {
-		tx.Rollback()
+	})
+	if err != nil {
 		return err
 	}
-	return tx.Commit().Error
-}
+	if err := checkTransactionLimit(h.store.Rates(), h.maxAmount, amount); err != nil {
+		return finishTransaction(h.store.Transactions(), &transaction, err)
+	}
+
+	err = h.store.Atomic(func(s repository.Store) error {
+		locked, err := s.Accounts().Lock(accountNumber)
+		if err != nil {
+			return accountLockError(err, accountNumber)
+		}
+		account := locked[accountNumber]
+		if err := checkActive(account); err != nil {
+			return err
+		}
 
-func handleTransfer(userID int, toAccount string, amount float64) error {
-	mu.Lock()
-	defer mu.Unlock()
+		balance, err := account.Balance.Add(amount)
+		if err != nil {
+			return err
+		}
+		account.Balance = balance
+		if err := s.Accounts().Save(account); err != nil {
+			return err
+		}
+		err = s.Ledger().Post(transaction.ID,
+			ledger.DebitOf(ledger.CashAccount, amount),
+			ledger.CreditOf(ledger.CustomerAccount(account.AccountNumber), amount),
+		)
+		if err != nil {
+			return err
+		}
+		return finishTransaction(s.Transactions(), &transaction, nil)
+	})
+	if err != nil {
+		return finishTransaction(h.store.Transactions(), &transaction, err)
+	}
+	return nil
+}
 
-	var sender models.Account
-	if err := database.DB.First(&sender, "user_id = ?", userID).Error; err != nil {
-		return fmt.Errorf("sender account not found")
+func (h *TransactionHandler) handleTransfer(fromAccount, toAccount string, amount money.Money) error {
+	transaction, err := h.beginTransaction(models.Transaction{
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+		Amount:      amount,
+		Type:        "transfer",
+	})
+	if err != nil {
+		return err
+	}
+	if fromAccount == toAccount {
+		return finishTransaction(h.store.Transactions(), &transaction,
+			txError(models.ReasonSameAccount, "cannot transfer to the same account"))
+	}
+	if err := checkTransactionLimit(h.store.Rates(), h.maxAmount, amount); err != nil {
+		return finishTransaction(h.store.Transactions(), &transaction, err)
 	}
 
-	var receiver models.Account
-	if err := database.DB.First(&receiver, "account_number = ?", toAccount).Error; err != nil {
-		return fmt.Errorf("receiver account not found")
+	err = h.store.Atomic(func(s repository.Store) error {
+		locked, err := s.Accounts().Lock(fromAccount, toAccount)
+		if err != nil {
+			return accountLockError(err, fromAccount)
+		}
+		sender, receiver := locked[fromAccount], locked[toAccount]
+		if err := checkActive(sender, receiver); err != nil {
+			return err
+		}
+
+		if cmp, err := sender.Balance.Cmp(amount); err != nil {
+			return err
+		} else if cmp < 0 {
+			return txError(models.ReasonInsufficientFunds, "insufficient funds")
+		}
+		credited := amount
+		if receiver.Balance.Currency != amount.Currency {
+			credited, err = convertTransfer(s, &transaction, receiver.Balance.Currency)
+			if err != nil {
+				return err
+			}
+		}
+
+		senderBalance, _ := sender.Balance.Sub(amount)
+		receiverBalance, err := receiver.Balance.Add(credited)
+		if err != nil {
+			return err
+		}
+		sender.Balance = senderBalance
+		receiver.Balance = receiverBalance
+		if err := s.Accounts().Save(sender); err != nil {
+			return err
+		}
+		if err := s.Accounts().Save(receiver); err != nil {
+			return err
+		}
+		err = s.Ledger().Post(transaction.ID, ledger.TransferPostings(
+			ledger.CustomerAccount(sender.AccountNumber),
+			ledger.CustomerAccount(receiver.AccountNumber),
+			amount, credited,
+		)...)
+		if err != nil {
+			return err
+		}
+		return finishTransaction(s.Transactions(), &transaction, nil)
+	})
+	if err != nil {
+		return finishTransaction(h.store.Transactions(), &transaction, err)
 	}
+	return nil
+}
 
-	if sender.Balance < amount {
-		return fmt.Errorf("insufficient funds")
+// convertTransfer converts the amount of a transfer into currency at the
+// configured rate and records the rate and converted amount on it.
+func convertTransfer(s repository.Store, transaction *models.Transaction, currency string) (money.Money, error) {
+	rate, err := s.Rates().Get(transaction.Amount.Currency, currency)
+	if errors.Is(err, fx.ErrNoRate) {
+		return money.Money{}, txError(models.ReasonFXRateUnavailable, err.Error())
+	} else if err != nil {
+		return money.Money{}, err
 	}
-	tx := database.DB.Begin()
-	sender.Balance -= amount
-	receiver.Balance += amount
-	if err := tx.Save(&sender).Error; err != nil {
-		tx.Rollback()
-		return err
+	converted, err := transaction.Amount.Convert(rate.Rate, currency)
+	if err != nil {
+		return money.Money{}, err
 	}
-	if err := tx.Save(&receiver).Error; err != nil {
-		tx.Rollback()
-		return err
+	if !converted.IsPositive() {
+		return money.Money{}, txError(codeInvalidAmount, "amount is too small to convert to "+currency)
 	}
-	transaction := models.Transaction{
-		FromAccount: sender.AccountNumber,
-		ToAccount:   receiver.AccountNumber,
+
+	transaction.ToAmount = &converted
+	transaction.FXRate = rate.Rate
+	return converted, s.Transactions().SetConversion(transaction)
+}
+
+func (h *TransactionHandler) handleWithdraw(accountNumber string, amount money.Money) error {
+	transaction, err := h.beginTransaction(models.Transaction{
+		FromAccount: accountNumber,
+		ToAccount:   accountNumber,
 		Amount:      amount,
-		Type:        "transfer",
-		Timestamp:   time.Now(),
-		Status:      "success",
+		Type:        "withdraw",
+	})
+	if err != nil {
+		return err
+	}
+	if err := checkTransactionLimit(h.store.Rates(), h.maxAmount, amount); err != nil {
+		return finishTransaction(h.store.Transactions(), &transaction, err)
+	}
+
+	err = h.store.Atomic(func(s repository.Store) error {
+		locked, err := s.Accounts().Lock(accountNumber)
+		if err != nil {
+			return accountLockError(err, accountNumber)
+		}
+		account := locked[accountNumber]
+		if err := checkActive(account); err != nil {
+			return err
+		}
+
+		if cmp, err := account.Balance.Cmp(amount); err != nil {
+			return err
+		} else if cmp < 0 {
+			return txError(models.ReasonInsufficientFunds, "insufficient funds")
+		}
+		account.Balance, _ = account.Balance.Sub(amount)
+		if err := s.Accounts().Save(account); err != nil {
+			return err
+		}
+		err = s.Ledger().Post(transaction.ID,
+			ledger.DebitOf(ledger.CustomerAccount(account.AccountNumber), amount),
+			ledger.CreditOf(ledger.CashAccount, amount),
+		)
+		if err != nil {
+			return err
+		}
+		return finishTransaction(s.Transactions(), &transaction, nil)
+	})
+	if err != nil {
+		return finishTransaction(h.store.Transactions(), &transaction, err)
 	}
-	if err := tx.Create(&transaction).Error; err != nil {
-		tx.Rollback()
+	return nil
+}
+
+// accountLockError reports a missing own account as ACCOUNT_NOT_FOUND and
+// any other missing account as RECEIVER_NOT_FOUND.
+func accountLockError(err error, ownAccount string) error {
+	var missing *repository.AccountNotFoundError
+	if !errors.As(err, &missing) {
 		return err
 	}
-	return tx.Commit().Error
+	if missing.AccountNumber == ownAccount {
+		return txError(models.ReasonAccountNotFound, "account not found")
+	}
+	return txError(models.ReasonReceiverNotFound, "receiver account not found")
 }
 
 // Deposit godoc
 // @Summary Deposit funds
-// @Description Deposit funds into the authenticated user's account
+// @Description Deposit funds into one of the authenticated user's accounts, the default account if account_number is omitted
 // @Tags Transaction
 // @Accept json
 // @Produce json
 // @Param deposit body dto.DepositRequest true "Deposit amount"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 200 {object} map[string]string
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST, VALIDATION_FAILED, INVALID_AMOUNT or CURRENCY_MISMATCH"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "KYC_NOT_VERIFIED, ACCOUNT_NOT_OWNED or ACCOUNT_FROZEN"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND or RECEIVER_NOT_FOUND"
+// @Failure 409 {object} problem.Problem "IDEMPOTENCY_KEY_IN_PROGRESS"
+// @Failure 422 {object} problem.Problem "LIMIT_EXCEEDED, or FX_RATE_UNAVAILABLE if there is no rate to check the limit in the account currency, or IDEMPOTENCY_KEY_REUSED if an Idempotency-Key is reused with a different request"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Failure 503 {object} problem.Problem "SERVICE_UNAVAILABLE while the server shuts down"
 // @Security BearerAuth
 // @Router /api/transaction/deposit [post]
-func Deposit(w http.ResponseWriter, r *http.Request) {
+func (h *TransactionHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 	userIDStr := middleware.GetUserIDFromContext(r)
 	userID, _ := strconv.Atoi(userIDStr)
 
-	var user models.User
-	if err := database.DB.First(&user, userID).Error; err != nil {
-		http.Error(w, "User not found.", http.StatusInternalServerError)
+	var req dto.DepositRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
-	if user.KYCStatus != "verified" {
-		http.Error(w, "KYC not verified", http.StatusForbidden)
+	user, err := h.store.Users().Get(uint(userID))
+	if err != nil {
+		writeTransactionError(w, r, txError(codeUserNotFound, "user not found"))
 		return
 	}
 
-	var req dto.DepositRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Amount <= 0 {
-		http.Error(w, "Invalid amount", http.StatusBadRequest)
+	attempt := models.Transaction{
+		FromAccount: req.AccountNumber,
+		ToAccount:   req.AccountNumber,
+		Type:        "deposit",
+	}
+	account, err := resolveAccount(h.store.Accounts(), userID, req.AccountNumber)
+	if err != nil {
+		attempt.Description = "requested amount " + string(req.Amount)
+		writeTransactionError(w, r, h.reject(attempt, accountTransactionError(err)))
 		return
 	}
-
-	respChan := make(chan error)
-	transactionChan <- TransactionJob{
-		Type:     "deposit",
-		UserID:   userID,
-		Amount:   req.Amount,
-		Response: respChan,
+	attempt.FromAccount, attempt.ToAccount = account.AccountNumber, account.AccountNumber
+	if !h.checkAttempt(w, r, user, &attempt, req.Amount, account.Balance.Currency) {
+		return
 	}
-	err := <-respChan
+
+	err = h.submit(r.Context(), TransactionJob{
+		Type:        "deposit",
+		UserID:      userID,
+		FromAccount: account.AccountNumber,
+		Amount:      attempt.Amount,
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeTransactionError(w, r, asTransactionError(err))
 		return
 	}
 
@@ -168,52 +544,68 @@ func Deposit(w http.ResponseWriter, r *http.Request) {
 
 // Transfer godoc
 // @Summary Transfer funds
-// @Description Transfer funds to another account
+// @Description Transfer funds to another account from one of the authenticated user's accounts, the default account if from_account is omitted. The amount is in the sender's currency; if the receiving account holds another currency it is converted at the configured FX rate, and the rate and converted amount are recorded on the transaction.
 // @Tags Transaction
 // @Accept json
 // @Produce json
 // @Param transfer body TransferRequest true "Transfer info"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 200 {object} map[string]string
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST, VALIDATION_FAILED, INVALID_AMOUNT or SAME_ACCOUNT"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "KYC_NOT_VERIFIED, ACCOUNT_NOT_OWNED or ACCOUNT_FROZEN"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND or RECEIVER_NOT_FOUND"
+// @Failure 409 {object} problem.Problem "IDEMPOTENCY_KEY_IN_PROGRESS"
+// @Failure 422 {object} problem.Problem "INSUFFICIENT_FUNDS, LIMIT_EXCEEDED or FX_RATE_UNAVAILABLE, or IDEMPOTENCY_KEY_REUSED if an Idempotency-Key is reused with a different request"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Failure 503 {object} problem.Problem "SERVICE_UNAVAILABLE while the server shuts down"
 // @Security BearerAuth
 // @Router /api/transaction/transfer [post]
 type TransferRequest struct {
-	ToAccount string  `json:"to_account"`
-	Amount    float64 `json:"amount"`
+	FromAccount string        `json:"from_account,omitempty"`
+	ToAccount   string        `json:"to_account" validate:"required"`
+	Amount      money.Decimal `json:"amount" validate:"required" swaggertype:"string" example:"250.00"`
 }
 
-func Transfer(w http.ResponseWriter, r *http.Request) {
+func (h *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 	userIDStr := middleware.GetUserIDFromContext(r)
 	userID, _ := strconv.Atoi(userIDStr)
 
-	var user models.User
-	if err := database.DB.First(&user, userID).Error; err != nil {
-		http.Error(w, "User not found", http.StatusInternalServerError)
+	var req TransferRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
-	if user.KYCStatus != "verified" {
-		http.Error(w, "KYC not verified", http.StatusForbidden)
+	user, err := h.store.Users().Get(uint(userID))
+	if err != nil {
+		writeTransactionError(w, r, txError(codeUserNotFound, "user not found"))
 		return
 	}
 
-	var req TransferRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Amount <= 0 {
-		http.Error(w, "Invalid transfer data", http.StatusBadRequest)
+	attempt := models.Transaction{
+		FromAccount: req.FromAccount,
+		ToAccount:   req.ToAccount,
+		Type:        "transfer",
+	}
+	sender, err := resolveAccount(h.store.Accounts(), userID, req.FromAccount)
+	if err != nil {
+		attempt.Description = "requested amount " + string(req.Amount)
+		writeTransactionError(w, r, h.reject(attempt, accountTransactionError(err)))
 		return
 	}
-
-	respChan := make(chan error)
-	transactionChan <- TransactionJob{
-		Type:      "transfer",
-		UserID:    userID,
-		ToAccount: req.ToAccount,
-		Amount:    req.Amount,
-		Response:  respChan,
+	attempt.FromAccount = sender.AccountNumber
+	if !h.checkAttempt(w, r, user, &attempt, req.Amount, sender.Balance.Currency) {
+		return
 	}
-	err := <-respChan
+
+	err = h.submit(r.Context(), TransactionJob{
+		Type:        "transfer",
+		UserID:      userID,
+		FromAccount: sender.AccountNumber,
+		ToAccount:   req.ToAccount,
+		Amount:      attempt.Amount,
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeTransactionError(w, r, asTransactionError(err))
 		return
 	}
 
@@ -222,30 +614,183 @@ func Transfer(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Withdraw godoc
+// @Summary Withdraw funds
+// @Description Cash out funds from one of the authenticated user's accounts (the default account if account_number is omitted), e.g. at an agent or ATM. The withdrawal is recorded with status pending and moves to success once the account is debited, or to failed with a reason code. Success is the completed state of every transaction; there is no separate completed status.
+// @Tags Transaction
+// @Accept json
+// @Produce json
+// @Param withdraw body dto.WithdrawRequest true "Withdrawal amount"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST, VALIDATION_FAILED or INVALID_AMOUNT"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "KYC_NOT_VERIFIED, ACCOUNT_NOT_OWNED or ACCOUNT_FROZEN"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND"
+// @Failure 409 {object} problem.Problem "IDEMPOTENCY_KEY_IN_PROGRESS"
+// @Failure 422 {object} problem.Problem "INSUFFICIENT_FUNDS, LIMIT_EXCEEDED, or FX_RATE_UNAVAILABLE if there is no rate to check the limit in the account currency, or IDEMPOTENCY_KEY_REUSED if an Idempotency-Key is reused with a different request"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Failure 503 {object} problem.Problem "SERVICE_UNAVAILABLE while the server shuts down"
+// @Security BearerAuth
+// @Router /api/transaction/withdraw [post]
+func (h *TransactionHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	userIDStr := middleware.GetUserIDFromContext(r)
+	userID, _ := strconv.Atoi(userIDStr)
+
+	var req dto.WithdrawRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	user, err := h.store.Users().Get(uint(userID))
+	if err != nil {
+		writeTransactionError(w, r, txError(codeUserNotFound, "user not found"))
+		return
+	}
+
+	attempt := models.Transaction{
+		FromAccount: req.AccountNumber,
+		ToAccount:   req.AccountNumber,
+		Type:        "withdraw",
+	}
+	account, err := resolveAccount(h.store.Accounts(), userID, req.AccountNumber)
+	if err != nil {
+		attempt.Description = "requested amount " + string(req.Amount)
+		writeTransactionError(w, r, h.reject(attempt, accountTransactionError(err)))
+		return
+	}
+	attempt.FromAccount, attempt.ToAccount = account.AccountNumber, account.AccountNumber
+	if !h.checkAttempt(w, r, user, &attempt, req.Amount, account.Balance.Currency) {
+		return
+	}
+
+	err = h.submit(r.Context(), TransactionJob{
+		Type:        "withdraw",
+		UserID:      userID,
+		FromAccount: account.AccountNumber,
+		Amount:      attempt.Amount,
+	})
+	if err != nil {
+		writeTransactionError(w, r, asTransactionError(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Withdrawal successful",
+	})
+}
+
+// TransactionPage is one page of transaction history. NextCursor is empty
+// on the last page.
+type TransactionPage struct {
+	Transactions []models.Transaction `json:"transactions"`
+	NextCursor   string               `json:"next_cursor,omitempty" example:"MjAyNS0wNy0wM1QxMDozMDowMFp8NDI"`
+}
+
 // TransactionHistory godoc
 // @Summary View transaction history
-// @Description Retrieve user's past transactions
+// @Description Retrieve past transactions of the user's default account, newest first, one page at a time
 // @Tags Transaction
 // @Produce json
-// @Success 200 {array} models.Transaction
-// @Failure 500 {string} string "Internal Server Error"
+// @Param from query string false "Earliest timestamp, YYYY-MM-DD or RFC 3339"
+// @Param to query string false "Latest timestamp, YYYY-MM-DD (whole day included) or RFC 3339 (exclusive)"
+// @Param type query string false "Transaction type" Enums(deposit, withdraw, transfer, reversal, opening_balance)
+// @Param status query string false "Transaction status" Enums(pending, success, failed, reversed)
+// @Param direction query string false "Money into or out of the account" Enums(in, out)
+// @Param min_amount query string false "Minimum amount, e.g. 10.00"
+// @Param max_amount query string false "Maximum amount, e.g. 500.00"
+// @Param counterparty query string false "Only transactions with this account number on the other side"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} TransactionPage
+// @Failure 400 {object} problem.Problem "VALIDATION_FAILED: invalid filter or cursor"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
 // @Security BearerAuth
 // @Router /api/transaction/history [get]
-func TransactionHistory(w http.ResponseWriter, r *http.Request) {
+func (h *TransactionHandler) TransactionHistory(w http.ResponseWriter, r *http.Request) {
+	h.writeHistory(w, r, "")
+}
+
+// AccountHistory godoc
+// @Summary View transaction history of a specific account
+// @Description Retrieve past transactions of one of the authenticated user's accounts, newest first, one page at a time
+// @Tags Transaction
+// @Produce json
+// @Param number path string true "Account number"
+// @Param from query string false "Earliest timestamp, YYYY-MM-DD or RFC 3339"
+// @Param to query string false "Latest timestamp, YYYY-MM-DD (whole day included) or RFC 3339 (exclusive)"
+// @Param type query string false "Transaction type" Enums(deposit, withdraw, transfer, reversal, opening_balance)
+// @Param status query string false "Transaction status" Enums(pending, success, failed, reversed)
+// @Param direction query string false "Money into or out of the account" Enums(in, out)
+// @Param min_amount query string false "Minimum amount, e.g. 10.00"
+// @Param max_amount query string false "Maximum amount, e.g. 500.00"
+// @Param counterparty query string false "Only transactions with this account number on the other side"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} TransactionPage
+// @Failure 400 {object} problem.Problem "VALIDATION_FAILED: invalid filter or cursor"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 403 {object} problem.Problem "ACCOUNT_NOT_OWNED"
+// @Failure 404 {object} problem.Problem "ACCOUNT_NOT_FOUND"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Security BearerAuth
+// @Router /api/account/{number}/history [get]
+func (h *TransactionHandler) AccountHistory(w http.ResponseWriter, r *http.Request) {
+	h.writeHistory(w, r, mux.Vars(r)["number"])
+}
+
+func (h *TransactionHandler) writeHistory(w http.ResponseWriter, r *http.Request, accountNumber string) {
 	userIDStr := middleware.GetUserIDFromContext(r)
 	userID, _ := strconv.Atoi(userIDStr)
 
-	var account models.Account
-	if err := database.DB.First(&account, "user_id = ?", userID).Error; err != nil {
-		http.Error(w, "Account not found for user.", http.StatusInternalServerError)
+	account, err := resolveAccount(h.store.Accounts(), userID, accountNumber)
+	if err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+	h.writeHistoryPage(w, r, account)
+}
+
+// writeHistoryPage writes one page of account's history as selected by the
+// query string.
+func (h *TransactionHandler) writeHistoryPage(w http.ResponseWriter, r *http.Request, account models.Account) {
+	q := r.URL.Query()
+	filter, err := parseHistoryFilter(q, account.Balance.Currency)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	limit, err := parseHistoryLimit(q.Get("limit"))
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	var transactions []models.Transaction
-	if err := database.DB.Where("from_account = ? OR to_account = ?", account.AccountNumber, account.AccountNumber).Order("timestamp desc").Find(&transactions).Error; err != nil {
-		http.Error(w, "Failed to retrieve transaction history.", http.StatusInternalServerError)
+	var after *repository.Cursor
+	if c := q.Get("cursor"); c != "" {
+		cursor, err := decodeHistoryCursor(c)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		after = &cursor
+	}
+
+	// Fetch one extra row to learn whether another page exists
+	transactions, err := h.store.Transactions().History(account.AccountNumber, filter, after, limit+1)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve transaction history.")
 		return
 	}
 
-	json.NewEncoder(w).Encode(transactions)
+	page := TransactionPage{Transactions: transactions}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		last := page.Transactions[limit-1]
+		page.NextCursor = encodeHistoryCursor(repository.Cursor{Timestamp: last.Timestamp, ID: last.ID})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
 }
@@ -0,0 +1,688 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"neobank-lite/config"
+	"neobank-lite/ledger"
+	"neobank-lite/middleware"
+	"neobank-lite/models"
+	"neobank-lite/money"
+	"neobank-lite/repository"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestTransactionHandler returns a handler on a memory store with these
+// accounts, funded by one deposit each:
+//
+//	etb-a       1000.00 ETB
+//	etb-b          0.00 ETB
+//	usd-a        100.00 USD
+//	etb-frozen    50.00 ETB, frozen
+//
+// Transactions are limited to 10000.00 ETB, USD converts at 57.25 ETB and the
+// next transaction gets ID 4.
+func newTestTransactionHandler(t *testing.T) (*TransactionHandler, repository.Store) {
+	t.Helper()
+	return newTestTransactionHandlerOn(t, repository.NewMemoryStore(), 1)
+}
+
+// newTestTransactionHandlerOn is newTestTransactionHandler on store, with
+// workers workers once started.
+func newTestTransactionHandlerOn(t *testing.T, store repository.Store, workers int) (*TransactionHandler, repository.Store) {
+	t.Helper()
+	limit := money.New(1000000, "ETB")
+	h := NewTransactionHandler(store, config.Transactions{Workers: workers, QueueSize: 100, MaxAmount: &limit})
+
+	if err := store.Rates().Put(models.FXRate{Base: "USD", Quote: "ETB", Rate: "57.25"}); err != nil {
+		t.Fatal(err)
+	}
+	accounts := []struct {
+		number  string
+		balance money.Money
+	}{
+		{"etb-a", money.New(100000, "ETB")},
+		{"etb-b", money.Zero("ETB")},
+		{"usd-a", money.New(10000, "USD")},
+		{"etb-frozen", money.New(5000, "ETB")},
+	}
+	for _, a := range accounts {
+		account := models.Account{AccountNumber: a.number, UserID: 1, Balance: money.Zero(a.balance.Currency)}
+		if err := store.Accounts().Create(&account); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Ledger().OpenCustomerAccount(a.number); err != nil {
+			t.Fatal(err)
+		}
+		if a.balance.IsPositive() {
+			if err := h.handleDeposit(a.number, a.balance); err != nil {
+				t.Fatalf("funding %s: %v", a.number, err)
+			}
+		}
+	}
+
+	frozen, err := store.Accounts().Get("etb-frozen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frozen.Status = models.AccountFrozen
+	if err := store.Accounts().Save(&frozen); err != nil {
+		t.Fatal(err)
+	}
+	return h, store
+}
+
+func TestMoneyMovements(t *testing.T) {
+	etb := func(minor int64) money.Money { return money.New(minor, "ETB") }
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+
+	tests := []struct {
+		name     string
+		run      func(h *TransactionHandler) error
+		code     string           // reason code of the failure, none if empty
+		balances map[string]int64 // in minor units, after the movement
+	}{
+		{
+			name:     "deposit",
+			run:      func(h *TransactionHandler) error { return h.handleDeposit("etb-b", etb(25000)) },
+			balances: map[string]int64{"etb-b": 25000},
+		},
+		{
+			name: "deposit over the limit",
+			run:  func(h *TransactionHandler) error { return h.handleDeposit("etb-b", etb(1000001)) },
+			code: models.ReasonLimitExceeded,
+		},
+		{
+			name:     "deposit under the limit in another currency",
+			run:      func(h *TransactionHandler) error { return h.handleDeposit("usd-a", usd(17467)) }, // 9999.86 ETB
+			balances: map[string]int64{"usd-a": 27467},
+		},
+		{
+			name: "deposit over the limit in another currency",
+			run:  func(h *TransactionHandler) error { return h.handleDeposit("usd-a", usd(17468)) }, // 10000.43 ETB
+			code: models.ReasonLimitExceeded,
+		},
+		{
+			name: "limit without a rate",
+			run:  func(h *TransactionHandler) error { return h.handleDeposit("usd-a", money.New(100, "EUR")) },
+			code: models.ReasonFXRateUnavailable,
+		},
+		{
+			name: "deposit into a frozen account",
+			run:  func(h *TransactionHandler) error { return h.handleDeposit("etb-frozen", etb(100)) },
+			code: models.ReasonAccountFrozen,
+		},
+		{
+			name: "deposit into a missing account",
+			run:  func(h *TransactionHandler) error { return h.handleDeposit("etb-missing", etb(100)) },
+			code: models.ReasonAccountNotFound,
+		},
+		{
+			name:     "withdraw",
+			run:      func(h *TransactionHandler) error { return h.handleWithdraw("etb-a", etb(40000)) },
+			balances: map[string]int64{"etb-a": 60000},
+		},
+		{
+			name:     "withdraw everything",
+			run:      func(h *TransactionHandler) error { return h.handleWithdraw("etb-a", etb(100000)) },
+			balances: map[string]int64{"etb-a": 0},
+		},
+		{
+			name: "withdraw more than the balance",
+			run:  func(h *TransactionHandler) error { return h.handleWithdraw("etb-a", etb(100001)) },
+			code: models.ReasonInsufficientFunds,
+		},
+		{
+			name: "withdraw from a frozen account",
+			run:  func(h *TransactionHandler) error { return h.handleWithdraw("etb-frozen", etb(100)) },
+			code: models.ReasonAccountFrozen,
+		},
+		{
+			name:     "transfer",
+			run:      func(h *TransactionHandler) error { return h.handleTransfer("etb-a", "etb-b", etb(30000)) },
+			balances: map[string]int64{"etb-a": 70000, "etb-b": 30000},
+		},
+		{
+			name: "transfer more than the balance",
+			run:  func(h *TransactionHandler) error { return h.handleTransfer("etb-a", "etb-b", etb(100001)) },
+			code: models.ReasonInsufficientFunds,
+		},
+		{
+			name: "transfer to the same account",
+			run:  func(h *TransactionHandler) error { return h.handleTransfer("etb-a", "etb-a", etb(100)) },
+			code: models.ReasonSameAccount,
+		},
+		{
+			name: "transfer to a missing account",
+			run:  func(h *TransactionHandler) error { return h.handleTransfer("etb-a", "etb-missing", etb(100)) },
+			code: models.ReasonReceiverNotFound,
+		},
+		{
+			name: "transfer to a frozen account",
+			run:  func(h *TransactionHandler) error { return h.handleTransfer("etb-a", "etb-frozen", etb(100)) },
+			code: models.ReasonAccountFrozen,
+		},
+		{
+			name:     "transfer with conversion",
+			run:      func(h *TransactionHandler) error { return h.handleTransfer("usd-a", "etb-b", usd(1000)) },
+			balances: map[string]int64{"usd-a": 9000, "etb-b": 57250},
+		},
+		{
+			name: "transfer without a rate",
+			run:  func(h *TransactionHandler) error { return h.handleTransfer("etb-a", "usd-a", etb(100)) },
+			code: models.ReasonFXRateUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store := newTestTransactionHandler(t)
+			before := map[string]int64{}
+			for _, number := range []string{"etb-a", "etb-b", "usd-a", "etb-frozen"} {
+				account, err := store.Accounts().Get(number)
+				if err != nil {
+					t.Fatal(err)
+				}
+				before[number] = account.Balance.Minor
+			}
+
+			err := tt.run(h)
+			var txErr *TransactionError
+			switch {
+			case tt.code == "" && err != nil:
+				t.Fatalf("unexpected error %v", err)
+			case tt.code != "" && (!errors.As(err, &txErr) || txErr.Code != tt.code):
+				t.Fatalf("got error %v, want %s", err, tt.code)
+			}
+
+			transaction, err := store.Transactions().Get(4)
+			if err != nil {
+				t.Fatalf("the movement was not recorded: %v", err)
+			}
+			switch {
+			case tt.code == "" && transaction.Status != models.TransactionSuccess:
+				t.Errorf("transaction is %s, want success", transaction.Status)
+			case tt.code != "" && (transaction.Status != models.TransactionFailed || transaction.ReasonCode != tt.code):
+				t.Errorf("transaction is %s %s, want failed %s", transaction.Status, transaction.ReasonCode, tt.code)
+			}
+			if tt.code != "" && txErr.TransactionID != transaction.ID {
+				t.Errorf("error names transaction %d, want %d", txErr.TransactionID, transaction.ID)
+			}
+
+			for number, want := range before {
+				if changed, ok := tt.balances[number]; ok {
+					want = changed
+				}
+				account, _ := store.Accounts().Get(number)
+				if account.Balance.Minor != want {
+					t.Errorf("%s has %d, want %d", number, account.Balance.Minor, want)
+				}
+			}
+
+			// Failed movements must not leave half a posting behind.
+			report, err := store.Ledger().TrialBalance()
+			if err != nil || !report.Balanced {
+				t.Errorf("ledger does not balance: %+v, %v", report.Totals, err)
+			}
+			if mismatches, err := store.Ledger().Reconcile(); err != nil || len(mismatches) > 0 {
+				t.Errorf("balances disagree with the ledger: %+v, %v", mismatches, err)
+			}
+		})
+	}
+}
+
+// TestReversals reverses transaction 4, made by setup, with each refund in
+// turn. All but the last refund must succeed.
+func TestReversals(t *testing.T) {
+	etb := func(minor int64) money.Money { return money.New(minor, "ETB") }
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	transfer := func(h *TransactionHandler, store repository.Store) error {
+		return h.handleTransfer("etb-a", "etb-b", etb(30000))
+	}
+	freeze := func(store repository.Store, number string) error {
+		account, err := store.Accounts().Get(number)
+		if err != nil {
+			return err
+		}
+		account.Status = models.AccountFrozen
+		return store.Accounts().Save(&account)
+	}
+	type refund struct {
+		amount money.Money
+		force  bool
+	}
+
+	tests := []struct {
+		name     string
+		setup    func(h *TransactionHandler, store repository.Store) error
+		refunds  []refund
+		code     string           // reason code of the last refund, none if empty
+		reversed bool             // whether transaction 4 ends up reversed
+		balances map[string]int64 // in minor units, after the refunds
+	}{
+		{
+			name:     "full reversal",
+			setup:    transfer,
+			refunds:  []refund{{amount: etb(30000)}},
+			reversed: true,
+		},
+		{
+			name:     "partial refund",
+			setup:    transfer,
+			refunds:  []refund{{amount: etb(10000)}},
+			balances: map[string]int64{"etb-a": 80000, "etb-b": 20000},
+		},
+		{
+			name:     "partial refunds adding up to the amount",
+			setup:    transfer,
+			refunds:  []refund{{amount: etb(10000)}, {amount: etb(20000)}},
+			reversed: true,
+		},
+		{
+			name:     "refund over what is left",
+			setup:    transfer,
+			refunds:  []refund{{amount: etb(10000)}, {amount: etb(20001)}},
+			code:     models.ReasonReversalTooLarge,
+			balances: map[string]int64{"etb-a": 80000, "etb-b": 20000},
+		},
+		{
+			name:     "already reversed",
+			setup:    transfer,
+			refunds:  []refund{{amount: etb(30000)}, {amount: etb(100)}},
+			code:     models.ReasonNotReversible,
+			reversed: true,
+		},
+		{
+			name: "failed original",
+			setup: func(h *TransactionHandler, store repository.Store) error {
+				h.handleTransfer("etb-a", "etb-b", etb(100001))
+				return nil
+			},
+			refunds: []refund{{amount: etb(100)}},
+			code:    models.ReasonNotReversible,
+		},
+		{
+			name: "receiver spent the funds",
+			setup: func(h *TransactionHandler, store repository.Store) error {
+				if err := transfer(h, store); err != nil {
+					return err
+				}
+				return h.handleWithdraw("etb-b", etb(25000))
+			},
+			refunds:  []refund{{amount: etb(30000)}},
+			code:     models.ReasonInsufficientFunds,
+			balances: map[string]int64{"etb-a": 70000, "etb-b": 5000},
+		},
+		{
+			name: "receiver spent the funds, forced",
+			setup: func(h *TransactionHandler, store repository.Store) error {
+				if err := transfer(h, store); err != nil {
+					return err
+				}
+				return h.handleWithdraw("etb-b", etb(25000))
+			},
+			refunds:  []refund{{amount: etb(30000), force: true}},
+			reversed: true,
+			balances: map[string]int64{"etb-b": -25000},
+		},
+		{
+			name: "frozen receiver",
+			setup: func(h *TransactionHandler, store repository.Store) error {
+				if err := transfer(h, store); err != nil {
+					return err
+				}
+				return freeze(store, "etb-b")
+			},
+			refunds:  []refund{{amount: etb(30000)}},
+			code:     models.ReasonAccountFrozen,
+			balances: map[string]int64{"etb-a": 70000, "etb-b": 30000},
+		},
+		{
+			name: "frozen receiver, forced",
+			setup: func(h *TransactionHandler, store repository.Store) error {
+				if err := transfer(h, store); err != nil {
+					return err
+				}
+				return freeze(store, "etb-b")
+			},
+			refunds:  []refund{{amount: etb(30000), force: true}},
+			reversed: true,
+		},
+		{
+			name: "deposit",
+			setup: func(h *TransactionHandler, store repository.Store) error {
+				return h.handleDeposit("etb-b", etb(25000))
+			},
+			refunds:  []refund{{amount: etb(25000)}},
+			reversed: true,
+		},
+		{
+			name: "withdraw",
+			setup: func(h *TransactionHandler, store repository.Store) error {
+				return h.handleWithdraw("etb-a", etb(40000))
+			},
+			refunds:  []refund{{amount: etb(40000)}},
+			reversed: true,
+		},
+		{
+			// 3.33 USD is 190.64 ETB each time, so the last refund gives
+			// back the 0.58 ETB left rather than the 0.57 ETB 0.01 USD converts to.
+			name: "conversion refunded in parts",
+			setup: func(h *TransactionHandler, store repository.Store) error {
+				return h.handleTransfer("usd-a", "etb-b", usd(1000))
+			},
+			refunds:  []refund{{amount: usd(333)}, {amount: usd(333)}, {amount: usd(333)}, {amount: usd(1)}},
+			reversed: true,
+		},
+		{
+			name: "conversion refunded in part",
+			setup: func(h *TransactionHandler, store repository.Store) error {
+				return h.handleTransfer("usd-a", "etb-b", usd(1000))
+			},
+			refunds:  []refund{{amount: usd(333)}},
+			balances: map[string]int64{"usd-a": 9333, "etb-b": 38186},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store := newTestTransactionHandler(t)
+			before := map[string]int64{}
+			for _, number := range []string{"etb-a", "etb-b", "usd-a", "etb-frozen"} {
+				account, err := store.Accounts().Get(number)
+				if err != nil {
+					t.Fatal(err)
+				}
+				before[number] = account.Balance.Minor
+			}
+			if err := tt.setup(h, store); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, r := range tt.refunds {
+				job := ReversalJob{OriginalID: 4, Force: r.force}
+				err := h.handleReversal(&job, r.amount)
+				if i < len(tt.refunds)-1 {
+					if err != nil {
+						t.Fatalf("refund %d: %v", i+1, err)
+					}
+					continue
+				}
+
+				var txErr *TransactionError
+				switch {
+				case tt.code == "" && err != nil:
+					t.Fatalf("unexpected error %v", err)
+				case tt.code != "" && (!errors.As(err, &txErr) || txErr.Code != tt.code):
+					t.Fatalf("got error %v, want %s", err, tt.code)
+				}
+				reversal, err := store.Transactions().Get(job.Result.ID)
+				if err != nil {
+					t.Fatalf("the reversal was not recorded: %v", err)
+				}
+				switch {
+				case tt.code == "" && reversal.Status != models.TransactionSuccess:
+					t.Errorf("reversal is %s, want success", reversal.Status)
+				case tt.code != "" && (reversal.Status != models.TransactionFailed || reversal.ReasonCode != tt.code):
+					t.Errorf("reversal is %s %s, want failed %s", reversal.Status, reversal.ReasonCode, tt.code)
+				}
+				if reversal.ReversalOf == nil || *reversal.ReversalOf != 4 {
+					t.Errorf("reversal is linked to %v, want 4", reversal.ReversalOf)
+				}
+			}
+
+			original, err := store.Transactions().Get(4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reversed := original.Status == models.TransactionReversed; reversed != tt.reversed {
+				t.Errorf("original is %s", original.Status)
+			}
+
+			for number, want := range before {
+				if changed, ok := tt.balances[number]; ok {
+					want = changed
+				}
+				account, _ := store.Accounts().Get(number)
+				if account.Balance.Minor != want {
+					t.Errorf("%s has %d, want %d", number, account.Balance.Minor, want)
+				}
+			}
+
+			report, err := store.Ledger().TrialBalance()
+			if err != nil || !report.Balanced {
+				t.Errorf("ledger does not balance: %+v, %v", report.Totals, err)
+			}
+			if mismatches, err := store.Ledger().Reconcile(); err != nil || len(mismatches) > 0 {
+				t.Errorf("balances disagree with the ledger: %+v, %v", mismatches, err)
+			}
+		})
+	}
+}
+
+func TestRejectedAttempts(t *testing.T) {
+	h, store := newTestTransactionHandler(t)
+	verified := models.User{Email: "a@example.com", KYCStatus: models.KYCVerified}
+	pending := models.User{Email: "b@example.com"}
+	for _, u := range []*models.User{&verified, &pending} {
+		if err := store.Users().Create(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	own := models.Account{AccountNumber: "etb-c", UserID: int(pending.ID), Balance: money.Zero("ETB"), IsDefault: true}
+	if err := store.Accounts().Create(&own); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		userID  string
+		code    string
+		account string // FromAccount of the recorded transaction
+		amount  money.Money
+	}{
+		{"KYC not verified", h.Deposit, `{"amount":"10.00"}`, "2", codeKYCNotVerified, "etb-c", money.New(1000, "ETB")},
+		{"invalid amount", h.Withdraw, `{"account_number":"etb-a","amount":"0.001"}`, "1", codeInvalidAmount, "etb-a", money.Zero("ETB")},
+		{"account not owned", h.Transfer, `{"from_account":"etb-a","to_account":"etb-c","amount":"10.00"}`, "2", codeAccountNotOwned, "etb-a", money.Money{}},
+		{"account not found", h.Withdraw, `{"account_number":"etb-missing","amount":"10.00"}`, "1", models.ReasonAccountNotFound, "etb-missing", money.Money{}},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.handler(w, asUser("POST", "/api/transaction", tt.body, tt.userID))
+		var body struct {
+			Code          string
+			TransactionID int `json:"transaction_id"`
+		}
+		json.NewDecoder(w.Body).Decode(&body)
+		if w.Code != transactionErrorStatus(tt.code) || body.Code != tt.code {
+			t.Errorf("%s: got %d %s, want %s", tt.name, w.Code, body.Code, tt.code)
+			continue
+		}
+
+		want := 4 + i
+		if body.TransactionID != want {
+			t.Errorf("%s: response names transaction %d, want %d", tt.name, body.TransactionID, want)
+		}
+		transaction, err := store.Transactions().Get(want)
+		if err != nil {
+			t.Errorf("%s: the attempt was not recorded: %v", tt.name, err)
+			continue
+		}
+		if transaction.Status != models.TransactionFailed || transaction.ReasonCode != tt.code ||
+			transaction.FromAccount != tt.account || transaction.Amount != tt.amount {
+			t.Errorf("%s: recorded %+v", tt.name, transaction)
+		}
+	}
+
+	// A malformed request names nothing to record.
+	w := httptest.NewRecorder()
+	h.Deposit(w, asUser("POST", "/api/transaction/deposit", `{"amount":`, "1"))
+	if _, err := store.Transactions().Get(4 + len(tests)); w.Code != http.StatusBadRequest || err != repository.ErrNotFound {
+		t.Errorf("malformed request: got %d and %v", w.Code, err)
+	}
+}
+
+// A job refused during shutdown changed nothing, so its Idempotency-Key is
+// released for the retry instead of replaying the 503.
+func TestShutdownReleasesIdempotencyKey(t *testing.T) {
+	h, store := newTestTransactionHandler(t)
+	user := models.User{Email: "a@example.com", KYCStatus: models.KYCVerified}
+	if err := store.Users().Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	drained := h.Start(ctx)
+	cancel()
+	<-drained
+
+	handler := middleware.Idempotency(store.IdempotencyKeys(), time.Hour)(http.HandlerFunc(h.Deposit))
+	r := asUser("POST", "/api/transaction/deposit", `{"account_number":"etb-a","amount":"10.00"}`, "1")
+	r.Header.Set(middleware.IdempotencyHeader, "k")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d, want 503", w.Code)
+	}
+	if _, err := store.IdempotencyKeys().Get("1", "k"); err != repository.ErrNotFound {
+		t.Errorf("key after shutdown: got %v, want ErrNotFound", err)
+	}
+}
+
+// sqliteStore is a gorm store on an in-memory SQLite database with the
+// tables the transaction endpoints use.
+func sqliteStore(t *testing.T) repository.Store {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	err = db.AutoMigrate(&models.User{}, &models.Account{}, &models.Transaction{},
+		&models.LedgerAccount{}, &models.LedgerEntry{}, &models.FXRate{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Setup(db); err != nil {
+		t.Fatal(err)
+	}
+	return repository.NewGormStore(db)
+}
+
+// Transfers in both directions between the same two accounts run on
+// several workers at once. Every one of them must land exactly once.
+func TestOpposingTransfers(t *testing.T) {
+	stores := map[string]func(t *testing.T) repository.Store{
+		"memory": func(*testing.T) repository.Store { return repository.NewMemoryStore() },
+		"sql":    sqliteStore,
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			h, store := newTestTransactionHandlerOn(t, newStore(t), 8)
+			if err := h.handleDeposit("etb-b", money.New(100000, "ETB")); err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			drained := h.Start(ctx)
+			defer func() {
+				cancel()
+				<-drained
+			}()
+
+			const aToB, bToA = 120, 80
+			errs := make(chan error, aToB+bToA)
+			var wg sync.WaitGroup
+			for i := 0; i < aToB+bToA; i++ {
+				from, to := "etb-a", "etb-b"
+				if i%5 >= 3 { // 2 in 5 go the other way
+					from, to = to, from
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- h.submit(context.Background(), TransactionJob{
+						Type: "transfer", FromAccount: from, ToAccount: to, Amount: money.New(100, "ETB"),
+					})
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					t.Errorf("transfer failed: %v", err)
+				}
+			}
+
+			for number, want := range map[string]int64{"etb-a": 100000 - 100*(aToB-bToA), "etb-b": 100000 + 100*(aToB-bToA)} {
+				account, err := store.Accounts().Get(number)
+				if err != nil || account.Balance.Minor != want {
+					t.Errorf("%s has %d, want %d (%v)", number, account.Balance.Minor, want, err)
+				}
+			}
+			report, err := store.Ledger().TrialBalance()
+			if err != nil || !report.Balanced {
+				t.Errorf("ledger does not balance: %+v, %v", report.Totals, err)
+			}
+			if mismatches, err := store.Ledger().Reconcile(); err != nil || len(mismatches) > 0 {
+				t.Errorf("balances disagree with the ledger: %+v, %v", mismatches, err)
+			}
+		})
+	}
+}
+
+// A request waiting for room in a full queue must neither hold up shutdown
+// nor the probes that read the queue status.
+func TestSubmitToFullQueue(t *testing.T) {
+	full := func() *TransactionHandler {
+		h := NewTransactionHandler(repository.NewMemoryStore(), config.Transactions{QueueSize: 1})
+		h.jobs <- TransactionJob{Response: make(chan error, 1)}
+		return h
+	}
+	job := TransactionJob{Type: "deposit", FromAccount: "etb-a", Amount: money.New(100, "ETB")}
+
+	h := full()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.submit(ctx, job); err != errNotQueued {
+		t.Errorf("request ended while waiting: got %v, want errNotQueued", err)
+	}
+
+	h = full()
+	submitted := make(chan error, 1)
+	go func() { submitted <- h.submit(context.Background(), job) }()
+	time.Sleep(20 * time.Millisecond) // let it block on the full queue
+
+	stop, cancelStop := context.WithCancel(context.Background())
+	drained := h.Start(stop)
+	cancelStop()
+	select {
+	case err := <-submitted:
+		if err != errShuttingDown {
+			t.Errorf("waiting request during shutdown: got %v, want errShuttingDown", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("shutdown did not release the waiting request")
+	}
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("shutdown did not finish")
+	}
+	if _, queued, stopping := h.QueueStatus(); queued != 1 || !stopping {
+		t.Errorf("QueueStatus after shutdown: %d queued, stopping %v", queued, stopping)
+	}
+}
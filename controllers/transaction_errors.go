@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"neobank-lite/fx"
+	"neobank-lite/middleware"
+	"neobank-lite/models"
+	"neobank-lite/money"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+)
+
+// TransactionError is an error of a transaction endpoint. Code is one of the
+// models.Reason* codes (or a request validation code) and is stable, so
+// clients can branch on it instead of on Message. It is sent as a
+// problem.Problem whose status follows from the code.
+type TransactionError struct {
+	Code          string
+	Message       string
+	TransactionID int
+}
+
+func (e *TransactionError) Error() string {
+	return e.Message
+}
+
+// Request validation codes, returned before a job is queued. A refused
+// attempt on a known user is still recorded as a failed transaction, except
+// for malformed requests, which name no account or amount.
+const (
+	codeInvalidRequest  = problem.CodeInvalidRequest
+	codeInvalidAmount   = "INVALID_AMOUNT"
+	codeKYCNotVerified  = "KYC_NOT_VERIFIED"
+	codeAccountNotOwned = "ACCOUNT_NOT_OWNED"
+	codeUserNotFound    = "USER_NOT_FOUND"
+	codeTxNotFound      = "TRANSACTION_NOT_FOUND"
+)
+
+func txError(code, message string) *TransactionError {
+	return &TransactionError{Code: code, Message: message}
+}
+
+// asTransactionError classifies any error from a transaction job, treating
+// unknown errors as internal.
+func asTransactionError(err error) *TransactionError {
+	var txErr *TransactionError
+	if errors.As(err, &txErr) {
+		return txErr
+	}
+	if errors.Is(err, money.ErrCurrencyMismatch) {
+		return txError(models.ReasonCurrencyMismatch, err.Error())
+	}
+	return txError(models.ReasonInternalError, "transaction could not be processed")
+}
+
+// checkTransactionLimit enforces limit, an amount in a base currency; there
+// is no limit if it is nil. Amounts in other currencies are converted into
+// it at the configured rate. Without a rate the movement is refused, not let
+// through unchecked.
+func checkTransactionLimit(rates repository.RateRepository, limit *money.Money, amount money.Money) error {
+	if limit == nil {
+		return nil
+	}
+	converted := amount
+	if amount.Currency != limit.Currency {
+		rate, err := rates.Get(amount.Currency, limit.Currency)
+		if errors.Is(err, fx.ErrNoRate) {
+			return txError(models.ReasonFXRateUnavailable, "no rate to check the amount against the limit of "+limit.String())
+		} else if err != nil {
+			return err
+		}
+		converted, err = amount.Convert(rate.Rate, limit.Currency)
+		if errors.Is(err, money.ErrInvalidAmount) {
+			return txError(models.ReasonLimitExceeded, "amount exceeds the limit of "+limit.String())
+		} else if err != nil {
+			return err
+		}
+	}
+	if cmp, err := converted.Cmp(*limit); err != nil {
+		return err
+	} else if cmp > 0 {
+		return txError(models.ReasonLimitExceeded, "amount exceeds the limit of "+limit.String())
+	}
+	return nil
+}
+
+func transactionErrorStatus(code string) int {
+	switch code {
+	case models.ReasonAccountNotFound, models.ReasonReceiverNotFound, codeUserNotFound, codeTxNotFound:
+		return http.StatusNotFound
+	case codeKYCNotVerified, codeAccountNotOwned, models.ReasonAccountFrozen:
+		return http.StatusForbidden
+	case models.ReasonNotReversible:
+		return http.StatusConflict
+	case codeInvalidRequest, codeInvalidAmount, models.ReasonSameAccount, models.ReasonCurrencyMismatch:
+		return http.StatusBadRequest
+	case problem.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case models.ReasonInsufficientFunds, models.ReasonLimitExceeded, models.ReasonReversalTooLarge,
+		models.ReasonFXRateUnavailable:
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+func writeTransactionError(w http.ResponseWriter, r *http.Request, err *TransactionError) {
+	p := problem.New(transactionErrorStatus(err.Code), err.Code, err.Message)
+	p.TransactionID = err.TransactionID
+	if p.Status == http.StatusServiceUnavailable {
+		// The job was refused before it ran, so the same key may be retried
+		w.Header().Set("Retry-After", "5")
+		middleware.ReleaseIdempotencyKey(r)
+	}
+	problem.Write(w, r, p)
+}
+
+// accountTransactionError maps resolveAccount errors to transaction errors.
+func accountTransactionError(err error) *TransactionError {
+	switch err {
+	case errAccountNotFound:
+		return txError(models.ReasonAccountNotFound, "account not found")
+	case errAccountNotOwned:
+		return txError(codeAccountNotOwned, "account does not belong to user")
+	}
+	return txError(models.ReasonInternalError, "failed to retrieve account")
+}
@@ -2,77 +2,104 @@ package controllers
 
 import (
 	"encoding/json"
-	"neobank-lite/database"
+	"errors"
+	"log"
+	"neobank-lite/auth"
 	"neobank-lite/dto"
+	"neobank-lite/kyc"
+	"neobank-lite/middleware"
 	"neobank-lite/models"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+	"neobank-lite/storage"
 	"neobank-lite/utils"
 	"net/http"
-
-	"gorm.io/gorm"
+	"strconv"
+	"strings"
 )
 
+// UserHandler serves registration, login and the token endpoints.
+type UserHandler struct {
+	store     repository.Store
+	tokens    *auth.Service
+	workflow  *kyc.Service
+	documents *storage.Vault
+}
+
+func NewUserHandler(store repository.Store, tokens *auth.Service, workflow *kyc.Service, documents *storage.Vault) *UserHandler {
+	return &UserHandler{store: store, tokens: tokens, workflow: workflow, documents: documents}
+}
+
 // Register godoc
 // @Summary Register a new user
-// @Description Register a new user with name, email, password, and national ID image
+// @Description Register a new user with name, email, password and optionally the front of their national ID, which becomes their first KYC document
 // @Tags Auth
 // @Accept multipart/form-data
 // @Produce json
 // @Param name formData string true "Full Name"
 // @Param email formData string true "Email Address"
-// @Param password formData string true "Password"
-// @Param national_id formData file true "National ID Image"
+// @Param password formData string true "Password: 8 to 72 characters with a letter and a digit"
+// @Param national_id formData file false "Front of the national ID: JPEG, PNG or PDF, at most 5 MB"
 // @Success 201 {object} map[string]string
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST, VALIDATION_FAILED or USER_EXISTS"
+// @Failure 413 {object} problem.Problem "DOCUMENT_TOO_LARGE"
+// @Failure 415 {object} problem.Problem "UNSUPPORTED_DOCUMENT_TYPE"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
 // @Router /register [post]
-func Register(w http.ResponseWriter, r *http.Request) {
-	// Limit the size to 10MB
-	r.ParseMultipartForm(10 << 20)
-
-	name := r.FormValue("name")
-	email := r.FormValue("email")
-	password := r.FormValue("password")
-
-	// Get uploaded file
-	file, handler, err := r.FormFile("national_id")
-	if err != nil {
-		http.Error(w, "Failed to read national ID image", http.StatusBadRequest)
+func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
+	if !parseUpload(w, r) {
 		return
 	}
-	defer file.Close()
-
-	// Generate image path (store inside uploads/national_ids/)
-	imagePath := "./uploads/national_ids/" + handler.Filename
 
-	// Save the file to disk
-	err = utils.CreateImageFile(imagePath, file)
-	if err != nil {
-		http.Error(w, "Failed to save image", http.StatusInternalServerError)
+	req := dto.SignupRequest{
+		Name:     strings.TrimSpace(r.FormValue("name")),
+		Email:    strings.TrimSpace(r.FormValue("email")),
+		Password: r.FormValue("password"),
+	}
+	if !validRequest(w, r, &req) {
 		return
 	}
 
 	// Hash password
-	hashedPassword, err := utils.HashPassword(password)
+	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to hash password")
 		return
 	}
 
+	// The national ID is optional here; it can also be uploaded later as a KYC document
+	var documentKey string
+	if r.MultipartForm != nil && len(r.MultipartForm.File["national_id"]) > 0 {
+		var ok bool
+		if documentKey, ok = storeUpload(w, r, h.documents, "national_id", "national_ids"); !ok {
+			return
+		}
+	}
+
 	// Create user
 	user := models.User{
-		Name:       name,
-		Email:      email,
-		Password:   hashedPassword,
-		NationalID: imagePath,
-		KYCStatus:  "pending",
-		Role:       "user",
+		Name:      req.Name,
+		Email:     req.Email,
+		Password:  hashedPassword,
+		KYCStatus: models.KYCPending,
+		Role:      "user",
 	}
 
-	if result := database.DB.Create(&user); result.Error != nil {
-		http.Error(w, "User already exists or invalid data", http.StatusBadRequest)
+	if err := h.store.Users().Create(&user); err != nil {
+		if documentKey != "" {
+			h.documents.Delete(r.Context(), documentKey)
+		}
+		problem.Error(w, r, http.StatusBadRequest, codeUserExists, "User already exists or invalid data")
 		return
 	}
 
+	if documentKey != "" {
+		_, err := h.workflow.AddDocument(user.ID, kyc.DocumentNationalIDFront, documentKey, storage.ContentType(documentKey))
+		if err != nil {
+			log.Printf("⚠️ Failed to record national ID of user %d: %v", user.ID, err)
+		}
+	}
+
 	// Success
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{"message": "User registered successfully!"})
@@ -80,36 +107,111 @@ func Register(w http.ResponseWriter, r *http.Request) {
 
 // Login godoc
 // @Summary User login
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return a short-lived access token and a refresh token
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param login body dto.LoginRequest true "Login credentials"
-// @Success 200 {object} map[string]string
-// @Failure 400 {string} string "Bad Request"
-// @Failure 401 {string} string "Unauthorized"
+// @Success 200 {object} auth.TokenPair
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST or VALIDATION_FAILED"
+// @Failure 401 {object} problem.Problem "INVALID_CREDENTIALS"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
 // @Router /login [post]
-func Login(w http.ResponseWriter, r *http.Request) {
+func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	//var input models.User
 	var req dto.LoginRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		http.Error(w, "Invalid login data", http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	var user models.User
-	result := database.DB.Where("email = ?", req.Email).First(&user)
-	if result.Error == gorm.ErrRecordNotFound {
-		http.Error(w, "User not found", http.StatusUnauthorized)
+	user, err := h.store.Users().GetByEmail(req.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusUnauthorized, codeInvalidCredentials, "User not found")
+		return
+	} else if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to retrieve user")
 		return
 	}
 
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		http.Error(w, "Incorrect password", http.StatusUnauthorized)
+		problem.Error(w, r, http.StatusUnauthorized, codeInvalidCredentials, "Incorrect password")
+		return
+	}
+
+	tokens, err := h.tokens.Login(user)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to issue tokens")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// RefreshToken godoc
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and refresh token. Every refresh token works once; reusing one revokes all tokens descending from the same login.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param refresh body dto.RefreshRequest true "Refresh token"
+// @Success 200 {object} auth.TokenPair
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST or VALIDATION_FAILED"
+// @Failure 401 {object} problem.Problem "REFRESH_TOKEN_INVALID or REFRESH_TOKEN_REUSED"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /auth/refresh [post]
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req dto.RefreshRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	tokens, err := h.tokens.Refresh(req.RefreshToken)
+	if errors.Is(err, auth.ErrRefreshTokenReused) {
+		log.Println("⚠️ Refresh token reuse detected, token family revoked")
+		problem.Error(w, r, http.StatusUnauthorized, codeRefreshTokenReused, "Refresh token was already used; all sessions of this login have been revoked")
+		return
+	} else if errors.Is(err, auth.ErrInvalidRefreshToken) {
+		problem.Error(w, r, http.StatusUnauthorized, codeRefreshTokenInvalid, "Invalid or expired refresh token")
+		return
+	} else if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to refresh tokens")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke the current access token and its refresh tokens. With all set, every session of the user is revoked, e.g. after losing a phone.
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param logout body dto.LogoutRequest false "Logout options"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} problem.Problem "INVALID_REQUEST or VALIDATION_FAILED"
+// @Failure 401 {object} problem.Problem "TOKEN_MISSING, TOKEN_INVALID or TOKEN_REVOKED"
+// @Failure 500 {object} problem.Problem "INTERNAL_ERROR"
+// @Router /auth/logout [post]
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req dto.LogoutRequest
+	if r.ContentLength != 0 {
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+	}
+
+	err := h.tokens.Logout(middleware.GetTokenIDFromContext(r))
+	if err == nil && req.All {
+		userID, _ := strconv.Atoi(middleware.GetUserIDFromContext(r))
+		err = h.tokens.LogoutAll(uint(userID))
+	}
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to log out")
 		return
 	}
 
-	token, _ := utils.GenerateJWT(user.ID, user.Role)
-	json.NewEncoder(w).Encode(map[string]string{"token": token})
+	json.NewEncoder(w).Encode(map[string]string{"message": "Logged out"})
 }
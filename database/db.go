@@ -3,8 +3,10 @@ package database
 import (
 	"fmt"
 	"log"
-	"neobank-lite/models"
-	"os"
+	"neobank-lite/config"
+	"neobank-lite/database/migrations"
+	"neobank-lite/fx"
+	"neobank-lite/ledger"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -12,23 +14,49 @@ import (
 
 var DB *gorm.DB
 
-func Connect() {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"))
+// Open connects to the PostgreSQL database described by cfg.
+func Open(cfg config.Database) (*gorm.DB, error) {
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		cfg.Host,
+		cfg.User,
+		cfg.Password.Reveal(),
+		cfg.Name,
+		cfg.Port,
+		cfg.SSLMode)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
+
+// Connect opens the database, applies pending migrations, loads
+// cfg.FXRatesFile if set and sets DB.
+func Connect(cfg *config.Config) {
+	db, err := Open(cfg.Database)
 	if err != nil {
 		log.Fatal("❌ Failed to connect to DB: ", err)
 	}
 
-	// Auto Migrate
-	err = db.AutoMigrate(&models.User{}, &models.Account{}, &models.Transaction{})
+	migrator, err := NewMigrator(db, migrations.Files)
 	if err != nil {
-		log.Fatal("❌ Auto migration failed: ", err)
+		log.Fatal("❌ Failed to read migrations: ", err)
+	}
+	applied, err := migrator.Up()
+	if err != nil {
+		log.Fatal("❌ Migration failed: ", err)
+	}
+	for _, m := range applied {
+		fmt.Printf("✅ Applied migration %s\n", m)
+	}
+
+	if err := ledger.Setup(db); err != nil {
+		log.Fatal("❌ Ledger setup failed: ", err)
+	}
+
+	if path := cfg.FXRatesFile; path != "" {
+		n, err := fx.LoadFile(db, path)
+		if err != nil {
+			log.Fatal("❌ Failed to load FX rates: ", err)
+		}
+		fmt.Printf("✅ Loaded %d FX rates from %s\n", n, path)
 	}
 
 	DB = db
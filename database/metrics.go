@@ -0,0 +1,45 @@
+package database
+
+import (
+	"neobank-lite/metrics"
+
+	"gorm.io/gorm"
+)
+
+// RegisterMetrics exposes the connection pool statistics of db. It must be
+// called once.
+func RegisterMetrics(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	gauge := func(name, help string, value func() float64) {
+		metrics.NewGaugeFunc(name, help, nil, func() []metrics.Sample {
+			return []metrics.Sample{{Value: value()}}
+		})
+	}
+	counter := func(name, help string, value func() float64) {
+		metrics.NewCounterFunc(name, help, nil, func() []metrics.Sample {
+			return []metrics.Sample{{Value: value()}}
+		})
+	}
+
+	gauge("db_pool_max_open_connections", "Maximum number of open connections to the database.",
+		func() float64 { return float64(sqlDB.Stats().MaxOpenConnections) })
+	gauge("db_pool_open_connections", "Established connections, in use or idle.",
+		func() float64 { return float64(sqlDB.Stats().OpenConnections) })
+	gauge("db_pool_in_use_connections", "Connections currently in use.",
+		func() float64 { return float64(sqlDB.Stats().InUse) })
+	gauge("db_pool_idle_connections", "Idle connections.",
+		func() float64 { return float64(sqlDB.Stats().Idle) })
+	counter("db_pool_wait_count_total", "Connections waited for because the pool was exhausted.",
+		func() float64 { return float64(sqlDB.Stats().WaitCount) })
+	counter("db_pool_wait_duration_seconds_total", "Time spent waiting for a connection.",
+		func() float64 { return sqlDB.Stats().WaitDuration.Seconds() })
+	counter("db_pool_max_idle_closed_total", "Connections closed because of the idle connection limit.",
+		func() float64 { return float64(sqlDB.Stats().MaxIdleClosed) })
+	counter("db_pool_max_lifetime_closed_total", "Connections closed because they reached their maximum lifetime.",
+		func() float64 { return float64(sqlDB.Stats().MaxLifetimeClosed) })
+	return nil
+}
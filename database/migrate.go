@@ -0,0 +1,285 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockKey identifies the Postgres advisory lock held while
+// migrating. Its value is arbitrary but shared by every instance.
+const migrationLockKey = 0x6e656f62616e6b // "neobank"
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one version of the schema.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string // empty if the migration cannot be undone
+	Checksum string // SHA-256 of Up
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// MigrationState is a migration as recorded in schema_migrations. Applied
+// migrations missing from the binary have an empty Name.
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+	Changed   bool // the applied checksum differs from the file's
+}
+
+// schemaMigration is a row of schema_migrations.
+type schemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	Checksum  string    `gorm:"size:64;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// Migrator applies versioned SQL migrations and records them in
+// schema_migrations. On Postgres it holds an advisory lock while working, so
+// instances starting together apply each migration once.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator reads the migrations in fsys, named NNNN_name.up.sql and
+// NNNN_name.down.sql.
+func NewMigrator(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	files, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, f := range files {
+		match := migrationFile.FindStringSubmatch(f.Name())
+		if match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		sql, err := fs.ReadFile(fsys, f.Name())
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(sql)
+		} else {
+			m.Down = string(sql)
+		}
+	}
+
+	migrator := &Migrator{db: db}
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %s has no up file", m)
+		}
+		sum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		migrator.migrations = append(migrator.migrations, *m)
+	}
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+	return migrator, nil
+}
+
+// Up applies every pending migration in order, each in its own DB
+// transaction, and returns the ones it applied. It refuses to run if an
+// applied migration has changed or is unknown to this binary.
+func (m *Migrator) Up() ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(func(db *gorm.DB) error {
+		states, err := m.status(db)
+		if err != nil {
+			return err
+		}
+		if err := verify(states); err != nil {
+			return err
+		}
+		for _, s := range states {
+			if s.AppliedAt != nil {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(s.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{
+					Version:   s.Version,
+					Name:      s.Name,
+					Checksum:  s.Checksum,
+					AppliedAt: time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("applying migration %s: %w", s.Migration, err)
+			}
+			applied = append(applied, s.Migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// the ones it reverted.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(func(db *gorm.DB) error {
+		states, err := m.status(db)
+		if err != nil {
+			return err
+		}
+		if err := verify(states); err != nil {
+			return err
+		}
+		for i := len(states) - 1; i >= 0 && len(reverted) < steps; i-- {
+			s := states[i]
+			if s.AppliedAt == nil {
+				continue
+			}
+			if s.Down == "" {
+				return fmt.Errorf("migration %s cannot be reverted", s.Migration)
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(s.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{}, s.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %s: %w", s.Migration, err)
+			}
+			reverted = append(reverted, s.Migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known or applied migration by version.
+func (m *Migrator) Status() ([]MigrationState, error) {
+	var states []MigrationState
+	err := m.locked(func(db *gorm.DB) error {
+		var err error
+		states, err = m.status(db)
+		return err
+	})
+	return states, err
+}
+
+// Pending lists the migrations not applied yet. Unlike Up and Status it does
+// not wait for the migration lock, so it suits health checks. It fails like
+// Up if an applied migration has changed or is unknown.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return m.migrations, nil
+	}
+	states, err := m.status(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(states); err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range states {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// locked runs fn on a single connection holding the migration lock, after
+// making sure schema_migrations exists.
+func (m *Migrator) locked(fn func(db *gorm.DB) error) error {
+	return m.db.Connection(func(db *gorm.DB) error {
+		if db.Dialector.Name() == "postgres" {
+			if err := db.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+				return fmt.Errorf("taking migration lock: %w", err)
+			}
+			defer db.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey)
+		}
+		if !db.Migrator().HasTable(&schemaMigration{}) {
+			if err := db.Migrator().CreateTable(&schemaMigration{}); err != nil {
+				return err
+			}
+		}
+		return fn(db)
+	})
+}
+
+func (m *Migrator) status(db *gorm.DB) ([]MigrationState, error) {
+	var rows []schemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	states := make([]MigrationState, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		s := MigrationState{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			s.AppliedAt = &appliedAt
+			s.Changed = row.Checksum != migration.Checksum
+			delete(applied, migration.Version)
+		}
+		states = append(states, s)
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		states = append(states, MigrationState{
+			Migration: Migration{Version: row.Version, Checksum: row.Checksum},
+			AppliedAt: &appliedAt,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, nil
+}
+
+// verify fails if an applied migration was edited afterwards or is unknown
+// to this binary, which usually means it was built from older sources.
+func verify(states []MigrationState) error {
+	var errs []error
+	for _, s := range states {
+		switch {
+		case s.Changed:
+			errs = append(errs, fmt.Errorf("migration %s was changed after it was applied", s.Migration))
+		case s.AppliedAt != nil && s.Name == "":
+			errs = append(errs, fmt.Errorf("migration %04d is applied but unknown to this binary", s.Version))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"neobank-lite/database/migrations"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_accounts.up.sql":   {Data: []byte(`CREATE TABLE accounts (number text PRIMARY KEY);`)},
+		"0001_accounts.down.sql": {Data: []byte(`DROP TABLE accounts;`)},
+		"0002_balance.up.sql":    {Data: []byte(`ALTER TABLE accounts ADD COLUMN balance integer;`)},
+		"embed.go":               {Data: []byte(`package migrations`)},
+	}
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1) // every connection to :memory: is a new database
+	return db
+}
+
+func TestNewMigrator(t *testing.T) {
+	m, err := NewMigrator(nil, testFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.migrations) != 2 {
+		t.Fatalf("got %d migrations, want 2", len(m.migrations))
+	}
+	for i, want := range []string{"0001_accounts", "0002_balance"} {
+		got := m.migrations[i]
+		if got.String() != want {
+			t.Errorf("migration %d is %s, want %s", i, got, want)
+		}
+		sum := sha256.Sum256([]byte(got.Up))
+		if got.Checksum != hex.EncodeToString(sum[:]) {
+			t.Errorf("%s: checksum %s is not the SHA-256 of its up file", got, got.Checksum)
+		}
+	}
+	if m.migrations[0].Down == "" || m.migrations[1].Down != "" {
+		t.Errorf("down files not matched to their migrations")
+	}
+}
+
+// The shipped migrations are numbered without gaps and can all be undone.
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := NewMigrator(nil, migrations.Files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, migration := range m.migrations {
+		if migration.Version != i+1 {
+			t.Errorf("%s should be version %d", migration, i+1)
+		}
+		if migration.Down == "" {
+			t.Errorf("%s has no down file", migration)
+		}
+	}
+}
+
+func TestNewMigratorRejectsBadFiles(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"no up file": {
+			"0001_a.down.sql": {Data: []byte(`SELECT 1;`)},
+		},
+		"two names": {
+			"0001_a.up.sql":   {Data: []byte(`SELECT 1;`)},
+			"0001_b.down.sql": {Data: []byte(`SELECT 1;`)},
+		},
+	}
+	for name, fsys := range tests {
+		if _, err := NewMigrator(nil, fsys); err == nil {
+			t.Errorf("%s: NewMigrator succeeded", name)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		states []MigrationState
+		want   string // in the error, none if empty
+	}{
+		{"pending", []MigrationState{{Migration: Migration{Version: 1, Name: "a"}}}, ""},
+		{"applied", []MigrationState{{Migration: Migration{Version: 1, Name: "a"}, AppliedAt: &now}}, ""},
+		{"changed", []MigrationState{{Migration: Migration{Version: 1, Name: "a"}, AppliedAt: &now, Changed: true}}, "0001_a was changed"},
+		{"unknown", []MigrationState{{Migration: Migration{Version: 7}, AppliedAt: &now}}, "0007 is applied but unknown"},
+	}
+	for _, tt := range tests {
+		err := verify(tt.states)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: got error %v, want one containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := openTestDB(t)
+	m, err := NewMigrator(db, testFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up()
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("Up applied %v, %v; want both migrations", applied, err)
+	}
+	if applied, err = m.Up(); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up applied %v, %v; want nothing", applied, err)
+	}
+	if !db.Migrator().HasColumn("accounts", "balance") {
+		t.Fatal("accounts.balance was not added")
+	}
+
+	if _, err := m.Down(1); err == nil || !strings.Contains(err.Error(), "cannot be reverted") {
+		t.Fatalf("Down of a migration without down file: got %v", err)
+	}
+	states, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range states {
+		if s.AppliedAt == nil {
+			t.Errorf("%s was reverted by a failed Down", s.Migration)
+		}
+	}
+}
+
+func TestUpRefusesChangedMigration(t *testing.T) {
+	db := openTestDB(t)
+	fsys := testFS()
+	m, err := NewMigrator(db, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys["0001_accounts.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE accounts (number text PRIMARY KEY, owner text);`)}
+	fsys["0003_owner.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE owners (id integer);`)}
+	if m, err = NewMigrator(db, fsys); err != nil {
+		t.Fatal(err)
+	}
+	states, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !states[0].Changed || states[1].Changed {
+		t.Errorf("Status does not flag exactly the edited migration as changed")
+	}
+	if _, err := m.Up(); err == nil || !strings.Contains(err.Error(), "0001_accounts was changed") {
+		t.Errorf("Up with an edited migration: got %v", err)
+	}
+	if db.Migrator().HasTable("owners") {
+		t.Error("Up applied a pending migration despite the edited one")
+	}
+	if _, err := m.Pending(context.Background()); err == nil {
+		t.Error("Pending succeeded despite the edited migration")
+	}
+}
+
+func TestUpRefusesUnknownMigration(t *testing.T) {
+	db := openTestDB(t)
+	fsys := testFS()
+	fsys["0003_owner.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE owners (id integer);`)}
+	m, err := NewMigrator(db, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A binary built before 0003 existed.
+	if m, err = NewMigrator(db, testFS()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err == nil || !strings.Contains(err.Error(), "0003 is applied but unknown") {
+		t.Errorf("Up with an unknown applied migration: got %v", err)
+	}
+}
+
+func TestFailedMigrationIsRolledBack(t *testing.T) {
+	db := openTestDB(t)
+	fsys := testFS()
+	fsys["0003_broken.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE owners (id integer); INSERT INTO missing VALUES (1);`)}
+	m, err := NewMigrator(db, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up()
+	if err == nil || !strings.Contains(err.Error(), "0003_broken") {
+		t.Fatalf("Up with a broken migration: got %v", err)
+	}
+	if len(applied) != 2 {
+		t.Errorf("Up applied %v before failing, want the first two", applied)
+	}
+	if db.Migrator().HasTable("owners") {
+		t.Error("the broken migration was partly applied")
+	}
+	pending, err := m.Pending(context.Background())
+	if err != nil || len(pending) != 1 || pending[0].Version != 3 {
+		t.Errorf("Pending = %v, %v; want 0003_broken", pending, err)
+	}
+}
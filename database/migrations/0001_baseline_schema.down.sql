@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "transactions";
+DROP TABLE IF EXISTS "accounts";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,43 @@
+-- Schema of the first release, as created by its AutoMigrate. Databases
+-- that release created already have it, hence IF NOT EXISTS; every later
+-- change is a migration of its own.
+
+CREATE TABLE IF NOT EXISTS "users" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"name" text,
+	"email" text,
+	"password" text,
+	"kyc_status" text DEFAULT 'pending',
+	"national_id" text,
+	"role" text DEFAULT 'user',
+	PRIMARY KEY ("id"),
+	CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "accounts" (
+	"id" bigint,
+	"created_at" text,
+	"updated_at" text,
+	"deleted_at" text,
+	"account_number" text,
+	"user_id" bigint,
+	"balance" decimal,
+	"account_type" text,
+	"phone_number" bigint,
+	PRIMARY KEY ("account_number")
+);
+
+CREATE TABLE IF NOT EXISTS "transactions" (
+	"id" bigserial,
+	"from_account" text,
+	"to_account" text,
+	"amount" decimal,
+	"timestamp" timestamptz,
+	"type" text,
+	"status" text,
+	PRIMARY KEY ("id")
+);
@@ -0,0 +1,21 @@
+CREATE TABLE "ledger_accounts" (
+	"code" text,
+	"name" text,
+	"type" text,
+	"account_number" text,
+	"created_at" timestamptz,
+	PRIMARY KEY ("code")
+);
+CREATE UNIQUE INDEX "idx_ledger_accounts_account_number" ON "ledger_accounts" ("account_number");
+
+CREATE TABLE "ledger_entries" (
+	"id" bigserial,
+	"transaction_id" bigint,
+	"ledger_account" text,
+	"direction" text,
+	"amount" decimal,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_ledger_entries_ledger_account" ON "ledger_entries" ("ledger_account");
+CREATE INDEX "idx_ledger_entries_transaction_id" ON "ledger_entries" ("transaction_id");
@@ -0,0 +1,22 @@
+-- The float columns have no currency, so this refuses to run once any
+-- amount is in a currency other than ETB.
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM "accounts" WHERE "balance_currency" <> 'ETB')
+		OR EXISTS (SELECT 1 FROM "transactions" WHERE "amount_currency" <> 'ETB')
+		OR EXISTS (SELECT 1 FROM "ledger_entries" WHERE "amount_currency" <> 'ETB') THEN
+		RAISE EXCEPTION 'amounts in currencies other than ETB cannot be converted back to floats';
+	END IF;
+END $$;
+
+ALTER TABLE "ledger_entries" ADD COLUMN "amount" decimal;
+UPDATE "ledger_entries" SET "amount" = "amount_minor" / 100.0;
+ALTER TABLE "ledger_entries" DROP COLUMN "amount_minor", DROP COLUMN "amount_currency";
+
+ALTER TABLE "transactions" ADD COLUMN "amount" decimal;
+UPDATE "transactions" SET "amount" = "amount_minor" / 100.0;
+ALTER TABLE "transactions" DROP COLUMN "amount_minor", DROP COLUMN "amount_currency";
+
+ALTER TABLE "accounts" ADD COLUMN "balance" decimal;
+UPDATE "accounts" SET "balance" = "balance_minor" / 100.0;
+ALTER TABLE "accounts" DROP COLUMN "balance_minor", DROP COLUMN "balance_currency";
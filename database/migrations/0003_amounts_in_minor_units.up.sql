@@ -0,0 +1,20 @@
+-- Amounts were floats in ETB. They become integer minor units (cents) with a
+-- currency, rounded to the nearest cent.
+
+ALTER TABLE "accounts"
+	ADD COLUMN "balance_minor" bigint NOT NULL DEFAULT 0,
+	ADD COLUMN "balance_currency" varchar(3) NOT NULL DEFAULT 'ETB';
+UPDATE "accounts" SET "balance_minor" = ROUND("balance" * 100) WHERE "balance" IS NOT NULL;
+ALTER TABLE "accounts" DROP COLUMN "balance";
+
+ALTER TABLE "transactions"
+	ADD COLUMN "amount_minor" bigint NOT NULL DEFAULT 0,
+	ADD COLUMN "amount_currency" varchar(3) NOT NULL DEFAULT 'ETB';
+UPDATE "transactions" SET "amount_minor" = ROUND("amount" * 100) WHERE "amount" IS NOT NULL;
+ALTER TABLE "transactions" DROP COLUMN "amount";
+
+ALTER TABLE "ledger_entries"
+	ADD COLUMN "amount_minor" bigint NOT NULL DEFAULT 0,
+	ADD COLUMN "amount_currency" varchar(3) NOT NULL DEFAULT 'ETB';
+UPDATE "ledger_entries" SET "amount_minor" = ROUND("amount" * 100) WHERE "amount" IS NOT NULL;
+ALTER TABLE "ledger_entries" DROP COLUMN "amount";
@@ -0,0 +1,15 @@
+CREATE TABLE "idempotency_keys" (
+	"id" bigserial,
+	"user_id" text NOT NULL,
+	"key" varchar(255) NOT NULL,
+	"fingerprint" varchar(64) NOT NULL,
+	"completed" boolean NOT NULL DEFAULT false,
+	"status_code" bigint,
+	"content_type" text,
+	"response_body" bytea,
+	"created_at" timestamptz,
+	"expires_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_idempotency_keys_expires_at" ON "idempotency_keys" ("expires_at");
+CREATE UNIQUE INDEX "idx_idempotency_user_key" ON "idempotency_keys" ("user_id", "key");
@@ -0,0 +1,7 @@
+ALTER TABLE "accounts" ADD COLUMN "is_default" boolean NOT NULL DEFAULT false;
+
+-- Users opened a single account before they could hold several; it becomes
+-- their default.
+UPDATE "accounts" SET "is_default" = true WHERE "account_number" IN (
+	SELECT MIN("account_number") FROM "accounts" GROUP BY "user_id"
+);
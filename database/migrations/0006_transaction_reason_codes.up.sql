@@ -0,0 +1 @@
+ALTER TABLE "transactions" ADD COLUMN "reason_code" varchar(32);
@@ -0,0 +1,4 @@
+ALTER TABLE "transactions"
+	ADD COLUMN "reversal_of" bigint,
+	ADD COLUMN "description" text;
+CREATE INDEX "idx_transactions_reversal_of" ON "transactions" ("reversal_of");
@@ -0,0 +1,6 @@
+ALTER TABLE "transactions"
+	DROP COLUMN "to_amount_minor",
+	DROP COLUMN "to_amount_currency",
+	DROP COLUMN "fx_rate";
+
+DROP TABLE "fx_rates";
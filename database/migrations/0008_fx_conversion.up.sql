@@ -0,0 +1,12 @@
+CREATE TABLE "fx_rates" (
+	"base" varchar(3),
+	"quote" varchar(3),
+	"rate" varchar(32) NOT NULL,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("base", "quote")
+);
+
+ALTER TABLE "transactions"
+	ADD COLUMN "to_amount_minor" bigint NOT NULL DEFAULT 0,
+	ADD COLUMN "to_amount_currency" varchar(3) NOT NULL DEFAULT 'ETB',
+	ADD COLUMN "fx_rate" varchar(32);
@@ -0,0 +1,24 @@
+CREATE TABLE "refresh_tokens" (
+	"id" bigserial,
+	"user_id" bigint NOT NULL,
+	"family_id" varchar(36) NOT NULL,
+	"token_hash" varchar(64) NOT NULL,
+	"access_jti" varchar(36),
+	"expires_at" timestamptz NOT NULL,
+	"used_at" timestamptz,
+	"revoked_at" timestamptz,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_refresh_tokens_access_jti" ON "refresh_tokens" ("access_jti");
+CREATE UNIQUE INDEX "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+CREATE INDEX "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
+CREATE INDEX "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+
+CREATE TABLE "revoked_tokens" (
+	"jti" varchar(36),
+	"expires_at" timestamptz NOT NULL,
+	"created_at" timestamptz,
+	PRIMARY KEY ("jti")
+);
+CREATE INDEX "idx_revoked_tokens_expires_at" ON "revoked_tokens" ("expires_at");
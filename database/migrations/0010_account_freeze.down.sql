@@ -0,0 +1,4 @@
+ALTER TABLE "accounts"
+	DROP COLUMN "status",
+	DROP COLUMN "frozen_reason",
+	DROP COLUMN "frozen_at";
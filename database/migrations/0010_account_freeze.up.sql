@@ -0,0 +1,4 @@
+ALTER TABLE "accounts"
+	ADD COLUMN "status" varchar(16) NOT NULL DEFAULT 'active',
+	ADD COLUMN "frozen_reason" text,
+	ADD COLUMN "frozen_at" timestamptz;
@@ -0,0 +1,8 @@
+DROP TABLE "notifications";
+DROP TABLE "kyc_events";
+
+ALTER TABLE "users"
+	DROP COLUMN "kyc_reason",
+	DROP COLUMN "kyc_submitted_at",
+	DROP COLUMN "kyc_reviewer_id",
+	DROP COLUMN "kyc_reviewed_at";
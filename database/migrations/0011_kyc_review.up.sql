@@ -0,0 +1,27 @@
+ALTER TABLE "users"
+	ADD COLUMN "kyc_reason" text,
+	ADD COLUMN "kyc_submitted_at" timestamptz,
+	ADD COLUMN "kyc_reviewer_id" bigint,
+	ADD COLUMN "kyc_reviewed_at" timestamptz;
+
+CREATE TABLE "kyc_events" (
+	"id" bigserial,
+	"user_id" bigint NOT NULL,
+	"from_status" varchar(32) NOT NULL,
+	"to_status" varchar(32) NOT NULL,
+	"actor_id" bigint,
+	"reason" text,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_kyc_events_user_id" ON "kyc_events" ("user_id");
+
+CREATE TABLE "notifications" (
+	"id" bigserial,
+	"user_id" bigint NOT NULL,
+	"title" text,
+	"body" text,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_notifications_user_id" ON "notifications" ("user_id");
@@ -0,0 +1,15 @@
+CREATE TABLE "kyc_checks" (
+	"id" bigserial,
+	"user_id" bigint NOT NULL,
+	"provider" varchar(32) NOT NULL,
+	"reference" varchar(128) NOT NULL,
+	"status" varchar(32) NOT NULL,
+	"reason" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"completed_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_kyc_checks_status" ON "kyc_checks" ("status");
+CREATE UNIQUE INDEX "idx_kyc_check_reference" ON "kyc_checks" ("provider", "reference");
+CREATE INDEX "idx_kyc_checks_user_id" ON "kyc_checks" ("user_id");
@@ -0,0 +1 @@
+UPDATE "users" SET "national_id" = './uploads/' || "national_id" WHERE "national_id" <> '';
@@ -0,0 +1,3 @@
+-- National IDs were stored as file paths under ./uploads; they become keys
+-- of the document store, which are relative to it.
+UPDATE "users" SET "national_id" = SUBSTR("national_id", 11) WHERE "national_id" LIKE './uploads/%';
@@ -0,0 +1,18 @@
+CREATE TABLE "kyc_documents" (
+	"id" bigserial,
+	"user_id" bigint NOT NULL,
+	"type" varchar(32) NOT NULL,
+	"storage_key" text NOT NULL,
+	"content_type" text,
+	"submission_id" bigint,
+	"replaced_at" timestamptz,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_kyc_documents_user_id" ON "kyc_documents" ("user_id");
+
+-- The national ID of users who registered before KYC documents existed
+-- becomes their national_id_front document.
+INSERT INTO "kyc_documents" ("user_id", "type", "storage_key", "content_type", "created_at")
+	SELECT "id", 'national_id_front', "national_id", '', "created_at" FROM "users"
+	WHERE "national_id" <> '';
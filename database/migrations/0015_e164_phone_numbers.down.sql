@@ -0,0 +1,2 @@
+ALTER TABLE "accounts" ALTER COLUMN "phone_number" TYPE bigint USING COALESCE(NULLIF(
+	regexp_replace(regexp_replace("phone_number", '^\+251', ''), '[^0-9]', '', 'g'), '')::bigint, 0);
@@ -0,0 +1,8 @@
+-- Phone numbers were integers, so they lost their leading + and 0, and
+-- accounts opened without one stored 0. They become E.164 text. Ethiopian
+-- numbers, with or without the country code, get +251; anything else keeps
+-- its digits and has to be corrected by the customer.
+ALTER TABLE "accounts" ALTER COLUMN "phone_number" TYPE text USING "phone_number"::text;
+UPDATE "accounts" SET "phone_number" = '' WHERE "phone_number" = '0';
+UPDATE "accounts" SET "phone_number" = '+251' || "phone_number" WHERE "phone_number" ~ '^[79][0-9]{8}$';
+UPDATE "accounts" SET "phone_number" = '+' || "phone_number" WHERE "phone_number" ~ '^251[79][0-9]{8}$';
@@ -0,0 +1,12 @@
+// Package migrations holds the versioned SQL migrations of the database
+// schema, applied in order by database.Migrator. Each version has an
+// NNNN_name.up.sql file and, if it can be undone, an NNNN_name.down.sql file.
+//
+// Applied migrations are checksummed and must never be edited; change the
+// schema by adding the next version, and keep the models in step with it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var Files embed.FS
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+
+	"neobank-lite/database/migrations"
+
+	"gorm.io/gorm"
+)
+
+// Status answers the readiness probe's questions about the database.
+type Status struct {
+	db *gorm.DB
+}
+
+func NewStatus(db *gorm.DB) Status {
+	return Status{db: db}
+}
+
+// Ping checks that the database answers.
+func (s Status) Ping(ctx context.Context) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Pending lists the migrations that are not applied yet.
+func (s Status) Pending(ctx context.Context) ([]Migration, error) {
+	migrator, err := NewMigrator(s.db, migrations.Files)
+	if err != nil {
+		return nil, err
+	}
+	return migrator.Pending(ctx)
+}
@@ -1,7 +1,9 @@
 package dto
 
+// CreateAccountRequest opens an empty account; money only comes in through
+// a deposit or transfer.
 type CreateAccountRequest struct {
-	Balance     float64 `json:"balance"`
-	AccountType string  `json:"account_type"`
-	PhoneNumber int     `json:"phone_number"`
+	Currency    string `json:"currency,omitempty" example:"USD"`                                                     // ISO 4217, defaults to ETB
+	AccountType string `json:"account_type,omitempty" validate:"omitempty,oneof=checking savings" example:"savings"` // defaults to checking
+	PhoneNumber string `json:"phone_number" validate:"required,e164" example:"+251911234567"`
 }
@@ -0,0 +1,9 @@
+package dto
+
+type FreezeAccountRequest struct {
+	Reason string `json:"reason" validate:"required,max=500" example:"Customer reported a stolen phone"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin support compliance auditor" example:"support"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+type FXRateRequest struct {
+	Base  string `json:"base" validate:"required" example:"USD"`
+	Quote string `json:"quote" validate:"required" example:"ETB"`
+	Rate  string `json:"rate" validate:"required" example:"57.25"` // units of quote per unit of base
+}
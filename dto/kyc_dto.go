@@ -3,3 +3,7 @@ package dto
 type KYCRequest struct {
 	UserID uint `json:"user_id"`
 }
+
+type KYCDecisionRequest struct {
+	Reason string `json:"reason" validate:"max=500" example:"National ID photo is unreadable"`
+}
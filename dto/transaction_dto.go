@@ -1,5 +1,19 @@
 package dto
 
+import "neobank-lite/money"
+
 type DepositRequest struct {
-	Amount float64 `json:"amount"`
+	AccountNumber string        `json:"account_number,omitempty"` // defaults to the user's default account
+	Amount        money.Decimal `json:"amount" validate:"required" swaggertype:"string" example:"100.50"`
+}
+
+type WithdrawRequest struct {
+	AccountNumber string        `json:"account_number,omitempty"` // defaults to the user's default account
+	Amount        money.Decimal `json:"amount" validate:"required" swaggertype:"string" example:"200.00"`
+}
+
+type ReversalRequest struct {
+	Amount money.Decimal `json:"amount,omitempty" swaggertype:"string" example:"50.00"` // defaults to the amount not yet reversed
+	Reason string        `json:"reason" validate:"max=500" example:"Mistaken transfer"`
+	Force  bool          `json:"force"` // reverse even if the account being debited lacks the funds
 }
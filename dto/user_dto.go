@@ -1,11 +1,19 @@
 package dto
 
 type SignupRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name     string `json:"name" validate:"required,max=100" example:"Abebe Kebede"`
+	Email    string `json:"email" validate:"required,email,max=254" example:"abebe@example.com"`
+	Password string `json:"password" validate:"required,password" example:"s3cretpass"` // 8 to 72 characters with a letter and a digit
 }
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	All bool `json:"all"` // sign out every device, not just this session
 }
@@ -0,0 +1,109 @@
+// Package fx keeps the table of exchange rates used to convert transfers
+// between accounts of different currencies.
+package fx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"neobank-lite/models"
+	"neobank-lite/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNoRate       = errors.New("no exchange rate")
+	ErrSameCurrency = errors.New("base and quote currencies must differ")
+)
+
+// RateFor returns the configured rate for converting from into to.
+func RateFor(db *gorm.DB, from, to string) (models.FXRate, error) {
+	var rate models.FXRate
+	err := db.Where("base = ? AND quote = ?", from, to).First(&rate).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.FXRate{}, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+	}
+	return rate, err
+}
+
+// NewRate validates a rate for base into quote and returns it in canonical
+// form, ready to be stored.
+func NewRate(base, quote, rate string) (models.FXRate, error) {
+	if _, err := money.Exponent(base); err != nil {
+		return models.FXRate{}, err
+	}
+	if _, err := money.Exponent(quote); err != nil {
+		return models.FXRate{}, err
+	}
+	if base == quote {
+		return models.FXRate{}, ErrSameCurrency
+	}
+	canonical, err := money.ParseRate(rate)
+	if err != nil {
+		return models.FXRate{}, err
+	}
+	return models.FXRate{Base: base, Quote: quote, Rate: canonical, UpdatedAt: time.Now()}, nil
+}
+
+// SetRate validates and stores the rate for base into quote, replacing any
+// previous rate for the pair.
+func SetRate(db *gorm.DB, base, quote, rate string) (models.FXRate, error) {
+	record, err := NewRate(base, quote, rate)
+	if err != nil {
+		return models.FXRate{}, err
+	}
+	return record, Put(db, record)
+}
+
+// Put stores a rate made by NewRate, replacing any previous rate for the pair.
+func Put(db *gorm.DB, rate models.FXRate) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base"}, {Name: "quote"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(&rate).Error
+}
+
+// List returns all configured rates ordered by currency pair.
+func List(db *gorm.DB) ([]models.FXRate, error) {
+	rates := []models.FXRate{}
+	err := db.Order("base, quote").Find(&rates).Error
+	return rates, err
+}
+
+// LoadFile stores every rate in a JSON file of the form
+//
+//	[{"base": "USD", "quote": "ETB", "rate": "57.25"}]
+//
+// in a single transaction, so a bad entry leaves the table untouched.
+func LoadFile(db *gorm.DB, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var entries []struct {
+		Base  string `json:"base"`
+		Quote string `json:"quote"`
+		Rate  string `json:"rate"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, e := range entries {
+			if _, err := SetRate(tx, e.Base, e.Quote, e.Rate); err != nil {
+				return fmt.Errorf("%s/%s: %w", e.Base, e.Quote, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,128 @@
+package kyc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"neobank-lite/models"
+	"neobank-lite/repository"
+)
+
+// Document types a user can upload.
+const (
+	DocumentNationalIDFront = "national_id_front"
+	DocumentNationalIDBack  = "national_id_back"
+	DocumentPassport        = "passport"
+	DocumentSelfie          = "selfie"
+	DocumentProofOfAddress  = "proof_of_address"
+)
+
+var DocumentTypes = []string{
+	DocumentNationalIDFront,
+	DocumentNationalIDBack,
+	DocumentPassport,
+	DocumentSelfie,
+	DocumentProofOfAddress,
+}
+
+var (
+	ErrUnknownDocumentType = errors.New("unknown document type")
+	ErrNoIdentityDocument  = errors.New("a national ID or passport is required")
+	ErrDocumentsLocked     = errors.New("documents can only be changed before submitting or when asked to resubmit")
+	ErrDocumentNotFound    = errors.New("document not found")
+)
+
+// AddDocument records a stored document for the user, replacing their
+// current document of the same type. The front of the national ID is also
+// kept on the user as NationalID.
+func (s *Service) AddDocument(userID uint, docType, storageKey, contentType string) (models.KYCDocument, error) {
+	if !IsDocumentType(docType) {
+		return models.KYCDocument{}, ErrUnknownDocumentType
+	}
+
+	document := models.KYCDocument{
+		UserID:      userID,
+		Type:        docType,
+		StorageKey:  storageKey,
+		ContentType: contentType,
+	}
+	err := s.store.Atomic(func(tx repository.Store) error {
+		user, err := tx.Users().Lock(userID)
+		if err != nil {
+			return err
+		}
+		if user.KYCStatus != models.KYCPending && user.KYCStatus != models.KYCResubmissionRequired {
+			return ErrDocumentsLocked
+		}
+
+		if err := tx.KYC().ReplaceDocuments(userID, docType, time.Now()); err != nil {
+			return err
+		}
+		if err := tx.KYC().CreateDocument(&document); err != nil {
+			return err
+		}
+		if docType == DocumentNationalIDFront {
+			return tx.Users().SetNationalID(user.ID, storageKey)
+		}
+		return nil
+	})
+	return document, err
+}
+
+// ReplaceDocument replaces one of the user's current documents with a new
+// upload of the same type.
+func (s *Service) ReplaceDocument(userID, documentID uint, storageKey, contentType string) (models.KYCDocument, error) {
+	current, err := s.store.KYC().CurrentDocument(userID, documentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.KYCDocument{}, ErrDocumentNotFound
+	} else if err != nil {
+		return models.KYCDocument{}, err
+	}
+	return s.AddDocument(userID, current.Type, storageKey, contentType)
+}
+
+// Documents returns the user's current documents. With all set, replaced
+// ones are included too.
+func (s *Service) Documents(userID uint, all bool) ([]models.KYCDocument, error) {
+	return s.store.KYC().Documents(userID, all)
+}
+
+// linkDocuments attaches the user's current documents to a submission,
+// which needs at least a national ID or a passport.
+func linkDocuments(records repository.KYCRepository, userID, submissionID uint) error {
+	current, err := records.Documents(userID, false)
+	if err != nil {
+		return err
+	}
+	identity := false
+	for _, document := range current {
+		if document.Type == DocumentNationalIDFront || document.Type == DocumentPassport {
+			identity = true
+		}
+	}
+	if !identity {
+		return ErrNoIdentityDocument
+	}
+	return records.LinkDocuments(userID, submissionID)
+}
+
+// EncryptLegacyDocuments encrypts the stored documents that were uploaded
+// before documents were encrypted, see storage.Vault.EncryptLegacy.
+func (s *Service) EncryptLegacyDocuments(ctx context.Context) (int, error) {
+	keys, err := s.store.KYC().DocumentKeys()
+	if err != nil {
+		return 0, err
+	}
+	return s.documents.EncryptLegacy(ctx, keys)
+}
+
+// IsDocumentType reports whether docType is one of DocumentTypes.
+func IsDocumentType(docType string) bool {
+	for _, t := range DocumentTypes {
+		if t == docType {
+			return true
+		}
+	}
+	return false
+}
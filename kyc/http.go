@@ -0,0 +1,172 @@
+package kyc
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPProvider talks to a verification service over a small JSON API:
+//
+//	POST {base}/verifications       submit, answers with a verification
+//	GET  {base}/verifications/{id}  current state of a verification
+//
+// A verification is {"id": "...", "status": "...", "reason": "..."} with one
+// of the Check* statuses. The service calls the webhook with the same body,
+// signed in the X-Signature header as "sha256=" followed by the hex HMAC-SHA256
+// of the body under the webhook secret.
+type HTTPProvider struct {
+	baseURL       string
+	apiKey        string
+	webhookSecret string
+	client        *http.Client
+}
+
+// NewHTTPProvider returns a provider for the service at baseURL. Webhooks
+// are rejected unless a webhook secret is set.
+func NewHTTPProvider(baseURL, apiKey, webhookSecret string) (*HTTPProvider, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("KYC provider URL %q is not an absolute URL", baseURL)
+	}
+	return &HTTPProvider{
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		apiKey:        apiKey,
+		webhookSecret: webhookSecret,
+		client:        &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// verification is the JSON the service answers and calls the webhook with.
+type verification struct {
+	ID        string `json:"id"`
+	Reference string `json:"reference"` // alternative to id
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+}
+
+func (v verification) toResult() Result {
+	reference := v.ID
+	if reference == "" {
+		reference = v.Reference
+	}
+	return Result{Reference: reference, Status: v.Status, Reason: v.Reason}
+}
+
+type verificationRequest struct {
+	ExternalID string                 `json:"external_id"`
+	Name       string                 `json:"name"`
+	Documents  []verificationDocument `json:"documents"`
+}
+
+type verificationDocument struct {
+	Type        string `json:"type"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content"` // base64
+}
+
+func (p *HTTPProvider) Name() string { return "http" }
+
+func (p *HTTPProvider) Submit(ctx context.Context, s Submission) (Result, error) {
+	req := verificationRequest{
+		ExternalID: strconv.FormatUint(uint64(s.UserID), 10),
+		Name:       s.Name,
+		Documents:  []verificationDocument{},
+	}
+	for _, d := range s.Documents {
+		req.Documents = append(req.Documents, verificationDocument{
+			Type:        d.Type,
+			ContentType: d.ContentType,
+			Content:     base64.StdEncoding.EncodeToString(d.Data),
+		})
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Result{}, err
+	}
+	return p.do(ctx, http.MethodPost, "/verifications", bytes.NewReader(body))
+}
+
+func (p *HTTPProvider) Check(ctx context.Context, reference string) (Result, error) {
+	return p.do(ctx, http.MethodGet, "/verifications/"+url.PathEscape(reference), nil)
+}
+
+func (p *HTTPProvider) ParseWebhook(r *http.Request) (Result, error) {
+	return parseSignedWebhook(r, p.webhookSecret)
+}
+
+// parseSignedWebhook reads a verification from a webhook call whose body is
+// signed in the X-Signature header as "sha256=" followed by the hex
+// HMAC-SHA256 of the body under secret. Without a secret every call is
+// rejected.
+func parseSignedWebhook(r *http.Request, secret string) (Result, error) {
+	if secret == "" {
+		return Result{}, ErrInvalidSignature
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return Result{}, err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(r.Header.Get("X-Signature")), []byte(want)) {
+		return Result{}, ErrInvalidSignature
+	}
+
+	var v verification
+	if err := json.Unmarshal(body, &v); err != nil {
+		return Result{}, err
+	}
+	return v.toResult(), nil
+}
+
+func (p *HTTPProvider) do(ctx context.Context, method, path string, body io.Reader) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Result{}, ErrUnknownCheck
+	}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return Result{}, fmt.Errorf("KYC provider answered %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var v verification
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return Result{}, fmt.Errorf("decoding KYC provider response: %w", err)
+	}
+	result := v.toResult()
+	if result.Reference == "" {
+		return Result{}, errors.New("KYC provider response has no id")
+	}
+	return result, nil
+}
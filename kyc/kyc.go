@@ -0,0 +1,207 @@
+// Package kyc moves users through the KYC review workflow. A user submits
+// their details (from pending, or after being asked to resubmit), a
+// compliance reviewer takes the submission under review and then verifies
+// it, rejects it or asks for a resubmission. Every transition is recorded as
+// a models.KYCEvent, and the user is notified when a reviewer decides.
+//
+// With a Provider configured, submissions are first sent to it and its
+// verdict decides in place of a reviewer; only submissions it cannot decide
+// are left in the queue.
+package kyc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"neobank-lite/models"
+	"neobank-lite/notify"
+	"neobank-lite/repository"
+	"neobank-lite/storage"
+)
+
+var (
+	ErrReasonRequired = errors.New("a reason is required")
+	ErrOwnReview      = errors.New("reviewers cannot review their own KYC")
+	ErrNotReviewer    = errors.New("the review was taken by another reviewer")
+)
+
+// TransitionError is returned when a user's KYC is not in a state that
+// allows the requested change.
+type TransitionError struct {
+	From, To string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("KYC cannot move from %s to %s", e.From, e.To)
+}
+
+// transitions lists the statuses each status can move to.
+var transitions = map[string][]string{
+	models.KYCPending:              {models.KYCSubmitted},
+	models.KYCResubmissionRequired: {models.KYCSubmitted},
+	models.KYCSubmitted:            {models.KYCUnderReview},
+	models.KYCUnderReview:          {models.KYCVerified, models.KYCRejected, models.KYCResubmissionRequired},
+}
+
+// Decisions are the statuses a reviewer can end a review with.
+var Decisions = transitions[models.KYCUnderReview]
+
+// CanTransition reports whether KYC may move from one status to another.
+func CanTransition(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Service runs the KYC workflow. Its records are kept in a store, the
+// documents in a vault.
+type Service struct {
+	store     repository.Store
+	documents *storage.Vault
+	provider  Provider
+}
+
+// NewService returns a Service keeping its records in store and reading
+// documents from documents. With a nil provider, compliance staff review
+// every submission.
+func NewService(store repository.Store, documents *storage.Vault, provider Provider) *Service {
+	return &Service{store: store, documents: documents, provider: provider}
+}
+
+// in returns the service working inside the unit of work tx.
+func (s *Service) in(tx repository.Store) *Service {
+	c := *s
+	c.store = tx
+	return &c
+}
+
+// Submit hands the user's KYC details in for review, sending them to the
+// provider if there is one.
+func (s *Service) Submit(userID uint) (models.User, error) {
+	user, err := s.transition(userID, userID, models.KYCSubmitted, "", func(tx repository.Store, user *models.User, event *models.KYCEvent) error {
+		if err := linkDocuments(tx.KYC(), user.ID, event.ID); err != nil {
+			return err
+		}
+		user.KYCSubmittedAt = &event.CreatedAt
+		user.KYCReviewerID = nil
+		user.KYCReviewedAt = nil
+		user.KYCReason = ""
+		return nil
+	})
+	if err != nil || s.provider == nil {
+		return user, err
+	}
+
+	if err := s.sendToProvider(user); err != nil {
+		log.Printf("⚠️ Could not send KYC of user %d to %s, leaving it for manual review: %v", user.ID, s.provider.Name(), err)
+		return user, nil
+	}
+	// The provider may have decided straight away
+	return s.store.Users().Get(user.ID)
+}
+
+// StartReview assigns a submitted user to reviewerID, taking them off the
+// queue of unclaimed submissions. Reviewer 0 is the verification provider.
+func (s *Service) StartReview(userID, reviewerID uint) (models.User, error) {
+	if reviewerID != 0 && reviewerID == userID {
+		return models.User{}, ErrOwnReview
+	}
+	return s.transition(userID, reviewerID, models.KYCUnderReview, "", func(tx repository.Store, user *models.User, event *models.KYCEvent) error {
+		user.KYCReviewerID = reviewer(reviewerID)
+		return nil
+	})
+}
+
+// Decide ends a review with one of Decisions. Only the reviewer who took
+// the review can decide it. Rejections and requests to resubmit need a
+// reason, which is passed on to the user.
+func (s *Service) Decide(userID, reviewerID uint, status, reason string) (models.User, error) {
+	if reviewerID != 0 && reviewerID == userID {
+		return models.User{}, ErrOwnReview
+	}
+	if status != models.KYCVerified && reason == "" {
+		return models.User{}, ErrReasonRequired
+	}
+	return s.transition(userID, reviewerID, status, reason, func(tx repository.Store, user *models.User, event *models.KYCEvent) error {
+		if !sameReviewer(user.KYCReviewerID, reviewerID) {
+			return ErrNotReviewer
+		}
+		user.KYCReviewerID = reviewer(reviewerID)
+		user.KYCReviewedAt = &event.CreatedAt
+		user.KYCReason = reason
+		title, body := decisionMessage(status, reason)
+		return notify.User(tx.Notifications(), user.ID, title, body)
+	})
+}
+
+// History returns the KYC events of a user, oldest first.
+func (s *Service) History(userID uint) ([]models.KYCEvent, error) {
+	return s.store.KYC().Events(userID)
+}
+
+// transition locks the user, checks that their KYC may move to status,
+// records the event and lets apply update the user before it is saved.
+func (s *Service) transition(userID, actorID uint, status, reason string, apply func(repository.Store, *models.User, *models.KYCEvent) error) (models.User, error) {
+	var user models.User
+	err := s.store.Atomic(func(tx repository.Store) error {
+		var err error
+		if user, err = tx.Users().Lock(userID); err != nil {
+			return err
+		}
+		from := user.KYCStatus
+		if !CanTransition(from, status) {
+			return &TransitionError{From: from, To: status}
+		}
+
+		event := models.KYCEvent{
+			UserID:     user.ID,
+			FromStatus: from,
+			ToStatus:   status,
+			ActorID:    actorID,
+			Reason:     reason,
+			CreatedAt:  time.Now(),
+		}
+		if err := tx.KYC().CreateEvent(&event); err != nil {
+			return err
+		}
+
+		user.KYCStatus = status
+		if err := apply(tx, &user, &event); err != nil {
+			return err
+		}
+		return tx.Users().SetKYC(&user)
+	})
+	return user, err
+}
+
+func reviewer(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// sameReviewer reports whether the review assigned to assigned is
+// reviewerID's, where nil and 0 stand for the provider.
+func sameReviewer(assigned *uint, reviewerID uint) bool {
+	if assigned == nil {
+		return reviewerID == 0
+	}
+	return *assigned == reviewerID
+}
+
+func decisionMessage(status, reason string) (title, body string) {
+	switch status {
+	case models.KYCVerified:
+		return "Your identity has been verified", "You can now deposit, transfer and withdraw money."
+	case models.KYCRejected:
+		return "We could not verify your identity", "Your KYC application was rejected: " + reason
+	default:
+		return "Please resubmit your KYC details", "We need more information to verify your identity: " + reason
+	}
+}
@@ -0,0 +1,118 @@
+package kyc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"neobank-lite/models"
+	"neobank-lite/repository"
+)
+
+func createUser(t *testing.T, store repository.Store, email, kycStatus string) uint {
+	t.Helper()
+	user := models.User{Name: email, Email: email, KYCStatus: kycStatus, Role: models.RoleCompliance}
+	if err := store.Users().Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+func TestReviewerChecks(t *testing.T) {
+	store := repository.NewMemoryStore()
+	s := NewService(store, nil, nil)
+	applicant := createUser(t, store, "applicant@example.com", models.KYCSubmitted)
+	reviewer := createUser(t, store, "reviewer@example.com", models.KYCSubmitted)
+	other := createUser(t, store, "other@example.com", models.KYCVerified)
+
+	if _, err := s.StartReview(reviewer, reviewer); !errors.Is(err, ErrOwnReview) {
+		t.Errorf("claiming own KYC: got %v, want ErrOwnReview", err)
+	}
+	if _, err := s.StartReview(applicant, reviewer); err != nil {
+		t.Fatalf("StartReview: %v", err)
+	}
+	if _, err := s.Decide(applicant, other, models.KYCVerified, ""); !errors.Is(err, ErrNotReviewer) {
+		t.Errorf("deciding another reviewer's review: got %v, want ErrNotReviewer", err)
+	}
+	if _, err := s.Decide(applicant, 0, models.KYCVerified, ""); !errors.Is(err, ErrNotReviewer) {
+		t.Errorf("provider deciding a reviewer's review: got %v, want ErrNotReviewer", err)
+	}
+	user, err := s.Decide(applicant, reviewer, models.KYCVerified, "")
+	if err != nil || user.KYCStatus != models.KYCVerified {
+		t.Fatalf("Decide by the assigned reviewer = %s, %v", user.KYCStatus, err)
+	}
+
+	// A reviewer whose own KYC someone else took cannot decide it either.
+	if _, err := s.StartReview(reviewer, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Decide(reviewer, reviewer, models.KYCVerified, ""); !errors.Is(err, ErrOwnReview) {
+		t.Errorf("deciding own KYC: got %v, want ErrOwnReview", err)
+	}
+
+	events, err := s.History(applicant)
+	if err != nil || len(events) != 2 {
+		t.Errorf("applicant has %d KYC events, %v; want 2, refused calls must not record any", len(events), err)
+	}
+}
+
+func TestProviderDecidesItsOwnReview(t *testing.T) {
+	store := repository.NewMemoryStore()
+	s := NewService(store, nil, nil)
+	applicant := createUser(t, store, "applicant@example.com", models.KYCSubmitted)
+	reviewer := createUser(t, store, "reviewer@example.com", models.KYCVerified)
+
+	if _, err := s.StartReview(applicant, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Decide(applicant, reviewer, models.KYCVerified, ""); !errors.Is(err, ErrNotReviewer) {
+		t.Errorf("reviewer deciding the provider's review: got %v, want ErrNotReviewer", err)
+	}
+	if _, err := s.Decide(applicant, 0, models.KYCRejected, "Document expired"); err != nil {
+		t.Errorf("provider decision: %v", err)
+	}
+}
+
+func TestPollingStopsWithContext(t *testing.T) {
+	store := repository.NewMemoryStore()
+	applicant := createUser(t, store, "applicant@example.com", models.KYCSubmitted)
+	check := models.KYCCheck{UserID: applicant, Provider: "mock", Reference: fmt.Sprintf("mock-approved-%d-1", applicant), Status: CheckPending}
+	if err := store.KYC().CreateCheck(&check); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := NewService(store, nil, NewMockProvider("secret")).Poll(ctx, 10*time.Millisecond)
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		checks, err := store.KYC().Checks(applicant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if check = checks[0]; check.Status != CheckPending {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the pending check was never polled")
+		}
+	}
+	if check.Status != CheckApproved {
+		t.Errorf("check is %s, want approved", check.Status)
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("polling did not stop with its context")
+	}
+
+	select {
+	case <-NewService(store, nil, nil).Poll(context.Background(), time.Millisecond):
+	default:
+		t.Error("without a provider there is nothing to wait for, but the channel is open")
+	}
+}
@@ -0,0 +1,34 @@
+package kyc
+
+import (
+	"log"
+
+	"neobank-lite/metrics"
+	"neobank-lite/models"
+	"neobank-lite/repository"
+)
+
+// statuses are always reported, so a status nobody is in shows as 0
+// instead of disappearing.
+var statuses = []string{
+	models.KYCPending, models.KYCSubmitted, models.KYCUnderReview,
+	models.KYCVerified, models.KYCRejected, models.KYCResubmissionRequired,
+}
+
+// RegisterMetrics exposes how many users are in each KYC status. The
+// numbers are counted in users on every scrape. It must be called once.
+func RegisterMetrics(users repository.UserRepository) {
+	metrics.NewGaugeFunc("neobank_kyc_users", "Users by KYC status.", []string{"status"}, func() []metrics.Sample {
+		counts, err := users.CountByKYCStatus()
+		if err != nil {
+			log.Println("❌ Failed to count users by KYC status: ", err)
+			return nil
+		}
+
+		samples := make([]metrics.Sample, 0, len(statuses))
+		for _, status := range statuses {
+			samples = append(samples, metrics.Sample{LabelValues: []string{status}, Value: float64(counts[status])})
+		}
+		return samples
+	})
+}
@@ -0,0 +1,69 @@
+package kyc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// MockProvider is a local provider for development. Its verdict depends only
+// on the name of the user:
+//
+//	contains "reject"    rejected
+//	contains "blurry"    resubmission_required
+//	contains "review"    review, left for a human
+//	anything else        approved
+//
+// Submit always answers pending; the verdict comes from Check or from a
+// webhook call with a JSON body like {"reference": "...", "status": "approved"},
+// signed like the webhook calls of HTTPProvider. References are predictable,
+// so unsigned calls are rejected. The verdict is encoded in the reference,
+// so the mock keeps no state.
+type MockProvider struct {
+	webhookSecret string
+}
+
+// NewMockProvider returns a mock provider. Webhooks are rejected unless a
+// webhook secret is set.
+func NewMockProvider(webhookSecret string) MockProvider {
+	return MockProvider{webhookSecret: webhookSecret}
+}
+
+var mockSequence atomic.Uint64
+
+func (MockProvider) Name() string { return "mock" }
+
+func (MockProvider) Submit(ctx context.Context, s Submission) (Result, error) {
+	name := strings.ToLower(s.Name)
+	status := CheckApproved
+	switch {
+	case strings.Contains(name, "reject"):
+		status = CheckRejected
+	case strings.Contains(name, "blurry"):
+		status = CheckResubmissionRequired
+	case strings.Contains(name, "review"):
+		status = CheckReview
+	}
+	reference := fmt.Sprintf("mock-%s-%d-%d", status, s.UserID, mockSequence.Add(1))
+	return Result{Reference: reference, Status: CheckPending}, nil
+}
+
+func (MockProvider) Check(ctx context.Context, reference string) (Result, error) {
+	for _, status := range []string{CheckApproved, CheckRejected, CheckResubmissionRequired, CheckReview} {
+		if strings.HasPrefix(reference, "mock-"+status+"-") {
+			return Result{Reference: reference, Status: status, Reason: mockReasons[status]}, nil
+		}
+	}
+	return Result{}, ErrUnknownCheck
+}
+
+func (p MockProvider) ParseWebhook(r *http.Request) (Result, error) {
+	return parseSignedWebhook(r, p.webhookSecret)
+}
+
+var mockReasons = map[string]string{
+	CheckRejected:             "The document did not pass verification",
+	CheckResubmissionRequired: "The document photo is too blurry to read",
+}
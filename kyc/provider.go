@@ -0,0 +1,246 @@
+package kyc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"neobank-lite/config"
+	"neobank-lite/models"
+	"neobank-lite/repository"
+)
+
+// Results a provider can come back with. CheckReview means the provider
+// could not decide and a human reviewer has to.
+const (
+	CheckPending              = models.KYCCheckPending
+	CheckApproved             = models.KYCCheckApproved
+	CheckRejected             = models.KYCCheckRejected
+	CheckResubmissionRequired = models.KYCCheckResubmissionRequired
+	CheckReview               = models.KYCCheckReview
+)
+
+var (
+	ErrUnknownCheck     = errors.New("unknown KYC check")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// Submission is what gets sent to a provider.
+type Submission struct {
+	UserID    uint
+	Name      string
+	Documents []SubmittedDocument
+}
+
+// SubmittedDocument is one decrypted document of a Submission.
+type SubmittedDocument struct {
+	Type        string
+	ContentType string
+	Data        []byte
+}
+
+// Result is a provider's verdict on a submission.
+type Result struct {
+	Reference string
+	Status    string
+	Reason    string
+}
+
+// Provider verifies identities with a third party. Results either come back
+// straight from Submit, are polled with Check, or are pushed to the webhook
+// and read with ParseWebhook.
+type Provider interface {
+	Name() string
+	Submit(ctx context.Context, s Submission) (Result, error)
+	Check(ctx context.Context, reference string) (Result, error)
+	ParseWebhook(r *http.Request) (Result, error)
+}
+
+// NewProvider returns the provider named by cfg.Provider:
+//
+//	manual  no provider, compliance staff review every submission (default)
+//	mock    deterministic local provider, see MockProvider
+//	http    HTTPProvider at cfg.ProviderURL
+//
+// For manual it returns nil.
+func NewProvider(cfg config.KYC) (Provider, error) {
+	switch cfg.Provider {
+	case "", "manual":
+		return nil, nil
+	case "mock":
+		return NewMockProvider(cfg.WebhookSecret.Reveal()), nil
+	case "http":
+		return NewHTTPProvider(cfg.ProviderURL, cfg.APIKey.Reveal(), cfg.WebhookSecret.Reveal())
+	default:
+		return nil, fmt.Errorf("unknown KYC provider %q", cfg.Provider)
+	}
+}
+
+// Provider returns the provider submissions are sent to, or nil if there
+// is none.
+func (s *Service) Provider() Provider {
+	return s.provider
+}
+
+// Poll asks the provider for results every interval until ctx is done. The
+// returned channel is closed once polling has stopped; without a provider
+// there is nothing to poll and it is closed already.
+func (s *Service) Poll(ctx context.Context, interval time.Duration) <-chan struct{} {
+	stopped := make(chan struct{})
+	if s.provider == nil {
+		close(stopped)
+		return stopped
+	}
+	go func() {
+		defer close(stopped)
+		s.poll(ctx, interval)
+	}()
+	return stopped
+}
+
+// sendToProvider submits the user's documents to the provider and records
+// the check. If the provider is unreachable the submission stays in the
+// manual review queue.
+func (s *Service) sendToProvider(user models.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	documents, err := s.Documents(user.ID, false)
+	if err != nil {
+		return err
+	}
+	submission := Submission{UserID: user.ID, Name: user.Name}
+	for _, document := range documents {
+		data, err := s.documents.Open(ctx, document.StorageKey)
+		if err != nil {
+			return fmt.Errorf("reading %s document: %w", document.Type, err)
+		}
+		submission.Documents = append(submission.Documents, SubmittedDocument{
+			Type:        document.Type,
+			ContentType: document.ContentType,
+			Data:        data,
+		})
+	}
+
+	result, err := s.provider.Submit(ctx, submission)
+	if err != nil {
+		return err
+	}
+
+	check := models.KYCCheck{
+		UserID:    user.ID,
+		Provider:  s.provider.Name(),
+		Reference: result.Reference,
+		Status:    CheckPending,
+	}
+	if err := s.store.KYC().CreateCheck(&check); err != nil {
+		return err
+	}
+	if result.Status != CheckPending {
+		return s.ApplyResult(s.provider.Name(), result)
+	}
+	return nil
+}
+
+// ApplyResult stores a provider result on its check. A final result decides
+// the user's KYC if their submission is still waiting; once a reviewer has
+// taken it the result is only kept for them to see. Results for checks that
+// already have one are ignored, so webhooks can safely be delivered twice.
+func (s *Service) ApplyResult(providerName string, result Result) error {
+	switch result.Status {
+	case CheckPending, CheckApproved, CheckRejected, CheckResubmissionRequired, CheckReview:
+	default:
+		return fmt.Errorf("unknown KYC check status %q", result.Status)
+	}
+
+	return s.store.Atomic(func(tx repository.Store) error {
+		check, err := tx.KYC().LockCheck(providerName, result.Reference)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUnknownCheck
+		} else if err != nil {
+			return err
+		}
+		if check.Status != CheckPending || result.Status == CheckPending {
+			return nil
+		}
+
+		now := time.Now()
+		check.Status, check.Reason, check.CompletedAt = result.Status, result.Reason, &now
+		if err := tx.KYC().CompleteCheck(&check); err != nil {
+			return err
+		}
+
+		var decision string
+		switch result.Status {
+		case CheckApproved:
+			decision = models.KYCVerified
+		case CheckRejected:
+			decision = models.KYCRejected
+		case CheckResubmissionRequired:
+			decision = models.KYCResubmissionRequired
+		default:
+			return nil // left for a reviewer
+		}
+
+		user, err := tx.Users().Get(check.UserID)
+		if err != nil {
+			return err
+		}
+		if user.KYCStatus != models.KYCSubmitted {
+			return nil
+		}
+		if _, err := s.in(tx).StartReview(user.ID, 0); err != nil {
+			return err
+		}
+		reason := result.Reason
+		if reason == "" && decision != models.KYCVerified {
+			reason = "Your identity could not be verified"
+		}
+		_, err = s.in(tx).Decide(user.ID, 0, decision, reason)
+		return err
+	})
+}
+
+// Checks returns the provider checks of a user, oldest first.
+func (s *Service) Checks(userID uint) ([]models.KYCCheck, error) {
+	return s.store.KYC().Checks(userID)
+}
+
+// poll asks the provider about pending checks, for providers that do not
+// call the webhook or when a call got lost. It returns when ctx is done,
+// abandoning the check in progress; the next start polls it again.
+func (s *Service) poll(ctx context.Context, interval time.Duration) {
+	p := s.provider
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pending, err := s.store.KYC().PendingChecks(p.Name())
+		if err != nil {
+			log.Printf("❌ Failed to load pending KYC checks: %v", err)
+			continue
+		}
+		for _, check := range pending {
+			checkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			result, err := p.Check(checkCtx, check.Reference)
+			cancel()
+			if err != nil && ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				err = s.ApplyResult(p.Name(), result)
+			}
+			if err != nil {
+				log.Printf("⚠️ KYC check %s with %s failed: %v", check.Reference, p.Name(), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package kyc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseWebhookRequiresSignature(t *testing.T) {
+	const (
+		secret = "webhook-secret"
+		body   = `{"reference": "mock-approved-10-1", "status": "approved"}`
+	)
+	httpProvider, err := NewHTTPProvider("https://kyc.example.com", "key", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	providers := map[string]Provider{
+		"mock":                NewMockProvider(secret),
+		"http":                httpProvider,
+		"mock without secret": NewMockProvider(""),
+	}
+	tests := []struct {
+		name      string
+		signature string
+		ok        bool
+	}{
+		{"signed", sign(secret, body), true},
+		{"unsigned", "", false},
+		{"wrong secret", sign("guess", body), false},
+		{"bare hex", strings.TrimPrefix(sign(secret, body), "sha256="), false},
+	}
+	for providerName, p := range providers {
+		for _, tt := range tests {
+			r := httptest.NewRequest("POST", "/kyc/webhook", strings.NewReader(body))
+			if tt.signature != "" {
+				r.Header.Set("X-Signature", tt.signature)
+			}
+			result, err := p.ParseWebhook(r)
+			ok := tt.ok && providerName != "mock without secret"
+			switch {
+			case ok && err != nil:
+				t.Errorf("%s, %s: %v", providerName, tt.name, err)
+			case ok && (result.Reference != "mock-approved-10-1" || result.Status != CheckApproved):
+				t.Errorf("%s, %s: got %+v", providerName, tt.name, result)
+			case !ok && !errors.Is(err, ErrInvalidSignature):
+				t.Errorf("%s, %s: got %+v, %v; want ErrInvalidSignature", providerName, tt.name, result, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"neobank-lite/models"
+	"neobank-lite/money"
+
+	"gorm.io/gorm"
+)
+
+// System ledger accounts that exist independently of any customer.
+const (
+	CashAccount           = "cash"
+	FeeIncomeAccount      = "fee_income"
+	OpeningBalanceAccount = "opening_balance_equity"
+	FXConversionAccount   = "fx_conversion"
+)
+
+const (
+	Debit  = "debit"
+	Credit = "credit"
+)
+
+var ErrUnbalanced = errors.New("ledger entries do not balance")
+
+var systemAccounts = []models.LedgerAccount{
+	{Code: CashAccount, Name: "Cash and settlement", Type: "asset"},
+	{Code: FeeIncomeAccount, Name: "Fee income", Type: "income"},
+	{Code: OpeningBalanceAccount, Name: "Opening balance equity", Type: "equity"},
+	{Code: FXConversionAccount, Name: "Currency conversion position", Type: "equity"},
+}
+
+// SystemAccounts returns the ledger accounts that Setup creates for every
+// installation.
+func SystemAccounts() []models.LedgerAccount {
+	return append([]models.LedgerAccount(nil), systemAccounts...)
+}
+
+// Posting is a single debit or credit to a ledger account.
+type Posting struct {
+	Account   string
+	Direction string
+	Amount    money.Money
+}
+
+func DebitOf(account string, amount money.Money) Posting {
+	return Posting{Account: account, Direction: Debit, Amount: amount}
+}
+
+func CreditOf(account string, amount money.Money) Posting {
+	return Posting{Account: account, Direction: Credit, Amount: amount}
+}
+
+// TransferPostings moves debited out of ledger account from and credited
+// into to. When the currencies differ the conversion goes through the FX
+// conversion account, so that each currency balances on its own.
+func TransferPostings(from, to string, debited, credited money.Money) []Posting {
+	if debited.Currency == credited.Currency {
+		return []Posting{DebitOf(from, debited), CreditOf(to, credited)}
+	}
+	return []Posting{
+		DebitOf(from, debited),
+		CreditOf(FXConversionAccount, debited),
+		DebitOf(FXConversionAccount, credited),
+		CreditOf(to, credited),
+	}
+}
+
+// CustomerAccount returns the ledger account code backing a customer account.
+func CustomerAccount(accountNumber string) string {
+	return "customer:" + accountNumber
+}
+
+// Post writes the postings for a transaction. It must be called inside the
+// same DB transaction that changes the account balances. Debits and credits
+// must balance in every currency involved.
+func Post(tx *gorm.DB, transactionID int, postings ...Posting) error {
+	entries, err := Entries(transactionID, postings...)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&entries).Error
+}
+
+// Entries checks that the postings balance and turns them into the ledger
+// entries of a transaction.
+func Entries(transactionID int, postings ...Posting) ([]models.LedgerEntry, error) {
+	net := map[string]int64{}
+	for _, p := range postings {
+		if !p.Amount.IsPositive() {
+			return nil, fmt.Errorf("invalid posting amount %s for %s", p.Amount, p.Account)
+		}
+		switch p.Direction {
+		case Debit:
+			net[p.Amount.Currency] += p.Amount.Minor
+		case Credit:
+			net[p.Amount.Currency] -= p.Amount.Minor
+		default:
+			return nil, fmt.Errorf("invalid posting direction %q", p.Direction)
+		}
+	}
+	if len(postings) < 2 {
+		return nil, ErrUnbalanced
+	}
+	for _, n := range net {
+		if n != 0 {
+			return nil, ErrUnbalanced
+		}
+	}
+
+	now := time.Now()
+	entries := make([]models.LedgerEntry, 0, len(postings))
+	for _, p := range postings {
+		entries = append(entries, models.LedgerEntry{
+			TransactionID: transactionID,
+			LedgerAccount: p.Account,
+			Direction:     p.Direction,
+			Amount:        p.Amount,
+			CreatedAt:     now,
+		})
+	}
+	return entries, nil
+}
+
+// OpenCustomerAccount creates the liability ledger account for a customer account.
+func OpenCustomerAccount(tx *gorm.DB, accountNumber string) error {
+	account := CustomerLedgerAccount(accountNumber)
+	return tx.Create(&account).Error
+}
+
+// CustomerLedgerAccount returns the liability ledger account backing a
+// customer account.
+func CustomerLedgerAccount(accountNumber string) models.LedgerAccount {
+	number := accountNumber
+	return models.LedgerAccount{
+		Code:          CustomerAccount(accountNumber),
+		Name:          "Customer " + accountNumber,
+		Type:          "liability",
+		AccountNumber: &number,
+	}
+}
+
+// Setup creates the system ledger accounts and opens ledger accounts for any
+// customer account that predates the ledger, posting its current balance
+// against opening balance equity so the books start out balanced.
+func Setup(db *gorm.DB) error {
+	for _, acc := range systemAccounts {
+		if err := db.Where(models.LedgerAccount{Code: acc.Code}).FirstOrCreate(&acc).Error; err != nil {
+			return err
+		}
+	}
+
+	var accounts []models.Account
+	err := db.Where("account_number NOT IN (?)",
+		db.Model(&models.LedgerAccount{}).Select("account_number").Where("account_number IS NOT NULL")).
+		Find(&accounts).Error
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := OpenCustomerAccount(tx, account.AccountNumber); err != nil {
+				return err
+			}
+			if !account.Balance.IsPositive() {
+				return nil
+			}
+			transaction := models.Transaction{
+				FromAccount: account.AccountNumber,
+				ToAccount:   account.AccountNumber,
+				Amount:      account.Balance,
+				Type:        "opening_balance",
+				Timestamp:   time.Now(),
+				Status:      models.TransactionSuccess,
+			}
+			if err := tx.Create(&transaction).Error; err != nil {
+				return err
+			}
+			return Post(tx, transaction.ID,
+				DebitOf(OpeningBalanceAccount, account.Balance),
+				CreditOf(CustomerAccount(account.AccountNumber), account.Balance),
+			)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+
+	"neobank-lite/models"
+	"neobank-lite/money"
+)
+
+func TestEntries(t *testing.T) {
+	etb := func(minor int64) money.Money { return money.New(minor, "ETB") }
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+
+	tests := []struct {
+		name       string
+		postings   []Posting
+		unbalanced bool // fails with ErrUnbalanced
+		invalid    bool // fails with another error
+	}{
+		{"deposit", []Posting{DebitOf(CashAccount, etb(1000)), CreditOf("customer:a", etb(1000))}, false, false},
+		{"split", []Posting{DebitOf("customer:a", etb(1000)), CreditOf("customer:b", etb(990)), CreditOf(FeeIncomeAccount, etb(10))}, false, false},
+		{"transfer", TransferPostings("customer:a", "customer:b", etb(500), etb(500)), false, false},
+		{"converted transfer", TransferPostings("customer:a", "customer:b", usd(1000), etb(57250)), false, false},
+		{"debit larger", []Posting{DebitOf(CashAccount, etb(1001)), CreditOf("customer:a", etb(1000))}, true, false},
+		{"one posting", []Posting{DebitOf(CashAccount, etb(1000))}, true, false},
+		{"no postings", nil, true, false},
+		{"same sum, other currencies", []Posting{DebitOf("customer:a", usd(1000)), CreditOf("customer:b", etb(1000))}, true, false},
+		{"zero amount", []Posting{DebitOf(CashAccount, etb(0)), CreditOf("customer:a", etb(0))}, false, true},
+		{"negative amounts", []Posting{DebitOf(CashAccount, etb(-5)), CreditOf("customer:a", etb(-5))}, false, true},
+		{"unknown direction", []Posting{{Account: CashAccount, Direction: "sideways", Amount: etb(5)}, CreditOf("customer:a", etb(5))}, false, true},
+	}
+	for _, tt := range tests {
+		entries, err := Entries(42, tt.postings...)
+		switch {
+		case tt.unbalanced:
+			if !errors.Is(err, ErrUnbalanced) {
+				t.Errorf("%s: got %v, want ErrUnbalanced", tt.name, err)
+			}
+			continue
+		case tt.invalid:
+			if err == nil || errors.Is(err, ErrUnbalanced) {
+				t.Errorf("%s: got %v, want an invalid posting error", tt.name, err)
+			}
+			continue
+		case err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+
+		if len(entries) != len(tt.postings) {
+			t.Fatalf("%s: got %d entries for %d postings", tt.name, len(entries), len(tt.postings))
+		}
+		sums := map[string]int64{}
+		for i, e := range entries {
+			p := tt.postings[i]
+			if e.TransactionID != 42 || e.LedgerAccount != p.Account || e.Direction != p.Direction || e.Amount != p.Amount {
+				t.Errorf("%s: entry %d is %+v for posting %+v", tt.name, i, e, p)
+			}
+			if e.Direction == Debit {
+				sums[e.Amount.Currency] += e.Amount.Minor
+			} else {
+				sums[e.Amount.Currency] -= e.Amount.Minor
+			}
+		}
+		for currency, sum := range sums {
+			if sum != 0 {
+				t.Errorf("%s: %s entries sum to %d", tt.name, currency, sum)
+			}
+		}
+	}
+}
+
+func TestTrialBalance(t *testing.T) {
+	var entries []models.LedgerEntry
+	for i, postings := range [][]Posting{
+		{DebitOf(CashAccount, money.New(10000, "ETB")), CreditOf(CustomerAccount("a"), money.New(10000, "ETB"))},
+		{DebitOf(CashAccount, money.New(500, "USD")), CreditOf(CustomerAccount("b"), money.New(500, "USD"))},
+		TransferPostings(CustomerAccount("b"), CustomerAccount("a"), money.New(100, "USD"), money.New(5725, "ETB")),
+	} {
+		e, err := Entries(i+1, postings...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e...)
+	}
+	accounts := append(SystemAccounts(), CustomerLedgerAccount("a"), CustomerLedgerAccount("b"))
+	totals := SumEntries(entries)
+
+	report := NewTrialBalance(accounts, totals)
+	if !report.Balanced || len(report.Totals) != 2 {
+		t.Fatalf("trial balance %+v, want two balanced currencies", report.Totals)
+	}
+	for _, total := range report.Totals {
+		if !total.Difference.IsZero() {
+			t.Errorf("%s differs by %s", total.Currency, total.Difference)
+		}
+	}
+
+	// Stored balances that agree with the entries are not reported.
+	stored := []models.Account{
+		{AccountNumber: "a", Balance: money.New(15725, "ETB")},
+		{AccountNumber: "b", Balance: money.New(400, "USD")},
+	}
+	if m := FindMismatches(stored, totals); len(m) != 0 {
+		t.Errorf("FindMismatches = %+v, want none", m)
+	}
+	stored[1].Balance = money.New(500, "USD")
+	m := FindMismatches(stored, totals)
+	if len(m) != 1 || m[0].AccountNumber != "b" || m[0].LedgerBalance != money.New(400, "USD") {
+		t.Errorf("FindMismatches = %+v, want b with a ledger balance of 4.00 USD", m)
+	}
+
+	// An entry without its counterpart unbalances the report.
+	report = NewTrialBalance(accounts, SumEntries(entries[1:]))
+	if report.Balanced {
+		t.Error("trial balance with a missing entry is balanced")
+	}
+}
@@ -0,0 +1,213 @@
+package ledger
+
+import (
+	"sort"
+
+	"neobank-lite/models"
+	"neobank-lite/money"
+
+	"gorm.io/gorm"
+)
+
+type TrialBalanceLine struct {
+	Account string      `json:"account" example:"cash"`
+	Name    string      `json:"name" example:"Cash and settlement"`
+	Type    string      `json:"type" example:"asset"`
+	Debits  money.Money `json:"debits"`
+	Credits money.Money `json:"credits"`
+	Balance money.Money `json:"balance"` // debits - credits
+}
+
+// TrialBalanceTotal sums all ledger accounts in one currency.
+type TrialBalanceTotal struct {
+	Currency   string      `json:"currency" example:"ETB"`
+	Debits     money.Money `json:"debits"`
+	Credits    money.Money `json:"credits"`
+	Difference money.Money `json:"difference"`
+}
+
+type TrialBalance struct {
+	Lines    []TrialBalanceLine  `json:"lines"`
+	Totals   []TrialBalanceTotal `json:"totals"`
+	Balanced bool                `json:"balanced" example:"true"`
+}
+
+// Mismatch is a customer account whose stored balance disagrees with the
+// balance derived from its ledger entries.
+type Mismatch struct {
+	AccountNumber string      `json:"account_number" example:"acc-123456"`
+	StoredBalance money.Money `json:"stored_balance"`
+	LedgerBalance money.Money `json:"ledger_balance"`
+}
+
+// AccountTotal is the sum of the entries of one ledger account in one
+// direction and currency.
+type AccountTotal struct {
+	LedgerAccount  string
+	Direction      string
+	AmountCurrency string
+	Total          int64
+}
+
+// SumEntries totals entries per ledger account, direction and currency, the
+// way the queries below do in SQL.
+func SumEntries(entries []models.LedgerEntry) []AccountTotal {
+	index := map[AccountTotal]int{}
+	var totals []AccountTotal
+	for _, e := range entries {
+		k := AccountTotal{LedgerAccount: e.LedgerAccount, Direction: e.Direction, AmountCurrency: e.Amount.Currency}
+		i, ok := index[k]
+		if !ok {
+			i = len(totals)
+			index[k] = i
+			totals = append(totals, k)
+		}
+		totals[i].Total += e.Amount.Minor
+	}
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].AmountCurrency < totals[j].AmountCurrency })
+	return totals
+}
+
+// GetTrialBalance sums all entries per ledger account and currency. In every
+// currency the total of all debits must equal the total of all credits.
+func GetTrialBalance(db *gorm.DB) (TrialBalance, error) {
+	var accounts []models.LedgerAccount
+	if err := db.Order("code").Find(&accounts).Error; err != nil {
+		return TrialBalance{}, err
+	}
+
+	var totals []AccountTotal
+	err := db.Model(&models.LedgerEntry{}).
+		Select("ledger_account, direction, amount_currency, SUM(amount_minor) AS total").
+		Group("ledger_account, direction, amount_currency").
+		Order("amount_currency").
+		Scan(&totals).Error
+	if err != nil {
+		return TrialBalance{}, err
+	}
+	return NewTrialBalance(accounts, totals), nil
+}
+
+// NewTrialBalance reports the totals of the ledger accounts, which must be
+// sorted by code, per currency.
+func NewTrialBalance(accounts []models.LedgerAccount, totals []AccountTotal) TrialBalance {
+	type key struct{ account, currency string }
+	debits := map[key]int64{}
+	credits := map[key]int64{}
+	var currencies []string
+	seen := map[string]bool{}
+	for _, row := range totals {
+		k := key{row.LedgerAccount, row.AmountCurrency}
+		if row.Direction == Debit {
+			debits[k] += row.Total
+		} else {
+			credits[k] += row.Total
+		}
+		if !seen[row.AmountCurrency] {
+			seen[row.AmountCurrency] = true
+			currencies = append(currencies, row.AmountCurrency)
+		}
+	}
+
+	report := TrialBalance{Lines: []TrialBalanceLine{}, Totals: []TrialBalanceTotal{}, Balanced: true}
+	for _, currency := range currencies {
+		var totalDebits, totalCredits int64
+		for _, acc := range accounts {
+			k := key{acc.Code, currency}
+			d, dok := debits[k]
+			c, cok := credits[k]
+			if !dok && !cok {
+				continue
+			}
+			totalDebits += d
+			totalCredits += c
+			report.Lines = append(report.Lines, TrialBalanceLine{
+				Account: acc.Code,
+				Name:    acc.Name,
+				Type:    acc.Type,
+				Debits:  money.New(d, currency),
+				Credits: money.New(c, currency),
+				Balance: money.New(d-c, currency),
+			})
+		}
+		report.Totals = append(report.Totals, TrialBalanceTotal{
+			Currency:   currency,
+			Debits:     money.New(totalDebits, currency),
+			Credits:    money.New(totalCredits, currency),
+			Difference: money.New(totalDebits-totalCredits, currency),
+		})
+		if totalDebits != totalCredits {
+			report.Balanced = false
+		}
+	}
+	return report
+}
+
+// CustomerBalance derives a customer account's balance from its entries.
+func CustomerBalance(db *gorm.DB, accountNumber string, currency string) (money.Money, error) {
+	var totals []AccountTotal
+	err := db.Model(&models.LedgerEntry{}).
+		Select("ledger_account, direction, amount_currency, SUM(amount_minor) AS total").
+		Where("ledger_account = ? AND amount_currency = ?", CustomerAccount(accountNumber), currency).
+		Group("ledger_account, direction, amount_currency").
+		Scan(&totals).Error
+	if err != nil {
+		return money.Money{}, err
+	}
+	return customerBalance(totals, accountNumber, currency), nil
+}
+
+// customerBalance picks a customer account's balance out of totals. Customer
+// accounts are liabilities, so credits increase the balance.
+func customerBalance(totals []AccountTotal, accountNumber string, currency string) money.Money {
+	code := CustomerAccount(accountNumber)
+	var balance int64
+	for _, row := range totals {
+		if row.LedgerAccount != code || row.AmountCurrency != currency {
+			continue
+		}
+		if row.Direction == Credit {
+			balance += row.Total
+		} else {
+			balance -= row.Total
+		}
+	}
+	return money.New(balance, currency)
+}
+
+// Reconcile compares every customer account's stored balance with the
+// balance derived from the ledger and returns the ones that differ.
+func Reconcile(db *gorm.DB) ([]Mismatch, error) {
+	var accounts []models.Account
+	if err := db.Order("account_number").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	var totals []AccountTotal
+	err := db.Model(&models.LedgerEntry{}).
+		Select("ledger_account, direction, amount_currency, SUM(amount_minor) AS total").
+		Where("ledger_account LIKE ?", CustomerAccount("%")).
+		Group("ledger_account, direction, amount_currency").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return FindMismatches(accounts, totals), nil
+}
+
+// FindMismatches returns the accounts whose stored balance differs from the
+// balance derived from totals.
+func FindMismatches(accounts []models.Account, totals []AccountTotal) []Mismatch {
+	mismatches := []Mismatch{}
+	for _, account := range accounts {
+		derived := customerBalance(totals, account.AccountNumber, account.Balance.Currency)
+		if derived != account.Balance {
+			mismatches = append(mismatches, Mismatch{
+				AccountNumber: account.AccountNumber,
+				StoredBalance: account.Balance,
+				LedgerBalance: derived,
+			})
+		}
+	}
+	return mismatches
+}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"neobank-lite/config"
+	"neobank-lite/controllers"
 	"neobank-lite/database"
+	"neobank-lite/kyc"
+	"neobank-lite/repository"
 	"neobank-lite/routes"
+	"neobank-lite/storage"
 
 	_ "neobank-lite/docs" // 👈 Required for Swagger docs
 
@@ -32,14 +42,91 @@ import (
 // @name Authorization
 
 func main() {
-	config.LoadEnvVariables()
-	database.Connect()
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	} else if err != nil {
+		log.Fatal("❌ Invalid configuration:\n", err)
+	}
 
-	router := routes.SetupRouter()
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := cfg.Database.Validate(); err != nil {
+			log.Fatal("❌ Invalid configuration:\n", err)
+		}
+		migrate(cfg.Database, args[1:])
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("❌ Invalid configuration:\n", err)
+	}
+
+	fmt.Printf("⚙️  Configuration:\n%s", cfg)
+
+	database.Connect(cfg)
+	store := repository.NewGormStore(database.DB)
+
+	documents, err := storage.New(cfg.Documents)
+	if err != nil {
+		log.Fatal("❌ Document storage setup failed: ", err)
+	}
+	provider, err := kyc.NewProvider(cfg.KYC)
+	if err != nil {
+		log.Fatal("❌ KYC provider setup failed: ", err)
+	}
+	workflow := kyc.NewService(store, documents, provider)
+	if n, err := workflow.EncryptLegacyDocuments(context.Background()); err != nil {
+		log.Fatal("❌ Encrypting legacy documents failed: ", err)
+	} else if n > 0 {
+		fmt.Printf("🔒 Encrypted %d legacy documents\n", n)
+	}
+	// SIGINT or SIGTERM starts a graceful shutdown; a second one kills the
+	// process right away.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	polled := workflow.Poll(ctx, cfg.KYC.PollInterval)
+	if err := database.RegisterMetrics(database.DB); err != nil {
+		log.Fatal("❌ Metrics setup failed: ", err)
+	}
+	kyc.RegisterMetrics(store.Users())
+
+	transactions := controllers.NewTransactionHandler(store, cfg.Transactions)
+	drained := transactions.Start(ctx)
+
+	router := routes.SetupRouter(cfg, database.DB, store, documents, workflow, transactions)
 
 	// Serve Swagger docs at /swagger/index.html
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
-	fmt.Println("🚀 Server running on http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", router))
+	server := &http.Server{Addr: cfg.Server.Addr, Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("❌ Server failed: ", err)
+		}
+	}()
+	fmt.Printf("🚀 Server running on %s\n", cfg.Server.Addr)
+
+	<-ctx.Done()
+	stop()
+	fmt.Println("🛑 Shutting down, waiting for requests and queued transactions...")
+
+	// New transactions are refused from here on. In-flight requests still
+	// get their answer because the workers drain the queue meanwhile.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("❌ HTTP shutdown did not finish: ", err)
+	}
+	select {
+	case <-drained:
+		fmt.Println("✅ Transaction queue drained")
+	case <-shutdownCtx.Done():
+		log.Println("❌ Gave up waiting for queued transactions")
+	}
+	select {
+	case <-polled:
+	case <-shutdownCtx.Done():
+		log.Println("❌ Gave up waiting for the KYC poller")
+	}
+	fmt.Println("👋 Server stopped")
 }
@@ -0,0 +1,273 @@
+// Package metrics keeps counters, gauges and histograms and serves them in
+// the Prometheus text exposition format, so the service can be scraped
+// without pulling in the Prometheus client library.
+//
+// Metrics are registered by name when they are created. Creating a second
+// metric with the same name panics, like a mismatched number of label values
+// does: both are programming errors, so metrics are best created once, in
+// package variables. Label values are passed in the order the label names
+// were declared.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets suit request latencies in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type metric interface {
+	describe() desc
+	write(w io.Writer)
+}
+
+var (
+	mu       sync.Mutex
+	registry = map[string]metric{}
+)
+
+// register adds m to the registry. It panics if the name is taken.
+func register(m metric) {
+	name := m.describe().name
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("metrics: %s is already registered", name))
+	}
+	registry[name] = m
+}
+
+// Handler serves every registered metric, sorted by name.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		all := make([]metric, 0, len(registry))
+		for _, m := range registry {
+			all = append(all, m)
+		}
+		mu.Unlock()
+		sort.Slice(all, func(i, j int) bool { return all[i].describe().name < all[j].describe().name })
+
+		w.Header().Set("Content-Type", contentType)
+		for _, m := range all {
+			d := m.describe()
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.kind)
+			m.write(w)
+		}
+	})
+}
+
+type desc struct {
+	name   string
+	help   string
+	kind   string // counter, gauge or histogram
+	labels []string
+}
+
+func (d desc) describe() desc { return d }
+
+// labelPairs renders {a="x",b="y"}, with extra appended as a final pair if
+// set. It panics if the number of values does not match the labels, which
+// is a programming error.
+func (d desc) labelPairs(values []string, extra ...string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	if len(values) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(values)+1)
+	for i, v := range values {
+		pairs = append(pairs, d.labels[i]+`="`+escapeLabel(v)+`"`)
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, extra[0]+`="`+escapeLabel(extra[1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// seriesKey identifies a combination of label values.
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec is a counter per combination of label values.
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*sample
+}
+
+type sample struct {
+	labels []string
+	value  float64
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name, help, "counter", labels}, series: map[string]*sample{}}
+	register(c)
+	return c
+}
+
+// Inc adds one to the counter of labelValues.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the counter of labelValues.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.name))
+	}
+	c.labelPairs(labelValues) // checks the number of values
+	key := seriesKey(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &sample{labels: append([]string(nil), labelValues...)}
+		c.series[key] = s
+	}
+	s.value += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(s.labels), formatFloat(s.value))
+	}
+}
+
+// HistogramVec counts observations into buckets per combination of label
+// values.
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogram
+}
+
+type histogram struct {
+	labels []string
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec creates a histogram with the given upper bounds, which
+// must be sorted. A +Inf bucket is always added.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name, help, "histogram", labels},
+		buckets: buckets,
+		series:  map[string]*histogram{},
+	}
+	register(h)
+	return h
+}
+
+// Observe records v for labelValues.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.labelPairs(labelValues) // checks the number of values
+	key := seriesKey(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(s.labels, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(s.labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelPairs(s.labels), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelPairs(s.labels), s.count)
+	}
+}
+
+// Sample is one value read by a collect function.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// collected is a metric whose values are read from elsewhere at scrape time.
+type collected struct {
+	desc
+	collect func() []Sample
+}
+
+// NewGaugeFunc registers a gauge read by calling collect on every scrape.
+func NewGaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	register(&collected{desc{name, help, "gauge", labels}, collect})
+}
+
+// NewCounterFunc registers a counter kept elsewhere, such as the wait count
+// of a connection pool, read by calling collect on every scrape.
+func NewCounterFunc(name, help string, labels []string, collect func() []Sample) {
+	register(&collected{desc{name, help, "counter", labels}, collect})
+}
+
+func (c *collected) write(w io.Writer) {
+	for _, s := range c.collect() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(s.LabelValues), formatFloat(s.Value))
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
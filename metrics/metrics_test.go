@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// isolate gives a test an empty registry, so that it can be run again.
+func isolate(t *testing.T) {
+	mu.Lock()
+	saved := registry
+	registry = map[string]metric{}
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		registry = saved
+		mu.Unlock()
+	})
+}
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); ct != contentType {
+		t.Errorf("Content-Type %q, want %q", ct, contentType)
+	}
+	return w.Body.String()
+}
+
+func TestExposition(t *testing.T) {
+	isolate(t)
+	requests := NewCounterVec("test_requests_total", "Requests by path.\nSecond line with a \\.", "path", "code")
+	requests.Inc("/a", "200")
+	requests.Add(2.5, `/b"\`+"\n", "500")
+	latency := NewHistogramVec("test_latency_seconds", "Latency.", []float64{0.1, 1}, "path")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.1, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(3, "/a")
+	NewGaugeFunc("test_queue", "Queued jobs.", nil, func() []Sample { return []Sample{{Value: 7}} })
+	NewCounterFunc("test_waits_total", "Waits by pool.", []string{"pool"}, func() []Sample {
+		return []Sample{{LabelValues: []string{"main"}, Value: 1e21}}
+	})
+
+	want := `# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{path="/a",le="0.1"} 2
+test_latency_seconds_bucket{path="/a",le="1"} 3
+test_latency_seconds_bucket{path="/a",le="+Inf"} 4
+test_latency_seconds_sum{path="/a"} 3.65
+test_latency_seconds_count{path="/a"} 4
+# HELP test_queue Queued jobs.
+# TYPE test_queue gauge
+test_queue 7
+# HELP test_requests_total Requests by path.\nSecond line with a \\.
+# TYPE test_requests_total counter
+test_requests_total{path="/a",code="200"} 1
+test_requests_total{path="/b\"\\\n",code="500"} 2.5
+# HELP test_waits_total Waits by pool.
+# TYPE test_waits_total counter
+test_waits_total{pool="main"} 1e+21
+`
+	if got := scrape(t); got != want {
+		t.Errorf("exposition is\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegistrationMistakesPanic(t *testing.T) {
+	isolate(t)
+	mustPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s did not panic", name)
+			}
+		}()
+		f()
+	}
+
+	c := NewCounterVec("test_duplicate_total", "First.", "kind")
+	mustPanic("a second metric of the same name", func() { NewGaugeFunc("test_duplicate_total", "Second.", nil, nil) })
+	mustPanic("too few label values", func() { c.Inc() })
+	mustPanic("a negative counter increment", func() { c.Add(-1, "x") })
+
+	// The first metric is still the one served.
+	c.Inc("x")
+	if out := scrape(t); !strings.Contains(out, "# HELP test_duplicate_total First.\n") || !strings.Contains(out, `test_duplicate_total{kind="x"} 1`) {
+		t.Errorf("duplicate replaced the original:\n%s", out)
+	}
+}
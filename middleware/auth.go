@@ -4,49 +4,75 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 
+	"neobank-lite/auth"
+	"neobank-lite/problem"
+
 	"github.com/golang-jwt/jwt/v4"
 )
 
 type contextKey string
 
 const (
-	UserIDKey contextKey = "userID"
-	RoleKey   contextKey = "role" // ✅ Add role key
+	UserIDKey  contextKey = "userID"
+	RoleKey    contextKey = "role" // ✅ Add role key
+	TokenIDKey contextKey = "jti"
 )
 
-func JWTAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
+// JWTAuth accepts requests carrying a valid access token signed with secret
+// that tokens has not revoked.
+func JWTAuth(secret []byte, tokens *auth.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
 
-		if authHeader == "" {
-			http.Error(w, "Missing token", http.StatusUnauthorized)
-			return
-		}
+			if authHeader == "" {
+				problem.Error(w, r, http.StatusUnauthorized, problem.CodeTokenMissing, "Missing token")
+				return
+			}
 
-		// Expecting format: Bearer <token>
-		tokenStr := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer"))
+			// Expecting format: Bearer <token>
+			tokenStr := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer"))
 
-		claims := jwt.MapClaims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+			claims := jwt.MapClaims{}
+			token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+				return secret, nil
+			})
+
+			if err != nil || !token.Valid {
+				problem.Error(w, r, http.StatusUnauthorized, problem.CodeTokenInvalid, "Invalid token")
+				return
+			}
+
+			// Every access token carries a jti so that it can be revoked
+			jti, _ := claims["jti"].(string)
+			if jti == "" {
+				problem.Error(w, r, http.StatusUnauthorized, problem.CodeTokenInvalid, "Invalid token")
+				return
+			}
+			revoked, err := tokens.IsRevoked(jti)
+			if err != nil {
+				problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to check token")
+				return
+			}
+			if revoked {
+				problem.Error(w, r, http.StatusUnauthorized, problem.CodeTokenRevoked, "Token has been revoked")
+				return
+			}
 
-		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+			// Extract user_id and role
+			userID := claims["user_id"]
+			role := claims["role"]
 
-		// Extract user_id and role
-		userID := claims["user_id"]
-		role := claims["role"]
+			// Add them to context
+			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = context.WithValue(ctx, RoleKey, role)
+			ctx = context.WithValue(ctx, TokenIDKey, jti)
 
-		// Add both to context
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		ctx = context.WithValue(ctx, RoleKey, role)
-	})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 // Helper to get user ID from context in protected handlers
@@ -66,3 +92,9 @@ func GetUserRoleFromContext(r *http.Request) string {
 	}
 	return ""
 }
+
+// GetTokenIDFromContext returns the jti of the access token of the request
+func GetTokenIDFromContext(r *http.Request) string {
+	jti, _ := r.Context().Value(TokenIDKey).(string)
+	return jti
+}
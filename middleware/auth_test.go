@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"neobank-lite/auth"
+	"neobank-lite/config"
+	"neobank-lite/models"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+	"neobank-lite/utils"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var testAuth = config.Auth{
+	JWTSecret:       "0123456789abcdef0123456789abcdef",
+	AccessTokenTTL:  time.Minute,
+	RefreshTokenTTL: time.Hour,
+}
+
+// problemCode returns the code of a problem response.
+func problemCode(w *httptest.ResponseRecorder) string {
+	var body struct{ Code string }
+	json.NewDecoder(w.Body).Decode(&body)
+	return body.Code
+}
+
+// tokenID returns the jti of an access token signed with secret.
+func tokenID(t *testing.T, secret []byte, accessToken string) string {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(accessToken, claims, func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	jti, _ := claims["jti"].(string)
+	return jti
+}
+
+func TestJWTAuth(t *testing.T) {
+	store := repository.NewMemoryStore()
+	tokens := auth.NewService(store, testAuth)
+	user := models.User{Email: "a@example.com", Role: models.RoleUser}
+	if err := store.Users().Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte(testAuth.JWTSecret.Reveal())
+
+	active, err := tokens.Login(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loggedOut, err := tokens.Login(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.Logout(tokenID(t, secret, loggedOut.AccessToken)); err != nil {
+		t.Fatal(err)
+	}
+	forged, _ := utils.GenerateJWT([]byte("another-secret-another-secret-00"), user.ID, models.RoleAdmin, "forged", time.Minute)
+	noJTI, _ := utils.GenerateJWT(secret, user.ID, user.Role, "", time.Minute)
+	expired, _ := utils.GenerateJWT(secret, user.ID, user.Role, "expired", -time.Minute)
+
+	tests := []struct {
+		name, header string
+		status       int
+		code         string
+	}{
+		{"active", "Bearer " + active.AccessToken, http.StatusOK, ""},
+		{"missing", "", http.StatusUnauthorized, problem.CodeTokenMissing},
+		{"garbage", "Bearer nonsense", http.StatusUnauthorized, problem.CodeTokenInvalid},
+		{"wrong signature", "Bearer " + forged, http.StatusUnauthorized, problem.CodeTokenInvalid},
+		{"expired", "Bearer " + expired, http.StatusUnauthorized, problem.CodeTokenInvalid},
+		{"without jti", "Bearer " + noJTI, http.StatusUnauthorized, problem.CodeTokenInvalid},
+		{"logged out", "Bearer " + loggedOut.AccessToken, http.StatusUnauthorized, problem.CodeTokenRevoked},
+	}
+	for _, tt := range tests {
+		var userID string
+		handler := JWTAuth(secret, tokens)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID = GetUserIDFromContext(r)
+		}))
+		r := httptest.NewRequest("GET", "/api/me", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if code := problemCode(w); w.Code != tt.status || code != tt.code {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, w.Code, code, tt.status, tt.code)
+		}
+		if tt.status == http.StatusOK && userID != "1" {
+			t.Errorf("%s: handler saw user %q, want 1", tt.name, userID)
+		}
+	}
+}
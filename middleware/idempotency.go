@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"neobank-lite/models"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+)
+
+const (
+	IdempotencyHeader         = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotentBody         = 1 << 20
+	maxIdempotencyKeyLength   = 255
+
+	codeIdempotencyKeyInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	codeIdempotencyKeyReused     = "IDEMPOTENCY_KEY_REUSED"
+
+	releaseKey contextKey = "releaseIdempotencyKey"
+)
+
+// Idempotency makes a POST safe to retry. When the client sends an
+// Idempotency-Key header, the first response for that key is stored and
+// replayed for every retry from the same user until the key expires. Reusing
+// a key with a different request body is rejected with 422, and a retry that
+// arrives while the original is still running gets 409. Every response is
+// stored, errors included, unless the handler calls ReleaseIdempotencyKey.
+//
+// It must run after JWTAuth because keys are scoped per user. Keys are kept
+// in keys and expire after ttl.
+func Idempotency(keys repository.IdempotencyRepository, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				problem.Write(w, r, problem.Invalid(problem.FieldError{
+					Field:   IdempotencyHeader,
+					Code:    "too_long",
+					Message: "Idempotency-Key is too long",
+				}))
+				return
+			}
+
+			userID := GetUserIDFromContext(r)
+			if userID == "" {
+				problem.Error(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			if err != nil || len(body) > maxIdempotentBody {
+				problem.Error(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			fingerprint := requestFingerprint(r, body)
+
+			now := time.Now()
+			keys.DeleteExpired(userID, now)
+
+			record := models.IdempotencyKey{
+				UserID:      userID,
+				Key:         key,
+				Fingerprint: fingerprint,
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(ttl),
+			}
+			reserved, err := keys.Reserve(&record)
+			if err != nil {
+				problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to store idempotency key")
+				return
+			}
+
+			if !reserved {
+				existing, err := keys.Get(userID, key)
+				if errors.Is(err, repository.ErrNotFound) {
+					problem.Error(w, r, http.StatusConflict, codeIdempotencyKeyInProgress, "Request with this Idempotency-Key is in progress")
+					return
+				} else if err != nil {
+					problem.Error(w, r, http.StatusInternalServerError, problem.CodeInternalError, "Failed to load idempotency key")
+					return
+				}
+				replayIdempotent(w, r, existing, fingerprint)
+				return
+			}
+
+			// A panic leaves no response to replay, so free the key for a retry
+			defer func() {
+				if p := recover(); p != nil {
+					keys.Delete(&record)
+					panic(p)
+				}
+			}()
+
+			released := false
+			r = r.WithContext(context.WithValue(r.Context(), releaseKey, &released))
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			if released {
+				keys.Delete(&record)
+				return
+			}
+
+			record.Completed = true
+			record.StatusCode = rec.status
+			record.ContentType = rec.Header().Get("Content-Type")
+			record.ResponseBody = rec.body.Bytes()
+			if err := keys.Save(&record); err != nil {
+				log.Println("❌ Failed to save idempotent response: ", err)
+			}
+		})
+	}
+}
+
+// ReleaseIdempotencyKey tells Idempotency not to store the response to r,
+// so that a retry with the same key runs the request again. Handlers call it
+// only for failures known to have changed nothing, such as a job refused
+// during shutdown; any other response, even a 500, is replayed because the
+// request may have been committed.
+func ReleaseIdempotencyKey(r *http.Request) {
+	if released, ok := r.Context().Value(releaseKey).(*bool); ok {
+		*released = true
+	}
+}
+
+func replayIdempotent(w http.ResponseWriter, r *http.Request, existing models.IdempotencyKey, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		problem.Error(w, r, http.StatusUnprocessableEntity, codeIdempotencyKeyReused, "Idempotency-Key was already used with a different request")
+		return
+	}
+	if !existing.Completed {
+		problem.Error(w, r, http.StatusConflict, codeIdempotencyKeyInProgress, "Request with this Idempotency-Key is in progress")
+		return
+	}
+
+	if existing.ContentType != "" {
+		w.Header().Set("Content-Type", existing.ContentType)
+	}
+	w.Header().Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(existing.StatusCode)
+	w.Write(existing.ResponseBody)
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder passes the response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"neobank-lite/models"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+)
+
+// countingHandler answers with the number of times it ran.
+type countingHandler struct {
+	calls  int
+	status int
+	before func(r *http.Request)
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	if h.before != nil {
+		h.before(r)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.status)
+	w.Write([]byte(`{"call":` + strconv.Itoa(h.calls) + `}`))
+}
+
+func idempotentRequest(handler http.Handler, userID, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/api/transaction/deposit", strings.NewReader(body))
+	r.Header.Set(IdempotencyHeader, key)
+	r = r.WithContext(context.WithValue(r.Context(), UserIDKey, userID))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	tests := []struct {
+		name string
+		// second request, sent after `{"amount":"1.00"}` by user 1 with key k
+		userID, key, body string
+		status            int // of the first response
+		before            func(r *http.Request)
+		wantStatus        int
+		wantCode          string
+		wantBody          string
+		wantCalls         int
+	}{
+		{"replay", "1", "k", `{"amount":"1.00"}`, http.StatusOK, nil, http.StatusOK, "", `{"call":1}`, 1},
+		{"replay of a failure", "1", "k", `{"amount":"1.00"}`, http.StatusInternalServerError, nil, http.StatusInternalServerError, "", `{"call":1}`, 1},
+		{"different body", "1", "k", `{"amount":"2.00"}`, http.StatusOK, nil, http.StatusUnprocessableEntity, codeIdempotencyKeyReused, "", 1},
+		{"another user", "2", "k", `{"amount":"1.00"}`, http.StatusOK, nil, http.StatusOK, "", `{"call":2}`, 2},
+		{"another key", "1", "k2", `{"amount":"1.00"}`, http.StatusOK, nil, http.StatusOK, "", `{"call":2}`, 2},
+		{"released", "1", "k", `{"amount":"1.00"}`, http.StatusServiceUnavailable, ReleaseIdempotencyKey, http.StatusServiceUnavailable, "", `{"call":2}`, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &countingHandler{status: tt.status, before: tt.before}
+			handler := Idempotency(repository.NewMemoryStore().IdempotencyKeys(), time.Hour)(next)
+
+			idempotentRequest(handler, "1", "k", `{"amount":"1.00"}`)
+			w := idempotentRequest(handler, tt.userID, tt.key, tt.body)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				if code := problemCode(w); code != tt.wantCode {
+					t.Errorf("got code %q, want %q", code, tt.wantCode)
+				}
+			} else if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+			if next.calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", next.calls, tt.wantCalls)
+			}
+			replayed := w.Header().Get(idempotencyReplayedHeader) == "true"
+			if wantReplayed := tt.wantCode == "" && tt.wantCalls == 1; replayed != wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, wantReplayed)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	keys := repository.NewMemoryStore().IdempotencyKeys()
+	next := &countingHandler{status: http.StatusOK}
+	handler := Idempotency(keys, time.Hour)(next)
+
+	started, finish := make(chan struct{}), make(chan struct{})
+	next.before = func(*http.Request) {
+		close(started)
+		<-finish
+	}
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentRequest(handler, "1", "k", `{}`) }()
+	<-started
+
+	w := idempotentRequest(handler, "1", "k", `{}`)
+	if code := problemCode(w); w.Code != http.StatusConflict || code != codeIdempotencyKeyInProgress {
+		t.Errorf("retry while running: got %d %s", w.Code, code)
+	}
+	close(finish)
+	if w := <-done; w.Code != http.StatusOK {
+		t.Errorf("original request: got %d", w.Code)
+	}
+	if w := idempotentRequest(handler, "1", "k", `{}`); w.Header().Get(idempotencyReplayedHeader) != "true" {
+		t.Errorf("retry after it finished was not replayed: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestIdempotencyExpiry(t *testing.T) {
+	keys := repository.NewMemoryStore().IdempotencyKeys()
+	next := &countingHandler{status: http.StatusOK}
+	handler := Idempotency(keys, time.Hour)(next)
+
+	past := time.Now().Add(-time.Minute)
+	expired := models.IdempotencyKey{UserID: "1", Key: "k", Fingerprint: "stale", Completed: true,
+		StatusCode: http.StatusOK, CreatedAt: past.Add(-time.Hour), ExpiresAt: past}
+	if _, err := keys.Reserve(&expired); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := idempotentRequest(handler, "1", "k", `{}`); w.Code != http.StatusOK || next.calls != 1 {
+		t.Errorf("request with an expired key: got %d after %d calls", w.Code, next.calls)
+	}
+	if record, err := keys.Get("1", "k"); err != nil || record.ExpiresAt.Before(time.Now()) {
+		t.Errorf("key was not stored again: %+v, %v", record, err)
+	}
+}
+
+// A handler that panics leaves nothing to replay, so the key is freed.
+func TestIdempotencyPanic(t *testing.T) {
+	keys := repository.NewMemoryStore().IdempotencyKeys()
+	next := &countingHandler{status: http.StatusOK, before: func(*http.Request) { panic("boom") }}
+	handler := Idempotency(keys, time.Hour)(next)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("the panic was swallowed")
+			}
+		}()
+		idempotentRequest(handler, "1", "k", `{}`)
+	}()
+	if _, err := keys.Get("1", "k"); err != repository.ErrNotFound {
+		t.Errorf("key after a panic: got %v, want ErrNotFound", err)
+	}
+
+	next.before = nil
+	if w := idempotentRequest(handler, "1", "k", `{}`); w.Code != http.StatusOK || next.calls != 2 {
+		t.Errorf("retry after a panic: got %d after %d calls", w.Code, next.calls)
+	}
+}
+
+func TestIdempotencyRequiresUser(t *testing.T) {
+	handler := Idempotency(repository.NewMemoryStore().IdempotencyKeys(), time.Hour)(&countingHandler{status: http.StatusOK})
+	w := idempotentRequest(handler, "", "k", `{}`)
+	if code := problemCode(w); w.Code != http.StatusUnauthorized || code != problem.CodeUnauthorized {
+		t.Errorf("got %d %s", w.Code, code)
+	}
+}
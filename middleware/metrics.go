@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"neobank-lite/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// unmatchedRoute labels requests that matched no route, so that scans of
+// random paths do not create a series each.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = metrics.NewCounterVec("http_requests_total",
+		"HTTP requests by method, route template and status code.", "method", "route", "status")
+	httpDuration = metrics.NewHistogramVec("http_request_duration_seconds",
+		"Time taken to serve HTTP requests by method and route template.", metrics.DefaultBuckets, "method", "route")
+)
+
+// Metrics counts and times requests per route template, such as
+// /api/account/{number}/balance, rather than per path.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		route := unmatchedRoute
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		httpRequests.Inc(r.Method, route, strconv.Itoa(sw.status))
+		httpDuration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
+
+// statusWriter remembers the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+
+	"neobank-lite/models"
+	"neobank-lite/problem"
+)
+
+// Permission is a single thing a staff role may do.
+type Permission string
+
+const (
+	PermViewUsers           Permission = "users:read"
+	PermManageRoles         Permission = "users:roles"
+	PermViewAccounts        Permission = "accounts:read"
+	PermFreezeAccounts      Permission = "accounts:freeze"
+	PermReviewKYC           Permission = "kyc:review"
+	PermReverseTransactions Permission = "transactions:reverse"
+	PermViewLedger          Permission = "ledger:read"
+	PermManageFX            Permission = "fx:write"
+)
+
+// RolePermissions lists what each role may do. Admins may do everything;
+// plain users have no staff permissions.
+var RolePermissions = map[string][]Permission{
+	models.RoleSupport:    {PermViewUsers, PermViewAccounts, PermFreezeAccounts},
+	models.RoleCompliance: {PermViewUsers, PermViewAccounts, PermFreezeAccounts, PermReviewKYC},
+	models.RoleAuditor:    {PermViewUsers, PermViewAccounts, PermViewLedger},
+}
+
+// HasPermission reports whether role grants p.
+func HasPermission(role string, p Permission) bool {
+	if role == models.RoleAdmin {
+		return true
+	}
+	for _, granted := range RolePermissions[role] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole only lets requests through whose token carries one of roles.
+// It must run after JWTAuth.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := GetUserRoleFromContext(r)
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			problem.Error(w, r, http.StatusForbidden, problem.CodeForbidden, "Forbidden")
+		})
+	}
+}
+
+// RequirePermission only lets requests through whose role grants p. It must
+// run after JWTAuth.
+func RequirePermission(p Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasPermission(GetUserRoleFromContext(r), p) {
+				problem.Error(w, r, http.StatusForbidden, problem.CodeForbidden, "Forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
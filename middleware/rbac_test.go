@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"neobank-lite/models"
+	"neobank-lite/problem"
+)
+
+func TestHasPermission(t *testing.T) {
+	all := []Permission{PermViewUsers, PermManageRoles, PermViewAccounts, PermFreezeAccounts,
+		PermReviewKYC, PermReverseTransactions, PermViewLedger, PermManageFX}
+	tests := []struct {
+		role    string
+		granted []Permission
+	}{
+		{models.RoleAdmin, all},
+		{models.RoleSupport, []Permission{PermViewUsers, PermViewAccounts, PermFreezeAccounts}},
+		{models.RoleCompliance, []Permission{PermViewUsers, PermViewAccounts, PermFreezeAccounts, PermReviewKYC}},
+		{models.RoleAuditor, []Permission{PermViewUsers, PermViewAccounts, PermViewLedger}},
+		{models.RoleUser, nil},
+		{"", nil},
+		{"superuser", nil},
+	}
+	for _, tt := range tests {
+		granted := map[Permission]bool{}
+		for _, p := range tt.granted {
+			granted[p] = true
+		}
+		for _, p := range all {
+			if got := HasPermission(tt.role, p); got != granted[p] {
+				t.Errorf("HasPermission(%q, %s) = %v, want %v", tt.role, p, got, granted[p])
+			}
+		}
+	}
+}
+
+func asRole(role string) *http.Request {
+	r := httptest.NewRequest("GET", "/admin", nil)
+	return r.WithContext(context.WithValue(r.Context(), RoleKey, role))
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission Permission
+		status     int
+	}{
+		{models.RoleAuditor, PermFreezeAccounts, http.StatusForbidden},
+		{models.RoleAuditor, PermViewLedger, http.StatusOK},
+		{models.RoleSupport, PermReviewKYC, http.StatusForbidden},
+		{models.RoleSupport, PermFreezeAccounts, http.StatusOK},
+		{models.RoleCompliance, PermReviewKYC, http.StatusOK},
+		{models.RoleCompliance, PermManageRoles, http.StatusForbidden},
+		{models.RoleUser, PermViewUsers, http.StatusForbidden},
+		{models.RoleAdmin, PermManageFX, http.StatusOK},
+	}
+	for _, tt := range tests {
+		ok := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+		w := httptest.NewRecorder()
+		RequirePermission(tt.permission)(ok).ServeHTTP(w, asRole(tt.role))
+		if w.Code != tt.status {
+			t.Errorf("%s with %s: got %d, want %d", tt.role, tt.permission, w.Code, tt.status)
+		}
+		if tt.status == http.StatusForbidden && problemCode(w) != problem.CodeForbidden {
+			t.Errorf("%s with %s: got code %s", tt.role, tt.permission, problemCode(w))
+		}
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	staff := RequireRole(models.RoleAdmin, models.RoleSupport)
+	for role, want := range map[string]int{
+		models.RoleAdmin:   http.StatusOK,
+		models.RoleSupport: http.StatusOK,
+		models.RoleAuditor: http.StatusForbidden,
+		models.RoleUser:    http.StatusForbidden,
+		"":                 http.StatusForbidden,
+	} {
+		w := httptest.NewRecorder()
+		staff(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(w, asRole(role))
+		if w.Code != want {
+			t.Errorf("role %q: got %d, want %d", role, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"neobank-lite/problem"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RequestIDKey       contextKey = "requestID"
+	maxRequestIDLength            = 128
+)
+
+// RequestID gives every request an ID, taken from the X-Request-ID header
+// when the client or a proxy sent a usable one. The ID is echoed in the
+// response header and in every error body.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(problem.RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength || !printable(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(problem.RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), RequestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetRequestIDFromContext returns the ID assigned by RequestID
+func GetRequestIDFromContext(r *http.Request) string {
+	id, _ := r.Context().Value(RequestIDKey).(string)
+	return id
+}
+
+func printable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"neobank-lite/config"
+	"neobank-lite/database"
+	"neobank-lite/database/migrations"
+)
+
+const migrateUsage = "usage: neobank-lite [flags] migrate up | down [steps] | status"
+
+// migrate runs the migrate subcommand:
+//
+//	migrate up            apply all pending migrations
+//	migrate down [steps]  revert the last steps migrations (default 1)
+//	migrate status        list migrations and whether they are applied
+func migrate(cfg config.Database, args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatal("❌ Failed to connect to DB: ", err)
+	}
+	migrator, err := database.NewMigrator(db, migrations.Files)
+	if err != nil {
+		log.Fatal("❌ Failed to read migrations: ", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("✅ Applied migration %s\n", m)
+		}
+		if err != nil {
+			log.Fatal("❌ Migration failed: ", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("✅ Database is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatal(migrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		for _, m := range reverted {
+			fmt.Printf("✅ Reverted migration %s\n", m)
+		}
+		if err != nil {
+			log.Fatal("❌ Migration failed: ", err)
+		}
+
+	case "status":
+		states, err := migrator.Status()
+		if err != nil {
+			log.Fatal("❌ Failed to read migration status: ", err)
+		}
+		for _, s := range states {
+			switch {
+			case s.Name == "":
+				fmt.Printf("%04d  applied %s, unknown to this binary\n", s.Version, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			case s.Changed:
+				fmt.Printf("%s  applied %s, CHANGED since\n", s.Migration, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			case s.AppliedAt != nil:
+				fmt.Printf("%s  applied %s\n", s.Migration, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			default:
+				fmt.Printf("%s  pending\n", s.Migration)
+			}
+		}
+
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
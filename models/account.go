@@ -1,14 +1,37 @@
 package models
 
+import (
+	"time"
+
+	"neobank-lite/money"
+)
+
+// Account types. Anything but a savings account is treated as checking.
+const (
+	AccountChecking = "checking"
+	AccountSavings  = "savings"
+)
+
+// Account statuses. No money moves into or out of a frozen account.
+const (
+	AccountActive = "active"
+	AccountFrozen = "frozen"
+)
+
 type Account struct {
 	ID        uint    `json:"id" example:"1"`
 	CreatedAt string  `json:"created_at" example:"2025-07-03T10:30:00Z"`
 	UpdatedAt string  `json:"updated_at" example:"2025-07-03T10:30:00Z"`
 	DeletedAt *string `json:"deleted_at,omitempty" example:"2025-07-03T10:30:00Z"`
 
-	AccountNumber string  `json:"account_number" gorm:"primaryKey" example:"acc-123456"`
-	UserID        int     `json:"user_id" example:"10"`
-	Balance       float64 `json:"balance" example:"1500.50"`
-	AccountType   string  `json:"account_type" example:"savings"`
-	PhoneNumber   int     `json:"phone_number" example:"911234567"`
+	AccountNumber string      `json:"account_number" gorm:"primaryKey" example:"acc-123456"`
+	UserID        int         `json:"user_id" example:"10"`
+	Balance       money.Money `json:"balance" gorm:"embedded;embeddedPrefix:balance_"`
+	AccountType   string      `json:"account_type" example:"savings"`
+	PhoneNumber   string      `json:"phone_number" example:"+251911234567"` // E.164
+	IsDefault     bool        `json:"is_default" gorm:"not null;default:false" example:"true"`
+
+	Status       string     `json:"status" gorm:"size:16;not null;default:'active'" example:"active"` // active, frozen
+	FrozenReason string     `json:"frozen_reason,omitempty" example:"Reported stolen phone"`
+	FrozenAt     *time.Time `json:"frozen_at,omitempty"`
 }
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// FXRate is the rate at which Base is converted into Quote: one unit of
+// Base buys Rate units of Quote. Each direction is configured separately so
+// buy and sell rates can differ.
+type FXRate struct {
+	Base      string    `json:"base" gorm:"primaryKey;size:3" example:"USD"`
+	Quote     string    `json:"quote" gorm:"primaryKey;size:3" example:"ETB"`
+	Rate      string    `json:"rate" gorm:"size:32;not null" example:"57.25"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers the outcome of a request sent with an
+// Idempotency-Key header so a retry can be answered without re-executing it.
+type IdempotencyKey struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       string `gorm:"uniqueIndex:idx_idempotency_user_key;not null"`
+	Key          string `gorm:"uniqueIndex:idx_idempotency_user_key;size:255;not null"`
+	Fingerprint  string `gorm:"size:64;not null"` // sha256 of method, path and body
+	Completed    bool   `gorm:"not null;default:false"`
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time `gorm:"index"`
+}
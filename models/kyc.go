@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// KYCEvent records one change of a user's KYC status, who made it and why.
+// ActorID is the user themselves for submissions and the reviewer otherwise;
+// 0 stands for the identity verification provider.
+type KYCEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey" example:"1"`
+	UserID     uint      `json:"user_id" gorm:"index;not null" example:"10"`
+	FromStatus string    `json:"from_status" gorm:"size:32;not null" example:"under_review"`
+	ToStatus   string    `json:"to_status" gorm:"size:32;not null" example:"rejected"`
+	ActorID    uint      `json:"actor_id" example:"3"`
+	Reason     string    `json:"reason,omitempty" example:"National ID photo is unreadable"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// KYC check statuses. KYCCheckReview means the provider could not decide
+// and a human reviewer has to.
+const (
+	KYCCheckPending              = "pending"
+	KYCCheckApproved             = "approved"
+	KYCCheckRejected             = "rejected"
+	KYCCheckResubmissionRequired = "resubmission_required"
+	KYCCheckReview               = "review"
+)
+
+// KYCCheck is one submission of a user's details to an identity
+// verification provider and the result it came back with.
+type KYCCheck struct {
+	ID          uint       `json:"id" gorm:"primaryKey" example:"1"`
+	UserID      uint       `json:"user_id" gorm:"index;not null" example:"10"`
+	Provider    string     `json:"provider" gorm:"size:32;not null;uniqueIndex:idx_kyc_check_reference" example:"mock"`
+	Reference   string     `json:"reference" gorm:"size:128;not null;uniqueIndex:idx_kyc_check_reference" example:"mock-approved-10-1751538600"`
+	Status      string     `json:"status" gorm:"size:32;not null;index" example:"approved"` // pending, approved, rejected, resubmission_required, review
+	Reason      string     `json:"reason,omitempty" example:"Document expired"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// KYCDocument is an identity document a user uploaded for KYC. Uploading a
+// document of a type the user already has replaces the old one, which is
+// kept with ReplacedAt set. SubmissionID is the KYCEvent of the latest
+// submission the document was part of.
+type KYCDocument struct {
+	ID           uint       `json:"id" gorm:"primaryKey" example:"1"`
+	UserID       uint       `json:"user_id" gorm:"index;not null" example:"10"`
+	Type         string     `json:"type" gorm:"size:32;not null" example:"passport"` // national_id_front, national_id_back, passport, selfie, proof_of_address
+	StorageKey   string     `json:"-" gorm:"not null"`
+	ContentType  string     `json:"content_type" example:"image/jpeg"`
+	SubmissionID *uint      `json:"submission_id,omitempty" example:"4"`
+	ReplacedAt   *time.Time `json:"replaced_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"neobank-lite/money"
+)
+
+// LedgerAccount is a book in the general ledger. Customer accounts, the cash
+// settlement account and fee income each get one so that every movement of
+// money can be posted as balanced debit/credit entries.
+type LedgerAccount struct {
+	Code          string    `json:"code" gorm:"primaryKey" example:"customer:acc-123456"`
+	Name          string    `json:"name" example:"Customer acc-123456"`
+	Type          string    `json:"type" example:"liability"` // asset, liability, equity, income, expense
+	AccountNumber *string   `json:"account_number,omitempty" gorm:"uniqueIndex" example:"acc-123456"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// LedgerEntry is one leg of a posting. The entries of a transaction always
+// sum to zero (total debits == total credits).
+type LedgerEntry struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	TransactionID int         `json:"transaction_id" gorm:"index"`
+	LedgerAccount string      `json:"ledger_account" gorm:"index"`
+	Direction     string      `json:"direction"` // debit, credit
+	Amount        money.Money `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
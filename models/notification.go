@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Notification is a message for a user, shown in their in-app inbox.
+type Notification struct {
+	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
+	UserID    uint      `json:"user_id" gorm:"index;not null" example:"10"`
+	Title     string    `json:"title" example:"Your identity has been verified"`
+	Body      string    `json:"body" example:"You can now deposit, transfer and withdraw money."`
+	CreatedAt time.Time `json:"created_at"`
+}
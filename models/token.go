@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// RefreshToken is a long-lived token that can be exchanged once for a new
+// access/refresh pair. Tokens descending from the same login share a
+// FamilyID, so a stolen token can be cut off together with all its
+// successors. Only a hash of the token is stored.
+type RefreshToken struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"index;not null"`
+	FamilyID  string `gorm:"index;size:36;not null"`
+	TokenHash string `gorm:"uniqueIndex;size:64;not null"` // sha256 of the token
+
+	// AccessJTI is the jti of the access token issued together with this
+	// refresh token, revoked along with the family.
+	AccessJTI string `gorm:"index;size:36"`
+
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// RevokedToken is an access token that was revoked before it expired. Rows
+// are only needed until ExpiresAt, after which the token is rejected anyway.
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:36"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time
+}
@@ -1,13 +1,72 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"neobank-lite/money"
+
+	"gorm.io/gorm"
+)
+
+// Transaction statuses. Every attempted money movement is stored as pending
+// first and then moves to success or failed; a reversal marks it reversed.
+// Success is the completed state of every type: a withdrawal that debited
+// the account is success, there is no separate completed status.
+const (
+	TransactionPending  = "pending"
+	TransactionSuccess  = "success"
+	TransactionFailed   = "failed"
+	TransactionReversed = "reversed"
+)
+
+// Reason codes explaining why a transaction failed. They are stored on the
+// transaction and returned to API clients unchanged.
+const (
+	ReasonInsufficientFunds = "INSUFFICIENT_FUNDS"
+	ReasonReceiverNotFound  = "RECEIVER_NOT_FOUND"
+	ReasonAccountNotFound   = "ACCOUNT_NOT_FOUND"
+	ReasonAccountFrozen     = "ACCOUNT_FROZEN"
+	ReasonSameAccount       = "SAME_ACCOUNT"
+	ReasonCurrencyMismatch  = "CURRENCY_MISMATCH"
+	ReasonLimitExceeded     = "LIMIT_EXCEEDED"
+	ReasonNotReversible     = "NOT_REVERSIBLE"
+	ReasonReversalTooLarge  = "REVERSAL_EXCEEDS_AMOUNT"
+	ReasonFXRateUnavailable = "FX_RATE_UNAVAILABLE"
+	ReasonInternalError     = "INTERNAL_ERROR"
+)
 
 type Transaction struct {
-	ID          int       `json:"id"`
-	FromAccount string    `json:"from_account"`
-	ToAccount   string    `json:"to_account"`
-	Amount      float64   `json:"amount"`
-	Timestamp   time.Time `json:"timestamp"`
-	Type        string    `json:"type"`   // deposit, withdraw, transfer
-	Status      string    `json:"status"` // success, failed
+	ID          int         `json:"id"`
+	FromAccount string      `json:"from_account"`
+	ToAccount   string      `json:"to_account"`
+	Amount      money.Money `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Timestamp   time.Time   `json:"timestamp"`
+	Type        string      `json:"type"`                                           // deposit, withdraw, transfer, reversal
+	Status      string      `json:"status" enums:"pending,success,failed,reversed"` // success means completed
+
+	// ReasonCode explains why a failed transaction failed.
+	ReasonCode string `json:"reason_code,omitempty" gorm:"size:32" example:"INSUFFICIENT_FUNDS"`
+
+	// ReversalOf links a reversal to the transaction it compensates.
+	ReversalOf  *int   `json:"reversal_of,omitempty" gorm:"index" example:"42"`
+	Description string `json:"description,omitempty" example:"Refund of mistaken transfer"`
+
+	// Transfers between accounts of different currencies debit Amount from
+	// FromAccount and credit ToAmount to ToAccount, converted at FXRate.
+	// Reversing such a transfer reuses its FXRate in the other direction.
+	ToAmount *money.Money `json:"to_amount,omitempty" gorm:"embedded;embeddedPrefix:to_amount_"`
+	FXRate   string       `json:"fx_rate,omitempty" gorm:"size:32" example:"57.25"`
+}
+
+// AfterFind and AfterCreate clear ToAmount on transactions without a
+// conversion, whose to_amount columns only hold their defaults.
+func (t *Transaction) AfterFind(tx *gorm.DB) error {
+	if t.FXRate == "" {
+		t.ToAmount = nil
+	}
+	return nil
+}
+
+func (t *Transaction) AfterCreate(tx *gorm.DB) error {
+	return t.AfterFind(tx)
 }
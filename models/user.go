@@ -1,6 +1,32 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Roles. Customers are plain users; the other roles are staff and get the
+// permissions listed in middleware.RolePermissions.
+const (
+	RoleUser       = "user"
+	RoleAdmin      = "admin"
+	RoleSupport    = "support"
+	RoleCompliance = "compliance"
+	RoleAuditor    = "auditor"
+)
+
+// KYC statuses. A user submits their details, a compliance reviewer picks
+// them up from the queue and decides; only verified users can move money.
+// See package kyc for the allowed transitions.
+const (
+	KYCPending              = "pending"
+	KYCSubmitted            = "submitted"
+	KYCUnderReview          = "under_review"
+	KYCVerified             = "verified"
+	KYCRejected             = "rejected"
+	KYCResubmissionRequired = "resubmission_required"
+)
 
 type User struct {
 	gorm.Model
@@ -10,4 +36,9 @@ type User struct {
 	KYCStatus  string `json:"kyc_status" gorm:"default:'pending'"`
 	NationalID string `json:"national_id"`
 	Role       string `json:"role" gorm:"default:'user'"`
+
+	KYCReason      string     `json:"kyc_reason,omitempty"` // why KYC was rejected or must be resubmitted
+	KYCSubmittedAt *time.Time `json:"kyc_submitted_at,omitempty"`
+	KYCReviewerID  *uint      `json:"kyc_reviewer_id,omitempty"`
+	KYCReviewedAt  *time.Time `json:"kyc_reviewed_at,omitempty"`
 }
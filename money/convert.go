@@ -0,0 +1,86 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var ErrInvalidRate = errors.New("invalid exchange rate")
+
+// maxRateDigits limits the fraction digits of an exchange rate.
+const maxRateDigits = 10
+
+// ParseRate validates a decimal exchange rate such as "57.25" and returns it
+// in canonical form, without redundant zeros.
+func ParseRate(s string) (string, error) {
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && frac == "") || !digitsOnly(whole) || !digitsOnly(frac) {
+		return "", fmt.Errorf("%w %q", ErrInvalidRate, s)
+	}
+	if len(strings.TrimLeft(whole, "0")) > maxIntegerDigits || len(frac) > maxRateDigits {
+		return "", fmt.Errorf("%w %q: too many digits", ErrInvalidRate, s)
+	}
+
+	whole = strings.TrimLeft(whole, "0")
+	if whole == "" {
+		whole = "0"
+	}
+	frac = strings.TrimRight(frac, "0")
+	if strings.Trim(whole+frac, "0") == "" {
+		return "", fmt.Errorf("%w %q: must be positive", ErrInvalidRate, s)
+	}
+	if frac == "" {
+		return whole, nil
+	}
+	return whole + "." + frac, nil
+}
+
+// Convert exchanges m into currency at rate, the number of units of currency
+// per unit of m's currency. The result is rounded half up to the minor unit
+// of currency.
+func (m Money) Convert(rate string, currency string) (Money, error) {
+	canonical, err := ParseRate(rate)
+	if err != nil {
+		return Money{}, err
+	}
+	fromExp, err := Exponent(m.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	toExp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	r, _ := new(big.Rat).SetString(canonical)
+	v := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Minor), r)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toExp-fromExp))), nil))
+	if toExp >= fromExp {
+		v.Mul(v, scale)
+	} else {
+		v.Quo(v, scale)
+	}
+
+	// Round half away from zero: (2*num + den) / (2*den), truncated
+	num := new(big.Int).Mul(v.Num(), big.NewInt(2))
+	den := new(big.Int).Mul(v.Denom(), big.NewInt(2))
+	if v.Sign() >= 0 {
+		num.Add(num, v.Denom())
+	} else {
+		num.Sub(num, v.Denom())
+	}
+	minor := new(big.Int).Quo(num, den)
+	if !minor.IsInt64() {
+		return Money{}, fmt.Errorf("%w: converted amount is too large", ErrInvalidAmount)
+	}
+	return Money{Minor: minor.Int64(), Currency: currency}, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
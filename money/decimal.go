@@ -0,0 +1,36 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Decimal is an amount exactly as the client wrote it. It accepts either a
+// JSON number (12.5) or a JSON string ("12.50") and keeps the digits as text,
+// so nothing is lost to float64 before the currency is known.
+type Decimal string
+
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*d = Decimal(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("%w: must be a number or decimal string", ErrInvalidAmount)
+	}
+	*d = Decimal(n)
+	return nil
+}
+
+// Money converts the decimal into minor units of the given currency.
+func (d Decimal) Money(currency string) (Money, error) {
+	return Parse(string(d), currency)
+}
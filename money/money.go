@@ -0,0 +1,202 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used for accounts that don't specify one.
+const DefaultCurrency = "ETB"
+
+// exponents holds the number of minor-unit digits per ISO 4217 currency.
+var exponents = map[string]int{
+	"ETB": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CHF": 2,
+	"CAD": 2,
+	"AUD": 2,
+	"CNY": 2,
+	"INR": 2,
+	"AED": 2,
+	"SAR": 2,
+	"KES": 2,
+	"UGX": 0,
+	"TZS": 2,
+	"NGN": 2,
+	"ZAR": 2,
+	"EGP": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+}
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrTooPrecise       = errors.New("amount has too many decimal places")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// maxIntegerDigits keeps parsed amounts well inside int64 minor units.
+const maxIntegerDigits = 15
+
+// Money is an exact amount held as an integer number of minor units
+// (e.g. cents) of its currency.
+type Money struct {
+	Minor    int64  `json:"amount" gorm:"column:minor;not null;default:0" swaggertype:"string" example:"1500.50"`
+	Currency string `json:"currency" gorm:"column:currency;size:3;not null;default:'ETB'" example:"ETB"`
+}
+
+// Exponent returns the number of minor-unit digits for a currency code.
+func Exponent(currency string) (int, error) {
+	exp, ok := exponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+	return exp, nil
+}
+
+func New(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// Parse converts a decimal string such as "1500.50" into minor units of the
+// currency. It rejects signs, exponents and more fraction digits than the
+// currency allows, so "0.001" is an error for a 2-decimal currency.
+func Parse(s string, currency string) (Money, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && frac == "") || !digitsOnly(whole) || !digitsOnly(frac) {
+		return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	if len(strings.TrimLeft(whole, "0")) > maxIntegerDigits {
+		return Money{}, fmt.Errorf("%w %q: too large", ErrInvalidAmount, s)
+	}
+
+	trimmed := strings.TrimRight(frac, "0")
+	if len(trimmed) > exp {
+		return Money{}, fmt.Errorf("%w: %s allows at most %d", ErrTooPrecise, currency, exp)
+	}
+
+	minor, err := strconv.ParseInt(whole+trimmed+strings.Repeat("0", exp-len(trimmed)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// FromFloat converts a legacy float amount, rounding to the nearest minor unit.
+// It exists only for migrating data stored before amounts were exact.
+func FromFloat(f float64, currency string) (Money, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	return Parse(strconv.FormatFloat(f, 'f', exp, 64), currency)
+}
+
+func (m Money) IsZero() bool     { return m.Minor == 0 }
+func (m Money) IsPositive() bool { return m.Minor > 0 }
+func (m Money) IsNegative() bool { return m.Minor < 0 }
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Minor: m.Minor + o.Minor, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Minor: m.Minor - o.Minor, Currency: m.Currency}, nil
+}
+
+// Cmp returns -1, 0 or 1. Both amounts must share a currency.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.Minor < o.Minor:
+		return -1, nil
+	case m.Minor > o.Minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Decimal renders the amount in major units, e.g. "1500.50".
+func (m Money) Decimal() string {
+	exp, ok := exponents[m.Currency]
+	if !ok {
+		return strconv.FormatInt(m.Minor, 10)
+	}
+
+	sign := ""
+	minor := m.Minor
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	digits := strconv.FormatInt(minor, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Amount   Decimal `json:"amount"`
+		Currency string  `json:"currency"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	parsed, err := v.Amount.Money(v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func digitsOnly(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in, currency string
+		minor        int64
+		err          error
+	}{
+		{"1500.50", "ETB", 150050, nil},
+		{"1500.5", "ETB", 150050, nil},
+		{"1500", "ETB", 150000, nil},
+		{"0.01", "USD", 1, nil},
+		{"0.10", "USD", 10, nil},
+		{"1.000", "USD", 100, nil}, // trailing zeros carry no precision
+		{"007.25", "USD", 725, nil},
+		{"1500", "JPY", 1500, nil},
+		{"1.0", "JPY", 1, nil},
+		{"1.234", "BHD", 1234, nil},
+		{"999999999999999.99", "USD", 99999999999999999, nil},
+
+		{"0.001", "USD", 0, ErrTooPrecise},
+		{"1.5", "JPY", 0, ErrTooPrecise},
+		{"1.2345", "BHD", 0, ErrTooPrecise},
+		{"-5", "ETB", 0, ErrInvalidAmount},
+		{"+5", "ETB", 0, ErrInvalidAmount},
+		{"1e3", "ETB", 0, ErrInvalidAmount},
+		{"1,000", "ETB", 0, ErrInvalidAmount},
+		{".5", "ETB", 0, ErrInvalidAmount},
+		{"5.", "ETB", 0, ErrInvalidAmount},
+		{"", "ETB", 0, ErrInvalidAmount},
+		{" 5", "ETB", 0, ErrInvalidAmount},
+		{"1000000000000000", "USD", 0, ErrInvalidAmount},
+		{"5", "XYZ", 0, ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, tt.currency)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse(%q, %s): got %v, want %v", tt.in, tt.currency, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != New(tt.minor, tt.currency) {
+			t.Errorf("Parse(%q, %s) = %v, %v; want %d minor units", tt.in, tt.currency, got, err, tt.minor)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{New(150050, "ETB"), "1500.50"},
+		{New(5, "ETB"), "0.05"},
+		{New(0, "USD"), "0.00"},
+		{New(-250, "USD"), "-2.50"},
+		{New(1500, "JPY"), "1500"},
+		{New(1, "BHD"), "0.001"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Decimal(); got != tt.want {
+			t.Errorf("%d %s: Decimal = %q, want %q", tt.m.Minor, tt.m.Currency, got, tt.want)
+		}
+		if tt.m.IsNegative() {
+			continue
+		}
+		if back, err := Parse(tt.m.Decimal(), tt.m.Currency); err != nil || back != tt.m {
+			t.Errorf("%s does not parse back: %v, %v", tt.m, back, err)
+		}
+	}
+	if got := New(150050, "ETB").String(); got != "1500.50 ETB" {
+		t.Errorf("String = %q", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var m Money
+	if err := json.Unmarshal([]byte(`{"amount":"12.50","currency":"USD"}`), &m); err != nil || m != New(1250, "USD") {
+		t.Errorf("string amount: got %v, %v", m, err)
+	}
+	if err := json.Unmarshal([]byte(`{"amount":12.5,"currency":"USD"}`), &m); err != nil || m != New(1250, "USD") {
+		t.Errorf("number amount: got %v, %v", m, err)
+	}
+	for _, body := range []string{
+		`{"amount":0.001,"currency":"USD"}`,
+		`{"amount":1e2,"currency":"USD"}`,
+		`{"amount":true,"currency":"USD"}`,
+		`{"amount":"1.5","currency":"JPY"}`,
+	} {
+		if err := json.Unmarshal([]byte(body), &m); err == nil {
+			t.Errorf("%s: accepted as %v", body, m)
+		}
+	}
+
+	out, err := json.Marshal(New(150050, "ETB"))
+	if err != nil || string(out) != `{"amount":"1500.50","currency":"ETB"}` {
+		t.Errorf("Marshal = %s, %v", out, err)
+	}
+
+	// A float64 would lose the last digits of this amount.
+	var d Decimal
+	if err := json.Unmarshal([]byte(`90071992547409.93`), &d); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := d.Money("USD"); err != nil || m.Minor != 9007199254740993 {
+		t.Errorf("large amount = %v, %v", m, err)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a, b := New(1000, "ETB"), New(250, "ETB")
+	if sum, err := a.Add(b); err != nil || sum != New(1250, "ETB") {
+		t.Errorf("Add = %v, %v", sum, err)
+	}
+	if diff, err := b.Sub(a); err != nil || diff != New(-750, "ETB") {
+		t.Errorf("Sub = %v, %v", diff, err)
+	}
+	if cmp, err := a.Cmp(b); err != nil || cmp != 1 {
+		t.Errorf("Cmp = %d, %v", cmp, err)
+	}
+
+	usd := New(1000, "USD")
+	if _, err := a.Add(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add across currencies: got %v", err)
+	}
+	if _, err := a.Sub(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub across currencies: got %v", err)
+	}
+	if _, err := a.Cmp(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp across currencies: got %v", err)
+	}
+
+	if m, err := FromFloat(0.1+0.2, "ETB"); err != nil || m != New(30, "ETB") {
+		t.Errorf("FromFloat(0.1+0.2) = %v, %v", m, err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		from     Money
+		rate     string
+		currency string
+		want     int64
+	}{
+		{New(1000, "USD"), "57.25", "ETB", 57250},
+		{New(1, "USD"), "57.25", "ETB", 57},        // 57.25 cents rounds down
+		{New(1, "USD"), "0.005", "ETB", 0},         // 0.005 cents rounds down
+		{New(1, "ETB"), "0.5", "USD", 1},           // half a cent rounds up
+		{New(100, "USD"), "150.5", "JPY", 151},     // 150.5 yen rounds up
+		{New(151, "JPY"), "0.0066445", "USD", 100}, // 1.0033 cents
+		{New(1000, "USD"), "0.377", "BHD", 3770},   // more minor digits
+		{New(-100, "USD"), "150.5", "JPY", -151},   // rounds away from zero
+		{New(1000, "USD"), "1.0000000001", "EUR", 1000},
+	}
+	for _, tt := range tests {
+		got, err := tt.from.Convert(tt.rate, tt.currency)
+		if err != nil || got != New(tt.want, tt.currency) {
+			t.Errorf("%s at %s: got %v, %v; want %d %s", tt.from, tt.rate, got, err, tt.want, tt.currency)
+		}
+	}
+
+	for _, rate := range []string{"0", "0.000", "-1", "1e3", "", "1.12345678901"} {
+		if _, err := New(100, "USD").Convert(rate, "ETB"); !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("rate %q: got %v, want ErrInvalidRate", rate, err)
+		}
+	}
+	if _, err := New(100, "USD").Convert("2", "XYZ"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("unknown currency: got %v", err)
+	}
+	if _, err := New(9000000000000000000, "JPY").Convert("1000", "USD"); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("overflow: got %v", err)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for in, want := range map[string]string{
+		"57.25":     "57.25",
+		"057.2500":  "57.25",
+		"1.000":     "1",
+		"0.0066445": "0.0066445",
+	} {
+		if got, err := ParseRate(in); err != nil || got != want {
+			t.Errorf("ParseRate(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
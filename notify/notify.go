@@ -0,0 +1,21 @@
+// Package notify delivers messages to users. Messages land in the user's
+// in-app inbox; delivering them by email or SMS as well would hook in here.
+package notify
+
+import (
+	"log"
+
+	"neobank-lite/models"
+	"neobank-lite/repository"
+)
+
+// User stores a notification for userID. Pass the repository of the unit of
+// work that made the change being announced so the message is only sent if
+// it commits.
+func User(notifications repository.NotificationRepository, userID uint, title, body string) error {
+	err := notifications.Create(&models.Notification{UserID: userID, Title: title, Body: body})
+	if err == nil {
+		log.Printf("📨 Notified user %d: %s", userID, title)
+	}
+	return err
+}
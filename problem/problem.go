@@ -0,0 +1,103 @@
+// Package problem writes API errors as RFC 7807 problem details
+// (application/problem+json). Every error carries a stable Code that
+// clients can branch on; Message is for humans and may change.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const (
+	ContentType = "application/problem+json"
+
+	// RequestIDHeader carries the ID of a request. The RequestID middleware
+	// sets it on the response before any handler runs, and Write copies it
+	// into the problem so that errors can be matched with the server logs.
+	RequestIDHeader = "X-Request-ID"
+
+	typeBase = "https://neobank-lite.com/problems/"
+)
+
+// Codes shared by all endpoints. Endpoints add their own, more specific ones.
+const (
+	CodeInvalidRequest   = "INVALID_REQUEST"
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeRequestTooLarge  = "REQUEST_TOO_LARGE"
+	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeTokenMissing     = "TOKEN_MISSING"
+	CodeTokenInvalid     = "TOKEN_INVALID"
+	CodeTokenRevoked     = "TOKEN_REVOKED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeNotFound         = "NOT_FOUND"
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	CodeInternalError    = "INTERNAL_ERROR"
+	CodeUnavailable      = "SERVICE_UNAVAILABLE"
+)
+
+// Problem is the body of every error response.
+type Problem struct {
+	Type          string       `json:"type" example:"https://neobank-lite.com/problems/insufficient-funds"`
+	Title         string       `json:"title" example:"Unprocessable Entity"`
+	Status        int          `json:"status" example:"422"`
+	Code          string       `json:"code" example:"INSUFFICIENT_FUNDS"`
+	Message       string       `json:"message" example:"insufficient funds"`
+	Instance      string       `json:"instance,omitempty" example:"/api/transaction/withdraw"`
+	RequestID     string       `json:"request_id,omitempty" example:"0b6c7e0a-3f4d-4c1e-9a51-2f7d8e6b1c3a"`
+	TransactionID int          `json:"transaction_id,omitempty" example:"42"` // the failed transaction, if one was recorded
+	Errors        []FieldError `json:"errors,omitempty"`                      // set for VALIDATION_FAILED
+}
+
+// FieldError is a problem with a single field of the request.
+type FieldError struct {
+	Field   string `json:"field" example:"amount"`
+	Code    string `json:"code" example:"required"`
+	Message string `json:"message" example:"amount is required"`
+}
+
+func (p *Problem) Error() string {
+	return p.Message
+}
+
+// New returns a problem with the given status and code.
+func New(status int, code, message string) *Problem {
+	return &Problem{Status: status, Code: code, Message: message}
+}
+
+// Invalid returns a VALIDATION_FAILED problem listing what is wrong with
+// each field.
+func Invalid(errs ...FieldError) *Problem {
+	message := "request is invalid"
+	if len(errs) == 1 {
+		message = errs[0].Message
+	}
+	return &Problem{Status: http.StatusBadRequest, Code: CodeValidationFailed, Message: message, Errors: errs}
+}
+
+// Write sends p as the response, filling in the fields derived from the
+// status, code and request.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Code == "" {
+		p.Code = CodeInternalError
+	}
+	p.Type = typeBase + strings.ReplaceAll(strings.ToLower(p.Code), "_", "-")
+	p.Title = http.StatusText(p.Status)
+	if r != nil {
+		p.Instance = r.URL.Path
+	}
+	p.RequestID = w.Header().Get(RequestIDHeader)
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Error replies with a problem, like http.Error does with plain text.
+func Error(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	Write(w, r, New(status, code, message))
+}
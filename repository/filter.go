@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"time"
+
+	"neobank-lite/models"
+	"neobank-lite/money"
+
+	"gorm.io/gorm"
+)
+
+// TransactionFilter narrows the history of an account. Zero fields match
+// everything.
+//
+// Deposits and withdrawals name the same account on both sides, so for
+// Direction their type decides: a deposit is money in, a withdrawal money out.
+type TransactionFilter struct {
+	From         *time.Time
+	To           *time.Time // exclusive
+	Type         string
+	Status       string
+	Direction    string // in, out
+	MinAmount    *money.Money
+	MaxAmount    *money.Money
+	Counterparty string
+}
+
+// apply narrows a query on transactions of accountNumber to the filter.
+func (f TransactionFilter) apply(db *gorm.DB, accountNumber string) *gorm.DB {
+	db = db.Where("(from_account = ? OR to_account = ?)", accountNumber, accountNumber)
+
+	if f.From != nil {
+		db = db.Where("timestamp >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("timestamp < ?", *f.To)
+	}
+	if f.Type != "" {
+		db = db.Where("type = ?", f.Type)
+	}
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	switch f.Direction {
+	case "in":
+		db = db.Where("to_account = ? AND (from_account <> ? OR type IN ?)",
+			accountNumber, accountNumber, []string{"deposit", "opening_balance"})
+	case "out":
+		db = db.Where("from_account = ? AND (to_account <> ? OR type = ?)",
+			accountNumber, accountNumber, "withdraw")
+	}
+	if f.MinAmount != nil {
+		db = db.Where("amount_minor >= ?", f.MinAmount.Minor)
+	}
+	if f.MaxAmount != nil {
+		db = db.Where("amount_minor <= ?", f.MaxAmount.Minor)
+	}
+	if f.Counterparty != "" {
+		db = db.Where("((from_account = ? AND to_account = ?) OR (to_account = ? AND from_account = ?))",
+			accountNumber, f.Counterparty, accountNumber, f.Counterparty)
+	}
+	return db
+}
+
+// matches is apply for a single transaction.
+func (f TransactionFilter) matches(t models.Transaction, accountNumber string) bool {
+	if t.FromAccount != accountNumber && t.ToAccount != accountNumber {
+		return false
+	}
+	if f.From != nil && t.Timestamp.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && !t.Timestamp.Before(*f.To) {
+		return false
+	}
+	if f.Type != "" && t.Type != f.Type {
+		return false
+	}
+	if f.Status != "" && t.Status != f.Status {
+		return false
+	}
+	switch f.Direction {
+	case "in":
+		if t.ToAccount != accountNumber ||
+			(t.FromAccount == accountNumber && t.Type != "deposit" && t.Type != "opening_balance") {
+			return false
+		}
+	case "out":
+		if t.FromAccount != accountNumber || (t.ToAccount == accountNumber && t.Type != "withdraw") {
+			return false
+		}
+	}
+	if f.MinAmount != nil && t.Amount.Minor < f.MinAmount.Minor {
+		return false
+	}
+	if f.MaxAmount != nil && t.Amount.Minor > f.MaxAmount.Minor {
+		return false
+	}
+	if f.Counterparty != "" &&
+		!(t.FromAccount == accountNumber && t.ToAccount == f.Counterparty) &&
+		!(t.ToAccount == accountNumber && t.FromAccount == f.Counterparty) {
+		return false
+	}
+	return true
+}
+
+// includes reports whether t belongs on the pages after the cursor.
+func (c Cursor) includes(t models.Transaction) bool {
+	return t.Timestamp.Before(c.Timestamp) || (t.Timestamp.Equal(c.Timestamp) && t.ID < c.ID)
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"neobank-lite/models"
+	"neobank-lite/money"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// historyStores returns a gorm store on sqlite and a memory store holding the
+// same transactions of account "a".
+func historyStores(t *testing.T) (gormStore, memory Store) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.Transaction{}); err != nil {
+		t.Fatal(err)
+	}
+	gormStore, memory = NewGormStore(db), NewMemoryStore()
+
+	day := func(d, hour int) time.Time { return time.Date(2024, 3, d, hour, 0, 0, 0, time.UTC) }
+	etb := func(minor int64) money.Money { return money.New(minor, "ETB") }
+	transactions := []models.Transaction{
+		{FromAccount: "a", ToAccount: "a", Type: "opening_balance", Amount: etb(100000), Timestamp: day(1, 9)},
+		{FromAccount: "a", ToAccount: "a", Type: "deposit", Amount: etb(5000), Timestamp: day(2, 9)},
+		{FromAccount: "a", ToAccount: "a", Type: "withdraw", Amount: etb(2000), Timestamp: day(2, 9)},
+		{FromAccount: "a", ToAccount: "b", Type: "transfer", Amount: etb(30000), Timestamp: day(3, 12)},
+		{FromAccount: "b", ToAccount: "a", Type: "transfer", Amount: etb(1000), Timestamp: day(3, 12)},
+		{FromAccount: "a", ToAccount: "c", Type: "transfer", Amount: etb(999999), Timestamp: day(4, 8), Status: models.TransactionFailed},
+		{FromAccount: "c", ToAccount: "a", Type: "transfer", Amount: etb(7000), Timestamp: day(5, 0)},
+		{FromAccount: "b", ToAccount: "c", Type: "transfer", Amount: etb(500), Timestamp: day(5, 0)},
+		{FromAccount: "b", ToAccount: "a", Type: "reversal", Amount: etb(30000), Timestamp: day(6, 10)},
+	}
+	for _, tx := range transactions {
+		if tx.Status == "" {
+			tx.Status = models.TransactionSuccess
+		}
+		for _, s := range []Store{gormStore, memory} {
+			tx := tx
+			if err := s.Transactions().Create(&tx); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return gormStore, memory
+}
+
+// TestHistoryParity checks that the SQL filter and its in-memory twin select
+// the same transactions in the same order, page by page.
+func TestHistoryParity(t *testing.T) {
+	gormStore, memory := historyStores(t)
+	at := func(d, hour int) *time.Time {
+		t := time.Date(2024, 3, d, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+	amount := func(minor int64) *money.Money {
+		m := money.New(minor, "ETB")
+		return &m
+	}
+
+	filters := []TransactionFilter{
+		{},
+		{From: at(2, 9)},
+		{To: at(3, 12)},
+		{From: at(2, 9), To: at(5, 0)},
+		{Type: "transfer"},
+		{Type: "opening_balance"},
+		{Status: models.TransactionFailed},
+		{Direction: "in"},
+		{Direction: "out"},
+		{MinAmount: amount(5000)},
+		{MaxAmount: amount(5000)},
+		{MinAmount: amount(1000), MaxAmount: amount(30000)},
+		{Counterparty: "b"},
+		{Counterparty: "c", Direction: "out"},
+		{Counterparty: "a"},
+	}
+	for _, f := range filters {
+		for _, limit := range []int{2, 100} {
+			name := fmt.Sprintf("%+v, pages of %d", f, limit)
+			var after *Cursor
+			for page := 0; ; page++ {
+				want, err := memory.Transactions().History("a", f, after, limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := gormStore.Transactions().History("a", f, after, limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ids(got) != ids(want) {
+					t.Errorf("%s: page %d is %s in SQL and %s in memory", name, page, ids(got), ids(want))
+					break
+				}
+				if len(want) < limit {
+					break
+				}
+				last := want[len(want)-1]
+				after = &Cursor{Timestamp: last.Timestamp, ID: last.ID}
+			}
+		}
+	}
+
+	// Spot check the memory store, so that the two do not agree on a bug.
+	in, _ := memory.Transactions().History("a", TransactionFilter{Direction: "in"}, nil, 100)
+	out, _ := memory.Transactions().History("a", TransactionFilter{Direction: "out"}, nil, 100)
+	if ids(in) != "[9 7 5 2 1]" || ids(out) != "[6 4 3]" {
+		t.Errorf("money in %s and out %s", ids(in), ids(out))
+	}
+}
+
+func ids(transactions []models.Transaction) string {
+	ids := make([]int, len(transactions))
+	for i, t := range transactions {
+		ids[i] = t.ID
+	}
+	return fmt.Sprint(ids)
+}
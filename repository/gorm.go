@@ -0,0 +1,559 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"neobank-lite/fx"
+	"neobank-lite/ledger"
+	"neobank-lite/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewGormStore returns a Store backed by db. Atomic runs in a DB transaction
+// and Lock takes row locks with SELECT ... FOR UPDATE.
+func NewGormStore(db *gorm.DB) Store {
+	return gormStore{db}
+}
+
+type gormStore struct {
+	db *gorm.DB
+}
+
+func (s gormStore) Users() UserRepository                  { return gormUsers{s.db} }
+func (s gormStore) Accounts() AccountRepository            { return gormAccounts{s.db} }
+func (s gormStore) Transactions() TransactionRepository    { return gormTransactions{s.db} }
+func (s gormStore) Ledger() LedgerRepository               { return gormLedger{s.db} }
+func (s gormStore) Rates() RateRepository                  { return gormRates{s.db} }
+func (s gormStore) Tokens() TokenRepository                { return gormTokens{s.db} }
+func (s gormStore) IdempotencyKeys() IdempotencyRepository { return gormIdempotencyKeys{s.db} }
+func (s gormStore) KYC() KYCRepository                     { return gormKYC{s.db} }
+func (s gormStore) Notifications() NotificationRepository  { return gormNotifications{s.db} }
+
+func (s gormStore) Atomic(fn func(Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(gormStore{tx})
+	})
+}
+
+// notFound turns gorm's not found error into ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+type gormUsers struct {
+	db *gorm.DB
+}
+
+func (r gormUsers) Get(id uint) (models.User, error) {
+	var user models.User
+	err := r.db.First(&user, id).Error
+	return user, notFound(err)
+}
+
+func (r gormUsers) GetByEmail(email string) (models.User, error) {
+	var user models.User
+	err := r.db.Where("email = ?", email).First(&user).Error
+	return user, notFound(err)
+}
+
+func (r gormUsers) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r gormUsers) List(filter UserFilter, limit, offset int) ([]models.User, error) {
+	query := r.db.Model(&models.User{})
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.KYCStatus != "" {
+		query = query.Where("kyc_status = ?", filter.KYCStatus)
+	}
+	users := []models.User{}
+	err := query.Order("id desc").Limit(limit).Offset(offset).Find(&users).Error
+	return users, err
+}
+
+func (r gormUsers) SetRole(user *models.User) error {
+	return r.db.Model(user).Update("role", user.Role).Error
+}
+
+func (r gormUsers) Lock(id uint) (models.User, error) {
+	var user models.User
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, id).Error
+	return user, notFound(err)
+}
+
+func (r gormUsers) SetKYC(user *models.User) error {
+	return r.db.Model(user).Select("kyc_status", "kyc_reason", "kyc_submitted_at", "kyc_reviewer_id", "kyc_reviewed_at").
+		Updates(user).Error
+}
+
+func (r gormUsers) SetNationalID(id uint, storageKey string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("national_id", storageKey).Error
+}
+
+func (r gormUsers) KYCQueue(filter KYCQueueFilter, limit int) ([]models.User, error) {
+	query := r.db.Model(&models.User{})
+	if filter.Status == "" {
+		query = query.Where("kyc_status IN ?", []string{models.KYCSubmitted, models.KYCUnderReview})
+	} else {
+		query = query.Where("kyc_status = ?", filter.Status)
+	}
+	if filter.ReviewerID != nil {
+		query = query.Where("kyc_status = ? AND kyc_reviewer_id = ?", models.KYCUnderReview, *filter.ReviewerID)
+	}
+	users := []models.User{}
+	err := query.Order("kyc_submitted_at, id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+func (r gormUsers) CountByKYCStatus() (map[string]int64, error) {
+	var rows []struct {
+		KYCStatus string
+		Count     int64
+	}
+	err := r.db.Model(&models.User{}).
+		Select("kyc_status, count(*) AS count").Group("kyc_status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.KYCStatus] = row.Count
+	}
+	return counts, nil
+}
+
+type gormAccounts struct {
+	db *gorm.DB
+}
+
+func (r gormAccounts) Get(accountNumber string) (models.Account, error) {
+	var account models.Account
+	err := r.db.First(&account, "account_number = ?", accountNumber).Error
+	return account, notFound(err)
+}
+
+func (r gormAccounts) GetDefault(userID int) (models.Account, error) {
+	var account models.Account
+	err := r.db.Where("user_id = ? AND is_default = ?", userID, true).First(&account).Error
+	return account, notFound(err)
+}
+
+func (r gormAccounts) ListByUser(userID int) ([]models.Account, error) {
+	accounts := []models.Account{}
+	err := r.db.Where("user_id = ?", userID).Order("is_default desc, account_number").Find(&accounts).Error
+	return accounts, err
+}
+
+func (r gormAccounts) CountByUser(userID int) (int64, error) {
+	var n int64
+	err := r.db.Model(&models.Account{}).Where("user_id = ?", userID).Count(&n).Error
+	return n, err
+}
+
+func (r gormAccounts) PhoneTaken(phoneNumber string, exceptUserID int) (bool, error) {
+	var n int64
+	err := r.db.Model(&models.Account{}).
+		Where("phone_number = ? AND user_id <> ?", phoneNumber, exceptUserID).Count(&n).Error
+	return n > 0, err
+}
+
+func (r gormAccounts) Create(account *models.Account) error {
+	return r.db.Create(account).Error
+}
+
+func (r gormAccounts) Save(account *models.Account) error {
+	return r.db.Save(account).Error
+}
+
+func (r gormAccounts) SetDefault(userID int, accountNumber string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Account{}).Where("user_id = ? AND account_number <> ?", userID, accountNumber).
+			Update("is_default", false).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Account{}).Where("account_number = ?", accountNumber).
+			Update("is_default", true).Error
+	})
+}
+
+func (r gormAccounts) Lock(accountNumbers ...string) (map[string]*models.Account, error) {
+	sorted := append([]string(nil), accountNumbers...)
+	sort.Strings(sorted)
+
+	locked := make(map[string]*models.Account, len(sorted))
+	for _, number := range sorted {
+		if _, ok := locked[number]; ok {
+			continue
+		}
+		var account models.Account
+		err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&account, "account_number = ?", number).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil, &AccountNotFoundError{number}
+		} else if err != nil {
+			return nil, err
+		}
+		locked[number] = &account
+	}
+	return locked, nil
+}
+
+type gormTransactions struct {
+	db *gorm.DB
+}
+
+func (r gormTransactions) Create(transaction *models.Transaction) error {
+	return r.db.Create(transaction).Error
+}
+
+func (r gormTransactions) Get(id int) (models.Transaction, error) {
+	var transaction models.Transaction
+	err := r.db.First(&transaction, id).Error
+	return transaction, notFound(err)
+}
+
+func (r gormTransactions) Lock(id int) (models.Transaction, error) {
+	var transaction models.Transaction
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transaction, id).Error
+	return transaction, notFound(err)
+}
+
+func (r gormTransactions) SetStatus(transaction *models.Transaction) error {
+	return r.db.Model(transaction).Updates(map[string]interface{}{
+		"status":      transaction.Status,
+		"reason_code": transaction.ReasonCode,
+	}).Error
+}
+
+func (r gormTransactions) SetConversion(transaction *models.Transaction) error {
+	fields := map[string]interface{}{
+		"amount_minor":    transaction.Amount.Minor,
+		"amount_currency": transaction.Amount.Currency,
+		"fx_rate":         transaction.FXRate,
+	}
+	if transaction.ToAmount != nil {
+		fields["to_amount_minor"] = transaction.ToAmount.Minor
+		fields["to_amount_currency"] = transaction.ToAmount.Currency
+	}
+	return r.db.Model(transaction).Updates(fields).Error
+}
+
+func (r gormTransactions) Reversals(originalID int) ([]models.Transaction, error) {
+	var reversals []models.Transaction
+	err := r.db.Where("reversal_of = ? AND status = ?", originalID, models.TransactionSuccess).
+		Order("id").Find(&reversals).Error
+	return reversals, err
+}
+
+func (r gormTransactions) History(accountNumber string, filter TransactionFilter, after *Cursor, limit int) ([]models.Transaction, error) {
+	query := filter.apply(r.db.Model(&models.Transaction{}), accountNumber)
+	if after != nil {
+		query = query.Where("(timestamp < ? OR (timestamp = ? AND id < ?))", after.Timestamp, after.Timestamp, after.ID)
+	}
+	transactions := []models.Transaction{}
+	err := query.Order("timestamp desc, id desc").Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
+
+type gormLedger struct {
+	db *gorm.DB
+}
+
+func (r gormLedger) Post(transactionID int, postings ...ledger.Posting) error {
+	return ledger.Post(r.db, transactionID, postings...)
+}
+
+func (r gormLedger) OpenCustomerAccount(accountNumber string) error {
+	return ledger.OpenCustomerAccount(r.db, accountNumber)
+}
+
+func (r gormLedger) Entries(transactionID int) ([]models.LedgerEntry, error) {
+	var entries []models.LedgerEntry
+	err := r.db.Where("transaction_id = ?", transactionID).Order("id").Find(&entries).Error
+	return entries, err
+}
+
+func (r gormLedger) TrialBalance() (ledger.TrialBalance, error) {
+	return ledger.GetTrialBalance(r.db)
+}
+
+func (r gormLedger) Reconcile() ([]ledger.Mismatch, error) {
+	return ledger.Reconcile(r.db)
+}
+
+// movementRow is the sum of one direction of the entries of a transaction.
+type movementRow struct {
+	TransactionID int
+	Direction     string
+	Total         int64
+}
+
+func (m *Movement) add(row movementRow) {
+	if row.Direction == ledger.Credit {
+		m.Credits += row.Total
+	} else {
+		m.Debits += row.Total
+	}
+}
+
+func (r gormLedger) entries(ledgerAccount, currency string) *gorm.DB {
+	return r.db.Table("ledger_entries").
+		Joins("JOIN transactions ON transactions.id = ledger_entries.transaction_id").
+		Where("ledger_entries.ledger_account = ? AND ledger_entries.amount_currency = ?", ledgerAccount, currency)
+}
+
+func (r gormLedger) Turnover(ledgerAccount, currency string, before time.Time) (Movement, error) {
+	var rows []movementRow
+	err := r.entries(ledgerAccount, currency).
+		Select("0 AS transaction_id, ledger_entries.direction, SUM(ledger_entries.amount_minor) AS total").
+		Where("transactions.timestamp < ?", before).
+		Group("ledger_entries.direction").
+		Scan(&rows).Error
+	var turnover Movement
+	for _, row := range rows {
+		turnover.add(row)
+	}
+	return turnover, err
+}
+
+func (r gormLedger) Movements(ledgerAccount, currency string, from, to time.Time) ([]Movement, error) {
+	var rows []movementRow
+	err := r.entries(ledgerAccount, currency).
+		Select("ledger_entries.transaction_id, ledger_entries.direction, SUM(ledger_entries.amount_minor) AS total").
+		Where("transactions.timestamp >= ? AND transactions.timestamp < ?", from, to).
+		Group("ledger_entries.transaction_id, ledger_entries.direction").
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return []Movement{}, err
+	}
+
+	byTransaction := map[int]*Movement{}
+	var ids []int
+	for _, row := range rows {
+		m, ok := byTransaction[row.TransactionID]
+		if !ok {
+			m = &Movement{}
+			byTransaction[row.TransactionID] = m
+			ids = append(ids, row.TransactionID)
+		}
+		m.add(row)
+	}
+
+	var transactions []models.Transaction
+	if err := r.db.Where("id IN ?", ids).Order("timestamp, id").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	movements := make([]Movement, 0, len(transactions))
+	for _, t := range transactions {
+		m := *byTransaction[t.ID]
+		m.Transaction = t
+		movements = append(movements, m)
+	}
+	return movements, nil
+}
+
+type gormRates struct {
+	db *gorm.DB
+}
+
+func (r gormRates) Get(base, quote string) (models.FXRate, error) {
+	return fx.RateFor(r.db, base, quote)
+}
+
+func (r gormRates) Put(rate models.FXRate) error {
+	return fx.Put(r.db, rate)
+}
+
+func (r gormRates) List() ([]models.FXRate, error) {
+	return fx.List(r.db)
+}
+
+type gormTokens struct {
+	db *gorm.DB
+}
+
+func (r gormTokens) Create(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r gormTokens) LockByHash(tokenHash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).First(&token).Error
+	return token, notFound(err)
+}
+
+func (r gormTokens) GetByAccessJTI(jti string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Where("access_jti = ?", jti).First(&token).Error
+	return token, notFound(err)
+}
+
+func (r gormTokens) MarkUsed(token *models.RefreshToken) error {
+	return r.db.Model(token).Update("used_at", token.UsedAt).Error
+}
+
+func (r gormTokens) Family(familyID string) ([]models.RefreshToken, error) {
+	var tokens []models.RefreshToken
+	err := r.db.Where("family_id = ?", familyID).Order("id").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r gormTokens) ActiveFamilies(userID uint) ([]string, error) {
+	var families []string
+	err := r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Distinct().Pluck("family_id", &families).Error
+	return families, err
+}
+
+func (r gormTokens) RevokeFamily(familyID string, at time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", &at).Error
+}
+
+func (r gormTokens) RevokeAccess(token models.RevokedToken) error {
+	r.db.Where("expires_at < ?", token.CreatedAt).Delete(&models.RevokedToken{})
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&token).Error
+}
+
+func (r gormTokens) IsAccessRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+type gormIdempotencyKeys struct {
+	db *gorm.DB
+}
+
+func (r gormIdempotencyKeys) Reserve(key *models.IdempotencyKey) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r gormIdempotencyKeys) Get(userID, key string) (models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := r.db.Where("user_id = ? AND key = ?", userID, key).First(&record).Error
+	return record, notFound(err)
+}
+
+func (r gormIdempotencyKeys) Save(key *models.IdempotencyKey) error {
+	return r.db.Save(key).Error
+}
+
+func (r gormIdempotencyKeys) Delete(key *models.IdempotencyKey) error {
+	return r.db.Delete(key).Error
+}
+
+func (r gormIdempotencyKeys) DeleteExpired(userID string, now time.Time) error {
+	return r.db.Where("user_id = ? AND expires_at < ?", userID, now).Delete(&models.IdempotencyKey{}).Error
+}
+
+type gormKYC struct {
+	db *gorm.DB
+}
+
+func (r gormKYC) CreateEvent(event *models.KYCEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r gormKYC) Events(userID uint) ([]models.KYCEvent, error) {
+	events := []models.KYCEvent{}
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&events).Error
+	return events, err
+}
+
+func (r gormKYC) CreateCheck(check *models.KYCCheck) error {
+	return r.db.Create(check).Error
+}
+
+func (r gormKYC) LockCheck(provider, reference string) (models.KYCCheck, error) {
+	var check models.KYCCheck
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("provider = ? AND reference = ?", provider, reference).First(&check).Error
+	return check, notFound(err)
+}
+
+func (r gormKYC) CompleteCheck(check *models.KYCCheck) error {
+	return r.db.Model(check).Updates(map[string]interface{}{
+		"status":       check.Status,
+		"reason":       check.Reason,
+		"completed_at": check.CompletedAt,
+	}).Error
+}
+
+func (r gormKYC) Checks(userID uint) ([]models.KYCCheck, error) {
+	checks := []models.KYCCheck{}
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&checks).Error
+	return checks, err
+}
+
+func (r gormKYC) PendingChecks(provider string) ([]models.KYCCheck, error) {
+	var checks []models.KYCCheck
+	err := r.db.Where("provider = ? AND status = ?", provider, models.KYCCheckPending).Order("id").Find(&checks).Error
+	return checks, err
+}
+
+func (r gormKYC) CreateDocument(document *models.KYCDocument) error {
+	return r.db.Create(document).Error
+}
+
+func (r gormKYC) CurrentDocument(userID, documentID uint) (models.KYCDocument, error) {
+	var document models.KYCDocument
+	err := r.db.Where("id = ? AND user_id = ? AND replaced_at IS NULL", documentID, userID).First(&document).Error
+	return document, notFound(err)
+}
+
+func (r gormKYC) Documents(userID uint, all bool) ([]models.KYCDocument, error) {
+	documents := []models.KYCDocument{}
+	query := r.db.Where("user_id = ?", userID)
+	if !all {
+		query = query.Where("replaced_at IS NULL")
+	}
+	err := query.Order("id").Find(&documents).Error
+	return documents, err
+}
+
+func (r gormKYC) ReplaceDocuments(userID uint, docType string, at time.Time) error {
+	return r.db.Model(&models.KYCDocument{}).
+		Where("user_id = ? AND type = ? AND replaced_at IS NULL", userID, docType).
+		Update("replaced_at", at).Error
+}
+
+func (r gormKYC) LinkDocuments(userID, submissionID uint) error {
+	return r.db.Model(&models.KYCDocument{}).
+		Where("user_id = ? AND replaced_at IS NULL", userID).
+		Update("submission_id", submissionID).Error
+}
+
+func (r gormKYC) DocumentKeys() ([]string, error) {
+	var keys []string
+	err := r.db.Model(&models.KYCDocument{}).Distinct().Pluck("storage_key", &keys).Error
+	return keys, err
+}
+
+type gormNotifications struct {
+	db *gorm.DB
+}
+
+func (r gormNotifications) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r gormNotifications) Inbox(userID uint, limit int) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	err := r.db.Where("user_id = ?", userID).Order("id desc").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
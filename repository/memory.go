@@ -0,0 +1,1106 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"neobank-lite/fx"
+	"neobank-lite/ledger"
+	"neobank-lite/models"
+)
+
+// MemoryStore is a Store that keeps everything in memory, for tests. Units
+// of work run one at a time on a copy of the data that replaces it only if
+// they succeed, which gives the isolation and rollback of the database at the
+// cost of all concurrency.
+type MemoryStore struct {
+	mu   sync.Mutex
+	data *memoryData
+}
+
+// NewMemoryStore returns an empty store holding only the system ledger
+// accounts.
+func NewMemoryStore() *MemoryStore {
+	data := &memoryData{
+		users:          map[uint]models.User{},
+		accounts:       map[string]models.Account{},
+		transactions:   map[int]models.Transaction{},
+		ledgerAccounts: map[string]models.LedgerAccount{},
+		rates:          map[string]models.FXRate{},
+		refreshTokens:  map[uint]models.RefreshToken{},
+		revokedTokens:  map[string]models.RevokedToken{},
+		idempotency:    map[string]models.IdempotencyKey{},
+		kycChecks:      map[uint]models.KYCCheck{},
+		kycDocuments:   map[uint]models.KYCDocument{},
+	}
+	for _, account := range ledger.SystemAccounts() {
+		data.ledgerAccounts[account.Code] = account
+	}
+	return &MemoryStore{data: data}
+}
+
+func (s *MemoryStore) Users() UserRepository                  { return s.view().Users() }
+func (s *MemoryStore) Accounts() AccountRepository            { return s.view().Accounts() }
+func (s *MemoryStore) Transactions() TransactionRepository    { return s.view().Transactions() }
+func (s *MemoryStore) Ledger() LedgerRepository               { return s.view().Ledger() }
+func (s *MemoryStore) Rates() RateRepository                  { return s.view().Rates() }
+func (s *MemoryStore) Tokens() TokenRepository                { return s.view().Tokens() }
+func (s *MemoryStore) IdempotencyKeys() IdempotencyRepository { return s.view().IdempotencyKeys() }
+func (s *MemoryStore) KYC() KYCRepository                     { return s.view().KYC() }
+func (s *MemoryStore) Notifications() NotificationRepository  { return s.view().Notifications() }
+
+func (s *MemoryStore) Atomic(fn func(Store) error) error {
+	return s.view().Atomic(fn)
+}
+
+func (s *MemoryStore) view() memoryView {
+	return memoryView{store: s}
+}
+
+type memoryData struct {
+	users          map[uint]models.User
+	accounts       map[string]models.Account
+	transactions   map[int]models.Transaction
+	ledgerAccounts map[string]models.LedgerAccount
+	entries        []models.LedgerEntry
+	rates          map[string]models.FXRate // by base/quote
+	refreshTokens  map[uint]models.RefreshToken
+	revokedTokens  map[string]models.RevokedToken   // by jti
+	idempotency    map[string]models.IdempotencyKey // by user ID and key
+	kycEvents      []models.KYCEvent
+	kycChecks      map[uint]models.KYCCheck
+	kycDocuments   map[uint]models.KYCDocument
+	notifications  []models.Notification
+
+	lastUserID         uint
+	lastTransactionID  int
+	lastEntryID        uint
+	lastRefreshTokenID uint
+	lastIdempotencyID  uint
+	lastKYCEventID     uint
+	lastKYCCheckID     uint
+	lastKYCDocumentID  uint
+	lastNotificationID uint
+}
+
+func (d *memoryData) clone() *memoryData {
+	c := *d
+	c.users = make(map[uint]models.User, len(d.users))
+	for k, v := range d.users {
+		c.users[k] = v
+	}
+	c.accounts = make(map[string]models.Account, len(d.accounts))
+	for k, v := range d.accounts {
+		c.accounts[k] = v
+	}
+	c.transactions = make(map[int]models.Transaction, len(d.transactions))
+	for k, v := range d.transactions {
+		c.transactions[k] = v
+	}
+	c.ledgerAccounts = make(map[string]models.LedgerAccount, len(d.ledgerAccounts))
+	for k, v := range d.ledgerAccounts {
+		c.ledgerAccounts[k] = v
+	}
+	c.entries = append([]models.LedgerEntry(nil), d.entries...)
+	c.rates = make(map[string]models.FXRate, len(d.rates))
+	for k, v := range d.rates {
+		c.rates[k] = v
+	}
+	c.refreshTokens = make(map[uint]models.RefreshToken, len(d.refreshTokens))
+	for k, v := range d.refreshTokens {
+		c.refreshTokens[k] = v
+	}
+	c.revokedTokens = make(map[string]models.RevokedToken, len(d.revokedTokens))
+	for k, v := range d.revokedTokens {
+		c.revokedTokens[k] = v
+	}
+	c.idempotency = make(map[string]models.IdempotencyKey, len(d.idempotency))
+	for k, v := range d.idempotency {
+		c.idempotency[k] = v
+	}
+	c.kycEvents = append([]models.KYCEvent(nil), d.kycEvents...)
+	c.kycChecks = make(map[uint]models.KYCCheck, len(d.kycChecks))
+	for k, v := range d.kycChecks {
+		c.kycChecks[k] = v
+	}
+	c.kycDocuments = make(map[uint]models.KYCDocument, len(d.kycDocuments))
+	for k, v := range d.kycDocuments {
+		c.kycDocuments[k] = v
+	}
+	c.notifications = append([]models.Notification(nil), d.notifications...)
+	return &c
+}
+
+// memoryView is the MemoryStore as seen from outside a unit of work, where
+// every call takes the lock, or from inside one, where tx is the private copy
+// being worked on and the lock is already held.
+type memoryView struct {
+	store *MemoryStore
+	tx    *memoryData
+}
+
+func (v memoryView) Users() UserRepository                  { return memoryUsers{v} }
+func (v memoryView) Accounts() AccountRepository            { return memoryAccounts{v} }
+func (v memoryView) Transactions() TransactionRepository    { return memoryTransactions{v} }
+func (v memoryView) Ledger() LedgerRepository               { return memoryLedger{v} }
+func (v memoryView) Rates() RateRepository                  { return memoryRates{v} }
+func (v memoryView) Tokens() TokenRepository                { return memoryTokens{v} }
+func (v memoryView) IdempotencyKeys() IdempotencyRepository { return memoryIdempotencyKeys{v} }
+func (v memoryView) KYC() KYCRepository                     { return memoryKYC{v} }
+func (v memoryView) Notifications() NotificationRepository  { return memoryNotifications{v} }
+
+func (v memoryView) Atomic(fn func(Store) error) error {
+	if v.tx != nil {
+		work := v.tx.clone()
+		if err := fn(memoryView{store: v.store, tx: work}); err != nil {
+			return err
+		}
+		*v.tx = *work
+		return nil
+	}
+
+	v.store.mu.Lock()
+	defer v.store.mu.Unlock()
+	work := v.store.data.clone()
+	if err := fn(memoryView{store: v.store, tx: work}); err != nil {
+		return err
+	}
+	v.store.data = work
+	return nil
+}
+
+// do runs fn on the data the view sees.
+func (v memoryView) do(fn func(d *memoryData) error) error {
+	if v.tx != nil {
+		return fn(v.tx)
+	}
+	v.store.mu.Lock()
+	defer v.store.mu.Unlock()
+	return fn(v.store.data)
+}
+
+type memoryUsers struct {
+	v memoryView
+}
+
+func (r memoryUsers) Get(id uint) (user models.User, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		var ok bool
+		if user, ok = d.users[id]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return user, err
+}
+
+func (r memoryUsers) GetByEmail(email string) (user models.User, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		for _, u := range d.users {
+			if u.Email == email {
+				user = u
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	return user, err
+}
+
+func (r memoryUsers) Create(user *models.User) error {
+	return r.v.do(func(d *memoryData) error {
+		for _, u := range d.users {
+			if u.Email == user.Email {
+				return fmt.Errorf("email %s is already taken", user.Email)
+			}
+		}
+		d.lastUserID++
+		user.ID = d.lastUserID
+		user.CreatedAt = time.Now()
+		user.UpdatedAt = user.CreatedAt
+		if user.KYCStatus == "" {
+			user.KYCStatus = models.KYCPending
+		}
+		if user.Role == "" {
+			user.Role = models.RoleUser
+		}
+		d.users[user.ID] = *user
+		return nil
+	})
+}
+
+func (r memoryUsers) List(filter UserFilter, limit, offset int) ([]models.User, error) {
+	users := []models.User{}
+	err := r.v.do(func(d *memoryData) error {
+		for _, u := range d.users {
+			if (filter.Role == "" || u.Role == filter.Role) && (filter.KYCStatus == "" || u.KYCStatus == filter.KYCStatus) {
+				users = append(users, u)
+			}
+		}
+		return nil
+	})
+	sort.Slice(users, func(i, j int) bool { return users[i].ID > users[j].ID })
+	if offset > len(users) {
+		offset = len(users)
+	}
+	users = users[offset:]
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, err
+}
+
+func (r memoryUsers) SetRole(user *models.User) error {
+	return r.v.do(func(d *memoryData) error {
+		if u, ok := d.users[user.ID]; ok {
+			u.Role = user.Role
+			u.UpdatedAt = time.Now()
+			d.users[user.ID] = u
+		}
+		return nil
+	})
+}
+
+func (r memoryUsers) Lock(id uint) (models.User, error) {
+	return r.Get(id)
+}
+
+func (r memoryUsers) SetKYC(user *models.User) error {
+	return r.v.do(func(d *memoryData) error {
+		if u, ok := d.users[user.ID]; ok {
+			u.KYCStatus = user.KYCStatus
+			u.KYCReason = user.KYCReason
+			u.KYCSubmittedAt = user.KYCSubmittedAt
+			u.KYCReviewerID = user.KYCReviewerID
+			u.KYCReviewedAt = user.KYCReviewedAt
+			u.UpdatedAt = time.Now()
+			d.users[user.ID] = u
+		}
+		return nil
+	})
+}
+
+func (r memoryUsers) SetNationalID(id uint, storageKey string) error {
+	return r.v.do(func(d *memoryData) error {
+		if u, ok := d.users[id]; ok {
+			u.NationalID = storageKey
+			u.UpdatedAt = time.Now()
+			d.users[id] = u
+		}
+		return nil
+	})
+}
+
+func (r memoryUsers) KYCQueue(filter KYCQueueFilter, limit int) ([]models.User, error) {
+	users := []models.User{}
+	err := r.v.do(func(d *memoryData) error {
+		for _, u := range d.users {
+			if filter.matches(u) {
+				users = append(users, u)
+			}
+		}
+		return nil
+	})
+	// Like ORDER BY kyc_submitted_at, id with NULLs last
+	sort.Slice(users, func(i, j int) bool {
+		a, b := users[i].KYCSubmittedAt, users[j].KYCSubmittedAt
+		switch {
+		case a == nil && b == nil:
+		case a == nil || b == nil:
+			return b == nil
+		case !a.Equal(*b):
+			return a.Before(*b)
+		}
+		return users[i].ID < users[j].ID
+	})
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, err
+}
+
+func (f KYCQueueFilter) matches(u models.User) bool {
+	if f.Status == "" && u.KYCStatus != models.KYCSubmitted && u.KYCStatus != models.KYCUnderReview {
+		return false
+	}
+	if f.Status != "" && u.KYCStatus != f.Status {
+		return false
+	}
+	if f.ReviewerID != nil {
+		return u.KYCStatus == models.KYCUnderReview && u.KYCReviewerID != nil && *u.KYCReviewerID == *f.ReviewerID
+	}
+	return true
+}
+
+func (r memoryUsers) CountByKYCStatus() (map[string]int64, error) {
+	counts := map[string]int64{}
+	err := r.v.do(func(d *memoryData) error {
+		for _, u := range d.users {
+			counts[u.KYCStatus]++
+		}
+		return nil
+	})
+	return counts, err
+}
+
+type memoryAccounts struct {
+	v memoryView
+}
+
+func (r memoryAccounts) Get(accountNumber string) (account models.Account, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		var ok bool
+		if account, ok = d.accounts[accountNumber]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return account, err
+}
+
+func (r memoryAccounts) GetDefault(userID int) (account models.Account, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		for _, a := range d.accounts {
+			if a.UserID == userID && a.IsDefault {
+				account = a
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	return account, err
+}
+
+func (r memoryAccounts) ListByUser(userID int) ([]models.Account, error) {
+	accounts := []models.Account{}
+	err := r.v.do(func(d *memoryData) error {
+		for _, a := range d.accounts {
+			if a.UserID == userID {
+				accounts = append(accounts, a)
+			}
+		}
+		return nil
+	})
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].IsDefault != accounts[j].IsDefault {
+			return accounts[i].IsDefault
+		}
+		return accounts[i].AccountNumber < accounts[j].AccountNumber
+	})
+	return accounts, err
+}
+
+func (r memoryAccounts) CountByUser(userID int) (n int64, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		for _, a := range d.accounts {
+			if a.UserID == userID {
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (r memoryAccounts) PhoneTaken(phoneNumber string, exceptUserID int) (taken bool, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		for _, a := range d.accounts {
+			if a.PhoneNumber == phoneNumber && a.UserID != exceptUserID {
+				taken = true
+			}
+		}
+		return nil
+	})
+	return taken, err
+}
+
+func (r memoryAccounts) Create(account *models.Account) error {
+	return r.v.do(func(d *memoryData) error {
+		if _, ok := d.accounts[account.AccountNumber]; ok {
+			return fmt.Errorf("account %s already exists", account.AccountNumber)
+		}
+		if account.Status == "" {
+			account.Status = models.AccountActive
+		}
+		d.accounts[account.AccountNumber] = *account
+		return nil
+	})
+}
+
+func (r memoryAccounts) Save(account *models.Account) error {
+	return r.v.do(func(d *memoryData) error {
+		d.accounts[account.AccountNumber] = *account
+		return nil
+	})
+}
+
+func (r memoryAccounts) SetDefault(userID int, accountNumber string) error {
+	return r.v.do(func(d *memoryData) error {
+		for number, a := range d.accounts {
+			if a.UserID == userID || number == accountNumber {
+				a.IsDefault = number == accountNumber
+				d.accounts[number] = a
+			}
+		}
+		return nil
+	})
+}
+
+// Lock only loads the accounts: units of work already run one at a time.
+func (r memoryAccounts) Lock(accountNumbers ...string) (map[string]*models.Account, error) {
+	sorted := append([]string(nil), accountNumbers...)
+	sort.Strings(sorted)
+
+	locked := make(map[string]*models.Account, len(sorted))
+	err := r.v.do(func(d *memoryData) error {
+		for _, number := range sorted {
+			account, ok := d.accounts[number]
+			if !ok {
+				return &AccountNotFoundError{number}
+			}
+			locked[number] = &account
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return locked, nil
+}
+
+type memoryTransactions struct {
+	v memoryView
+}
+
+func (r memoryTransactions) Create(transaction *models.Transaction) error {
+	return r.v.do(func(d *memoryData) error {
+		d.lastTransactionID++
+		transaction.ID = d.lastTransactionID
+		if transaction.FXRate == "" {
+			transaction.ToAmount = nil
+		}
+		d.transactions[transaction.ID] = *transaction
+		return nil
+	})
+}
+
+func (r memoryTransactions) Get(id int) (transaction models.Transaction, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		var ok bool
+		if transaction, ok = d.transactions[id]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return transaction, err
+}
+
+func (r memoryTransactions) Lock(id int) (models.Transaction, error) {
+	return r.Get(id)
+}
+
+func (r memoryTransactions) SetStatus(transaction *models.Transaction) error {
+	return r.update(transaction.ID, func(t *models.Transaction) {
+		t.Status = transaction.Status
+		t.ReasonCode = transaction.ReasonCode
+	})
+}
+
+func (r memoryTransactions) SetConversion(transaction *models.Transaction) error {
+	return r.update(transaction.ID, func(t *models.Transaction) {
+		t.Amount = transaction.Amount
+		t.FXRate = transaction.FXRate
+		if transaction.ToAmount != nil {
+			converted := *transaction.ToAmount
+			t.ToAmount = &converted
+		}
+	})
+}
+
+// update changes the stored copy of a transaction. Like an UPDATE it does
+// nothing if there is no such transaction.
+func (r memoryTransactions) update(id int, fn func(t *models.Transaction)) error {
+	return r.v.do(func(d *memoryData) error {
+		if t, ok := d.transactions[id]; ok {
+			fn(&t)
+			d.transactions[id] = t
+		}
+		return nil
+	})
+}
+
+func (r memoryTransactions) Reversals(originalID int) ([]models.Transaction, error) {
+	var reversals []models.Transaction
+	err := r.v.do(func(d *memoryData) error {
+		for _, t := range d.transactions {
+			if t.ReversalOf != nil && *t.ReversalOf == originalID && t.Status == models.TransactionSuccess {
+				reversals = append(reversals, t)
+			}
+		}
+		return nil
+	})
+	sort.Slice(reversals, func(i, j int) bool { return reversals[i].ID < reversals[j].ID })
+	return reversals, err
+}
+
+func (r memoryTransactions) History(accountNumber string, filter TransactionFilter, after *Cursor, limit int) ([]models.Transaction, error) {
+	transactions := []models.Transaction{}
+	err := r.v.do(func(d *memoryData) error {
+		for _, t := range d.transactions {
+			if filter.matches(t, accountNumber) && (after == nil || after.includes(t)) {
+				transactions = append(transactions, t)
+			}
+		}
+		return nil
+	})
+	sort.Slice(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.After(b.Timestamp)
+		}
+		return a.ID > b.ID
+	})
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+	}
+	return transactions, err
+}
+
+type memoryLedger struct {
+	v memoryView
+}
+
+func (r memoryLedger) Post(transactionID int, postings ...ledger.Posting) error {
+	entries, err := ledger.Entries(transactionID, postings...)
+	if err != nil {
+		return err
+	}
+	return r.v.do(func(d *memoryData) error {
+		for _, e := range entries {
+			d.lastEntryID++
+			e.ID = d.lastEntryID
+			d.entries = append(d.entries, e)
+		}
+		return nil
+	})
+}
+
+func (r memoryLedger) OpenCustomerAccount(accountNumber string) error {
+	account := ledger.CustomerLedgerAccount(accountNumber)
+	return r.v.do(func(d *memoryData) error {
+		if _, ok := d.ledgerAccounts[account.Code]; ok {
+			return fmt.Errorf("ledger account %s already exists", account.Code)
+		}
+		account.CreatedAt = time.Now()
+		d.ledgerAccounts[account.Code] = account
+		return nil
+	})
+}
+
+func (r memoryLedger) Entries(transactionID int) ([]models.LedgerEntry, error) {
+	var entries []models.LedgerEntry
+	err := r.v.do(func(d *memoryData) error {
+		for _, e := range d.entries {
+			if e.TransactionID == transactionID {
+				entries = append(entries, e)
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func (r memoryLedger) TrialBalance() (report ledger.TrialBalance, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		accounts := make([]models.LedgerAccount, 0, len(d.ledgerAccounts))
+		for _, a := range d.ledgerAccounts {
+			accounts = append(accounts, a)
+		}
+		sort.Slice(accounts, func(i, j int) bool { return accounts[i].Code < accounts[j].Code })
+		report = ledger.NewTrialBalance(accounts, ledger.SumEntries(d.entries))
+		return nil
+	})
+	return report, err
+}
+
+func (r memoryLedger) Reconcile() (mismatches []ledger.Mismatch, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		accounts := make([]models.Account, 0, len(d.accounts))
+		for _, a := range d.accounts {
+			accounts = append(accounts, a)
+		}
+		sort.Slice(accounts, func(i, j int) bool { return accounts[i].AccountNumber < accounts[j].AccountNumber })
+		mismatches = ledger.FindMismatches(accounts, ledger.SumEntries(d.entries))
+		return nil
+	})
+	return mismatches, err
+}
+
+func (r memoryLedger) Turnover(ledgerAccount, currency string, before time.Time) (turnover Movement, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		for _, e := range d.entries {
+			t := d.transactions[e.TransactionID]
+			if e.LedgerAccount == ledgerAccount && e.Amount.Currency == currency && t.Timestamp.Before(before) {
+				turnover.add(movementRow{Direction: e.Direction, Total: e.Amount.Minor})
+			}
+		}
+		return nil
+	})
+	return turnover, err
+}
+
+func (r memoryLedger) Movements(ledgerAccount, currency string, from, to time.Time) ([]Movement, error) {
+	movements := []Movement{}
+	err := r.v.do(func(d *memoryData) error {
+		index := map[int]int{}
+		for _, e := range d.entries {
+			t := d.transactions[e.TransactionID]
+			if e.LedgerAccount != ledgerAccount || e.Amount.Currency != currency || t.Timestamp.Before(from) || !t.Timestamp.Before(to) {
+				continue
+			}
+			i, ok := index[t.ID]
+			if !ok {
+				i = len(movements)
+				index[t.ID] = i
+				movements = append(movements, Movement{Transaction: t})
+			}
+			movements[i].add(movementRow{Direction: e.Direction, Total: e.Amount.Minor})
+		}
+		return nil
+	})
+	sort.Slice(movements, func(i, j int) bool {
+		a, b := movements[i].Transaction, movements[j].Transaction
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		return a.ID < b.ID
+	})
+	return movements, err
+}
+
+type memoryRates struct {
+	v memoryView
+}
+
+func (r memoryRates) Get(base, quote string) (rate models.FXRate, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		var ok bool
+		if rate, ok = d.rates[base+"/"+quote]; !ok {
+			return fmt.Errorf("%w from %s to %s", fx.ErrNoRate, base, quote)
+		}
+		return nil
+	})
+	return rate, err
+}
+
+func (r memoryRates) Put(rate models.FXRate) error {
+	if rate.Base == "" || rate.Quote == "" {
+		return errors.New("rate needs a base and a quote currency")
+	}
+	rate.UpdatedAt = time.Now()
+	return r.v.do(func(d *memoryData) error {
+		d.rates[rate.Base+"/"+rate.Quote] = rate
+		return nil
+	})
+}
+
+func (r memoryRates) List() ([]models.FXRate, error) {
+	rates := []models.FXRate{}
+	err := r.v.do(func(d *memoryData) error {
+		for _, rate := range d.rates {
+			rates = append(rates, rate)
+		}
+		return nil
+	})
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Base != rates[j].Base {
+			return rates[i].Base < rates[j].Base
+		}
+		return rates[i].Quote < rates[j].Quote
+	})
+	return rates, err
+}
+
+type memoryTokens struct {
+	v memoryView
+}
+
+func (r memoryTokens) Create(token *models.RefreshToken) error {
+	return r.v.do(func(d *memoryData) error {
+		for _, t := range d.refreshTokens {
+			if t.TokenHash == token.TokenHash {
+				return errors.New("refresh token already exists")
+			}
+		}
+		d.lastRefreshTokenID++
+		token.ID = d.lastRefreshTokenID
+		token.CreatedAt = time.Now()
+		d.refreshTokens[token.ID] = *token
+		return nil
+	})
+}
+
+// find returns the first refresh token, in ID order, for which match is true.
+func (r memoryTokens) find(match func(t models.RefreshToken) bool) (token models.RefreshToken, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		found := false
+		for _, t := range d.refreshTokens {
+			if match(t) && (!found || t.ID < token.ID) {
+				token, found = t, true
+			}
+		}
+		if !found {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return token, err
+}
+
+func (r memoryTokens) LockByHash(tokenHash string) (models.RefreshToken, error) {
+	return r.find(func(t models.RefreshToken) bool { return t.TokenHash == tokenHash })
+}
+
+func (r memoryTokens) GetByAccessJTI(jti string) (models.RefreshToken, error) {
+	return r.find(func(t models.RefreshToken) bool { return t.AccessJTI == jti })
+}
+
+func (r memoryTokens) MarkUsed(token *models.RefreshToken) error {
+	return r.v.do(func(d *memoryData) error {
+		if t, ok := d.refreshTokens[token.ID]; ok {
+			t.UsedAt = token.UsedAt
+			d.refreshTokens[token.ID] = t
+		}
+		return nil
+	})
+}
+
+func (r memoryTokens) Family(familyID string) ([]models.RefreshToken, error) {
+	var tokens []models.RefreshToken
+	err := r.v.do(func(d *memoryData) error {
+		for _, t := range d.refreshTokens {
+			if t.FamilyID == familyID {
+				tokens = append(tokens, t)
+			}
+		}
+		return nil
+	})
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID < tokens[j].ID })
+	return tokens, err
+}
+
+func (r memoryTokens) ActiveFamilies(userID uint) ([]string, error) {
+	var families []string
+	err := r.v.do(func(d *memoryData) error {
+		seen := map[string]bool{}
+		for _, t := range d.refreshTokens {
+			if t.UserID == userID && t.RevokedAt == nil && !seen[t.FamilyID] {
+				seen[t.FamilyID] = true
+				families = append(families, t.FamilyID)
+			}
+		}
+		return nil
+	})
+	sort.Strings(families)
+	return families, err
+}
+
+func (r memoryTokens) RevokeFamily(familyID string, at time.Time) error {
+	return r.v.do(func(d *memoryData) error {
+		for id, t := range d.refreshTokens {
+			if t.FamilyID == familyID && t.RevokedAt == nil {
+				revokedAt := at
+				t.RevokedAt = &revokedAt
+				d.refreshTokens[id] = t
+			}
+		}
+		return nil
+	})
+}
+
+func (r memoryTokens) RevokeAccess(token models.RevokedToken) error {
+	return r.v.do(func(d *memoryData) error {
+		for jti, t := range d.revokedTokens {
+			if t.ExpiresAt.Before(token.CreatedAt) {
+				delete(d.revokedTokens, jti)
+			}
+		}
+		if _, ok := d.revokedTokens[token.JTI]; !ok {
+			d.revokedTokens[token.JTI] = token
+		}
+		return nil
+	})
+}
+
+func (r memoryTokens) IsAccessRevoked(jti string) (revoked bool, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		_, revoked = d.revokedTokens[jti]
+		return nil
+	})
+	return revoked, err
+}
+
+type memoryIdempotencyKeys struct {
+	v memoryView
+}
+
+func idempotencyIndex(userID, key string) string {
+	return userID + "\x00" + key
+}
+
+func (r memoryIdempotencyKeys) Reserve(key *models.IdempotencyKey) (reserved bool, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		index := idempotencyIndex(key.UserID, key.Key)
+		if _, ok := d.idempotency[index]; ok {
+			return nil
+		}
+		d.lastIdempotencyID++
+		key.ID = d.lastIdempotencyID
+		d.idempotency[index] = *key
+		reserved = true
+		return nil
+	})
+	return reserved, err
+}
+
+func (r memoryIdempotencyKeys) Get(userID, key string) (record models.IdempotencyKey, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		var ok bool
+		if record, ok = d.idempotency[idempotencyIndex(userID, key)]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return record, err
+}
+
+func (r memoryIdempotencyKeys) Save(key *models.IdempotencyKey) error {
+	return r.v.do(func(d *memoryData) error {
+		record := *key
+		record.ResponseBody = append([]byte(nil), key.ResponseBody...)
+		d.idempotency[idempotencyIndex(key.UserID, key.Key)] = record
+		return nil
+	})
+}
+
+func (r memoryIdempotencyKeys) Delete(key *models.IdempotencyKey) error {
+	return r.v.do(func(d *memoryData) error {
+		index := idempotencyIndex(key.UserID, key.Key)
+		if record, ok := d.idempotency[index]; ok && record.ID == key.ID {
+			delete(d.idempotency, index)
+		}
+		return nil
+	})
+}
+
+func (r memoryIdempotencyKeys) DeleteExpired(userID string, now time.Time) error {
+	return r.v.do(func(d *memoryData) error {
+		for index, record := range d.idempotency {
+			if record.UserID == userID && record.ExpiresAt.Before(now) {
+				delete(d.idempotency, index)
+			}
+		}
+		return nil
+	})
+}
+
+type memoryKYC struct {
+	v memoryView
+}
+
+func (r memoryKYC) CreateEvent(event *models.KYCEvent) error {
+	return r.v.do(func(d *memoryData) error {
+		d.lastKYCEventID++
+		event.ID = d.lastKYCEventID
+		d.kycEvents = append(d.kycEvents, *event)
+		return nil
+	})
+}
+
+func (r memoryKYC) Events(userID uint) ([]models.KYCEvent, error) {
+	events := []models.KYCEvent{}
+	err := r.v.do(func(d *memoryData) error {
+		for _, e := range d.kycEvents {
+			if e.UserID == userID {
+				events = append(events, e)
+			}
+		}
+		return nil
+	})
+	return events, err
+}
+
+func (r memoryKYC) CreateCheck(check *models.KYCCheck) error {
+	return r.v.do(func(d *memoryData) error {
+		for _, c := range d.kycChecks {
+			if c.Provider == check.Provider && c.Reference == check.Reference {
+				return fmt.Errorf("check %s of %s already exists", check.Reference, check.Provider)
+			}
+		}
+		d.lastKYCCheckID++
+		check.ID = d.lastKYCCheckID
+		check.CreatedAt = time.Now()
+		check.UpdatedAt = check.CreatedAt
+		d.kycChecks[check.ID] = *check
+		return nil
+	})
+}
+
+func (r memoryKYC) LockCheck(provider, reference string) (check models.KYCCheck, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		for _, c := range d.kycChecks {
+			if c.Provider == provider && c.Reference == reference {
+				check = c
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	return check, err
+}
+
+func (r memoryKYC) CompleteCheck(check *models.KYCCheck) error {
+	return r.v.do(func(d *memoryData) error {
+		if c, ok := d.kycChecks[check.ID]; ok {
+			c.Status = check.Status
+			c.Reason = check.Reason
+			c.CompletedAt = check.CompletedAt
+			c.UpdatedAt = time.Now()
+			d.kycChecks[check.ID] = c
+		}
+		return nil
+	})
+}
+
+// checks returns the checks for which match is true, oldest first.
+func (r memoryKYC) checks(match func(c models.KYCCheck) bool) ([]models.KYCCheck, error) {
+	checks := []models.KYCCheck{}
+	err := r.v.do(func(d *memoryData) error {
+		for _, c := range d.kycChecks {
+			if match(c) {
+				checks = append(checks, c)
+			}
+		}
+		return nil
+	})
+	sort.Slice(checks, func(i, j int) bool { return checks[i].ID < checks[j].ID })
+	return checks, err
+}
+
+func (r memoryKYC) Checks(userID uint) ([]models.KYCCheck, error) {
+	return r.checks(func(c models.KYCCheck) bool { return c.UserID == userID })
+}
+
+func (r memoryKYC) PendingChecks(provider string) ([]models.KYCCheck, error) {
+	return r.checks(func(c models.KYCCheck) bool {
+		return c.Provider == provider && c.Status == models.KYCCheckPending
+	})
+}
+
+func (r memoryKYC) CreateDocument(document *models.KYCDocument) error {
+	return r.v.do(func(d *memoryData) error {
+		d.lastKYCDocumentID++
+		document.ID = d.lastKYCDocumentID
+		document.CreatedAt = time.Now()
+		d.kycDocuments[document.ID] = *document
+		return nil
+	})
+}
+
+func (r memoryKYC) CurrentDocument(userID, documentID uint) (document models.KYCDocument, err error) {
+	err = r.v.do(func(d *memoryData) error {
+		var ok bool
+		document, ok = d.kycDocuments[documentID]
+		if !ok || document.UserID != userID || document.ReplacedAt != nil {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return document, err
+}
+
+func (r memoryKYC) Documents(userID uint, all bool) ([]models.KYCDocument, error) {
+	documents := []models.KYCDocument{}
+	err := r.v.do(func(d *memoryData) error {
+		for _, doc := range d.kycDocuments {
+			if doc.UserID == userID && (all || doc.ReplacedAt == nil) {
+				documents = append(documents, doc)
+			}
+		}
+		return nil
+	})
+	sort.Slice(documents, func(i, j int) bool { return documents[i].ID < documents[j].ID })
+	return documents, err
+}
+
+func (r memoryKYC) ReplaceDocuments(userID uint, docType string, at time.Time) error {
+	return r.v.do(func(d *memoryData) error {
+		for id, doc := range d.kycDocuments {
+			if doc.UserID == userID && doc.Type == docType && doc.ReplacedAt == nil {
+				replacedAt := at
+				doc.ReplacedAt = &replacedAt
+				d.kycDocuments[id] = doc
+			}
+		}
+		return nil
+	})
+}
+
+func (r memoryKYC) LinkDocuments(userID, submissionID uint) error {
+	return r.v.do(func(d *memoryData) error {
+		for id, doc := range d.kycDocuments {
+			if doc.UserID == userID && doc.ReplacedAt == nil {
+				submission := submissionID
+				doc.SubmissionID = &submission
+				d.kycDocuments[id] = doc
+			}
+		}
+		return nil
+	})
+}
+
+func (r memoryKYC) DocumentKeys() ([]string, error) {
+	var keys []string
+	err := r.v.do(func(d *memoryData) error {
+		seen := map[string]bool{}
+		for _, doc := range d.kycDocuments {
+			if !seen[doc.StorageKey] {
+				seen[doc.StorageKey] = true
+				keys = append(keys, doc.StorageKey)
+			}
+		}
+		return nil
+	})
+	sort.Strings(keys)
+	return keys, err
+}
+
+type memoryNotifications struct {
+	v memoryView
+}
+
+func (r memoryNotifications) Create(notification *models.Notification) error {
+	return r.v.do(func(d *memoryData) error {
+		d.lastNotificationID++
+		notification.ID = d.lastNotificationID
+		notification.CreatedAt = time.Now()
+		d.notifications = append(d.notifications, *notification)
+		return nil
+	})
+}
+
+func (r memoryNotifications) Inbox(userID uint, limit int) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	err := r.v.do(func(d *memoryData) error {
+		for i := len(d.notifications) - 1; i >= 0 && len(notifications) < limit; i-- {
+			if n := d.notifications[i]; n.UserID == userID {
+				notifications = append(notifications, n)
+			}
+		}
+		return nil
+	})
+	return notifications, err
+}
@@ -0,0 +1,250 @@
+// Package repository hides where users, accounts, transactions, ledger
+// entries and everything around them (tokens, idempotency keys, KYC records
+// and notifications) are stored. Handlers, middleware and services depend on
+// the interfaces below; NewGormStore backs them with the database and
+// NewMemoryStore with plain maps, so that they can be exercised without
+// PostgreSQL.
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"neobank-lite/ledger"
+	"neobank-lite/models"
+)
+
+// ErrNotFound is returned when the requested record does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// AccountNotFoundError is returned by AccountRepository.Lock for the first
+// account that does not exist. It matches ErrNotFound.
+type AccountNotFoundError struct {
+	AccountNumber string
+}
+
+func (e *AccountNotFoundError) Error() string {
+	return "account " + e.AccountNumber + " not found"
+}
+
+func (e *AccountNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// Store gives access to the repositories. Changes made through them are
+// saved immediately unless they are made inside Atomic.
+type Store interface {
+	Users() UserRepository
+	Accounts() AccountRepository
+	Transactions() TransactionRepository
+	Ledger() LedgerRepository
+	Rates() RateRepository
+	Tokens() TokenRepository
+	IdempotencyKeys() IdempotencyRepository
+	KYC() KYCRepository
+	Notifications() NotificationRepository
+
+	// Atomic runs fn as one unit of work: fn gets a Store whose changes are
+	// all kept if fn returns nil and all discarded otherwise. Nested calls
+	// roll back on their own, like savepoints.
+	Atomic(fn func(Store) error) error
+}
+
+type UserRepository interface {
+	Get(id uint) (models.User, error)
+	GetByEmail(email string) (models.User, error)
+	Create(user *models.User) error
+	// List returns up to limit users that match the filter, newest first,
+	// after skipping offset of them.
+	List(filter UserFilter, limit, offset int) ([]models.User, error)
+	// SetRole saves the role of the user.
+	SetRole(user *models.User) error
+
+	// Lock loads a user for update until the end of the unit of work.
+	Lock(id uint) (models.User, error)
+	// SetKYC saves the KYC status, reason, submission and review fields of
+	// the user.
+	SetKYC(user *models.User) error
+	// SetNationalID saves the storage key of the user's national ID.
+	SetNationalID(id uint, storageKey string) error
+	// KYCQueue returns up to limit users waiting for or in KYC review that
+	// match the filter, longest waiting first.
+	KYCQueue(filter KYCQueueFilter, limit int) ([]models.User, error)
+	// CountByKYCStatus counts users per KYC status. Statuses nobody is in
+	// are left out.
+	CountByKYCStatus() (map[string]int64, error)
+}
+
+// UserFilter selects users. Empty fields match every user.
+type UserFilter struct {
+	Role      string
+	KYCStatus string
+}
+
+// KYCQueueFilter selects users from the KYC review queue. An empty Status
+// matches both submitted and under_review users; ReviewerID limits the
+// queue to users under review by that reviewer.
+type KYCQueueFilter struct {
+	Status     string
+	ReviewerID *uint
+}
+
+type AccountRepository interface {
+	Get(accountNumber string) (models.Account, error)
+	// GetDefault returns the user's default account.
+	GetDefault(userID int) (models.Account, error)
+	// ListByUser returns the user's accounts, default account first.
+	ListByUser(userID int) ([]models.Account, error)
+	CountByUser(userID int) (int64, error)
+	// PhoneTaken reports whether an account of another user than exceptUserID
+	// has the phone number.
+	PhoneTaken(phoneNumber string, exceptUserID int) (bool, error)
+	Create(account *models.Account) error
+	Save(account *models.Account) error
+	// SetDefault makes the account the user's only default account.
+	SetDefault(userID int, accountNumber string) error
+
+	// Lock loads the accounts for update until the end of the unit of work.
+	// Accounts are always locked in account number order so two transfers
+	// touching the same pair of accounts in opposite directions cannot
+	// deadlock. A missing account fails with *AccountNotFoundError.
+	Lock(accountNumbers ...string) (map[string]*models.Account, error)
+}
+
+type TransactionRepository interface {
+	Create(transaction *models.Transaction) error
+	Get(id int) (models.Transaction, error)
+	// Lock loads a transaction for update until the end of the unit of work.
+	Lock(id int) (models.Transaction, error)
+	// SetStatus saves the status and reason code of the transaction.
+	SetStatus(transaction *models.Transaction) error
+	// SetConversion saves the amount, converted amount and FX rate of the
+	// transaction.
+	SetConversion(transaction *models.Transaction) error
+	// Reversals returns the successful reversals of a transaction.
+	Reversals(originalID int) ([]models.Transaction, error)
+	// History returns up to limit transactions of an account that match the
+	// filter, newest first, starting after the cursor if one is given.
+	History(accountNumber string, filter TransactionFilter, after *Cursor, limit int) ([]models.Transaction, error)
+}
+
+type LedgerRepository interface {
+	// Post writes the entries of a transaction. The postings must balance;
+	// see ledger.Entries.
+	Post(transactionID int, postings ...ledger.Posting) error
+	// OpenCustomerAccount creates the ledger account backing a customer account.
+	OpenCustomerAccount(accountNumber string) error
+	Entries(transactionID int) ([]models.LedgerEntry, error)
+	// TrialBalance sums all entries per ledger account and currency.
+	TrialBalance() (ledger.TrialBalance, error)
+	// Reconcile returns the customer accounts whose stored balance differs
+	// from the balance derived from their entries.
+	Reconcile() ([]ledger.Mismatch, error)
+
+	// Turnover sums the debits and credits of a ledger account in currency
+	// made by transactions dated before the given time.
+	Turnover(ledgerAccount, currency string, before time.Time) (Movement, error)
+	// Movements returns what each transaction dated in [from, to) debited
+	// and credited to a ledger account in currency, oldest first.
+	Movements(ledgerAccount, currency string, from, to time.Time) ([]Movement, error)
+}
+
+// Movement is what was debited and credited to one ledger account, in minor
+// units. Turnover leaves Transaction empty.
+type Movement struct {
+	Transaction models.Transaction
+	Debits      int64
+	Credits     int64
+}
+
+type RateRepository interface {
+	// Get returns the rate from base into quote, or an error wrapping
+	// fx.ErrNoRate if none is configured.
+	Get(base, quote string) (models.FXRate, error)
+	Put(rate models.FXRate) error
+	// List returns every rate ordered by currency pair.
+	List() ([]models.FXRate, error)
+}
+
+type TokenRepository interface {
+	Create(token *models.RefreshToken) error
+	// LockByHash loads the refresh token with the hash for update until the
+	// end of the unit of work.
+	LockByHash(tokenHash string) (models.RefreshToken, error)
+	// GetByAccessJTI returns the refresh token issued with the access token.
+	GetByAccessJTI(jti string) (models.RefreshToken, error)
+	// MarkUsed saves UsedAt of the refresh token.
+	MarkUsed(token *models.RefreshToken) error
+	// Family returns every refresh token of a family.
+	Family(familyID string) ([]models.RefreshToken, error)
+	// ActiveFamilies returns the families of the user that still have
+	// refresh tokens that are not revoked.
+	ActiveFamilies(userID uint) ([]string, error)
+	// RevokeFamily revokes the refresh tokens of a family that are not
+	// revoked yet.
+	RevokeFamily(familyID string, at time.Time) error
+
+	// RevokeAccess records a revoked access token; revoking one twice is
+	// not an error. Records of tokens that expired meanwhile are dropped.
+	RevokeAccess(token models.RevokedToken) error
+	IsAccessRevoked(jti string) (bool, error)
+}
+
+type IdempotencyRepository interface {
+	// Reserve stores the key unless the user already has it, and reports
+	// whether it did.
+	Reserve(key *models.IdempotencyKey) (bool, error)
+	Get(userID, key string) (models.IdempotencyKey, error)
+	// Save stores the response of a reserved key.
+	Save(key *models.IdempotencyKey) error
+	// Delete frees a reserved key for the next request.
+	Delete(key *models.IdempotencyKey) error
+	// DeleteExpired drops the keys of the user that expired before now.
+	DeleteExpired(userID string, now time.Time) error
+}
+
+type KYCRepository interface {
+	CreateEvent(event *models.KYCEvent) error
+	// Events returns the KYC events of a user, oldest first.
+	Events(userID uint) ([]models.KYCEvent, error)
+
+	CreateCheck(check *models.KYCCheck) error
+	// LockCheck loads a provider check for update until the end of the unit
+	// of work.
+	LockCheck(provider, reference string) (models.KYCCheck, error)
+	// CompleteCheck saves the status, reason and completion time of a check.
+	CompleteCheck(check *models.KYCCheck) error
+	// Checks returns the provider checks of a user, oldest first.
+	Checks(userID uint) ([]models.KYCCheck, error)
+	// PendingChecks returns the checks still waiting for a result from the
+	// provider, oldest first.
+	PendingChecks(provider string) ([]models.KYCCheck, error)
+
+	CreateDocument(document *models.KYCDocument) error
+	// CurrentDocument returns a document of the user that was not replaced.
+	CurrentDocument(userID, documentID uint) (models.KYCDocument, error)
+	// Documents returns the user's current documents, oldest first. With
+	// all set, replaced ones are included too.
+	Documents(userID uint, all bool) ([]models.KYCDocument, error)
+	// ReplaceDocuments marks the user's current documents of a type as
+	// replaced.
+	ReplaceDocuments(userID uint, docType string, at time.Time) error
+	// LinkDocuments attaches the user's current documents to a submission.
+	LinkDocuments(userID, submissionID uint) error
+	// DocumentKeys returns the storage key of every document.
+	DocumentKeys() ([]string, error)
+}
+
+type NotificationRepository interface {
+	Create(notification *models.Notification) error
+	// Inbox returns the newest notifications of a user.
+	Inbox(userID uint, limit int) ([]models.Notification, error)
+}
+
+// Cursor points at the last transaction of a history page. Pages are ordered
+// by (timestamp, id) descending, so the cursor stays stable while new
+// transactions are added at the top.
+type Cursor struct {
+	Timestamp time.Time
+	ID        int
+}
@@ -1,40 +1,129 @@
 package routes
 
 import (
+	"encoding/json"
+	"neobank-lite/auth"
+	"neobank-lite/config"
 	"neobank-lite/controllers"
+	"neobank-lite/database"
+	"neobank-lite/kyc"
+	"neobank-lite/metrics"
 	"neobank-lite/middleware"
+	"neobank-lite/models"
+	"neobank-lite/problem"
+	"neobank-lite/repository"
+	"neobank-lite/storage"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
-func SetupRouter() *mux.Router {
+// SetupRouter wires every endpoint to handlers backed by store, with
+// documents kept in documents and KYC run by workflow. db is only pinged
+// and checked for migrations by the readiness probe. transactions must be
+// started by the caller, which also decides when it stops.
+func SetupRouter(cfg *config.Config, db *gorm.DB, store repository.Store, documents *storage.Vault, workflow *kyc.Service, transactions *controllers.TransactionHandler) *mux.Router {
+	tokens := auth.NewService(store, cfg.Auth)
+	users := controllers.NewUserHandler(store, tokens, workflow, documents)
+	accounts := controllers.NewAccountHandler(store)
+	kyc := controllers.NewKYCHandler(store, workflow, documents)
+	admins := controllers.NewAdminHandler(store, tokens)
+	ledger := controllers.NewLedgerHandler(store)
+	fx := controllers.NewFXHandler(store)
+	notifications := controllers.NewNotificationHandler(store)
+	downloads := controllers.NewDocumentHandler(documents)
+	health := controllers.NewHealthHandler(database.NewStatus(db), documents, transactions, cfg.Health)
+
+	authenticated := middleware.JWTAuth([]byte(cfg.Auth.JWTSecret.Reveal()), tokens)
+	idempotent := middleware.Idempotency(store.IdempotencyKeys(), cfg.IdempotencyTTL)
+
 	router := mux.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(middleware.Metrics)
+
+	// Unknown routes get the same error body as everything else
+	router.NotFoundHandler = middleware.RequestID(middleware.Metrics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problem.Error(w, r, http.StatusNotFound, problem.CodeNotFound, "Not found")
+	})))
+	router.MethodNotAllowedHandler = middleware.RequestID(middleware.Metrics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problem.Error(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+	})))
+
+	// Probes for the orchestrator. /health is kept for older monitors.
+	router.HandleFunc("/healthz", health.Liveness).Methods("GET")
+	router.HandleFunc("/health", health.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", health.Readiness).Methods("GET")
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
 
 	// Public routes
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("NeoBank API is up and running! 🚀"))
-	}).Methods("GET")
-	router.HandleFunc("/register", controllers.Register).Methods("POST")
-	router.HandleFunc("/login", controllers.Login).Methods("POST")
+	router.HandleFunc("/register", users.Register).Methods("POST")
+	router.HandleFunc("/login", users.Login).Methods("POST")
+	router.HandleFunc("/auth/refresh", users.RefreshToken).Methods("POST")
+	router.HandleFunc("/kyc/webhook", kyc.KYCWebhook).Methods("POST")
+	router.HandleFunc("/documents/{key:.+}", downloads.DownloadDocument).Methods("GET")
+	router.Handle("/auth/logout", authenticated(http.HandlerFunc(users.Logout))).Methods("POST")
 
 	// Protected routes group
 	protected := router.PathPrefix("/api").Subrouter()
-	protected.Use(middleware.JWTAuth)
+	protected.Use(authenticated)
 
 	// Example protected endpoint
 	protected.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
-		userID := middleware.GetUserIDFromContext(r)
-		w.Write([]byte("✅ Authenticated. Your user ID: " + userID))
-
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Authenticated",
+			"user_id": middleware.GetUserIDFromContext(r),
+		})
 	}).Methods("GET")
-	protected.HandleFunc("/account/create", controllers.CreateAccount).Methods("POST")
-	protected.HandleFunc("/account/balance", controllers.GetBalance).Methods("GET")
-	protected.HandleFunc("/transaction/deposit", controllers.Deposit).Methods("POST")
-	protected.HandleFunc("/transaction/transfer", controllers.Transfer).Methods("POST")
-	protected.HandleFunc("/transaction/history", controllers.TransactionHistory).Methods("GET")
-	protected.HandleFunc("/kyc/verify", controllers.SubmitKYC).Methods("POST")
-	protected.HandleFunc("/kyc/status", controllers.GetKYCStatus).Methods("GET")
+	protected.HandleFunc("/account/create", accounts.CreateAccount).Methods("POST")
+	protected.HandleFunc("/account/balance", accounts.GetBalance).Methods("GET")
+	protected.HandleFunc("/accounts", accounts.ListAccounts).Methods("GET")
+	protected.HandleFunc("/account/{number}/balance", accounts.GetAccountBalance).Methods("GET")
+	protected.HandleFunc("/account/{number}/history", transactions.AccountHistory).Methods("GET")
+	protected.HandleFunc("/account/{number}/statement", accounts.AccountStatement).Methods("GET")
+	protected.HandleFunc("/account/{number}/default", accounts.SetDefaultAccount).Methods("PUT")
+	protected.Handle("/transaction/deposit", idempotent(http.HandlerFunc(transactions.Deposit))).Methods("POST")
+	protected.Handle("/transaction/transfer", idempotent(http.HandlerFunc(transactions.Transfer))).Methods("POST")
+	protected.Handle("/transaction/withdraw", idempotent(http.HandlerFunc(transactions.Withdraw))).Methods("POST")
+	protected.Handle("/transaction/{id}/reverse", middleware.RequirePermission(middleware.PermReverseTransactions)(
+		idempotent(http.HandlerFunc(transactions.ReverseTransaction)))).Methods("POST")
+	protected.HandleFunc("/transaction/history", transactions.TransactionHistory).Methods("GET")
+	protected.HandleFunc("/kyc/verify", kyc.SubmitKYC).Methods("POST")
+	protected.HandleFunc("/kyc/status", kyc.GetKYCStatus).Methods("GET")
+	protected.HandleFunc("/kyc/documents", kyc.UploadKYCDocument).Methods("POST")
+	protected.HandleFunc("/kyc/documents", kyc.ListKYCDocuments).Methods("GET")
+	protected.HandleFunc("/kyc/documents/{id}", kyc.ReplaceKYCDocument).Methods("PUT")
+	protected.HandleFunc("/notifications", notifications.ListNotifications).Methods("GET")
+	protected.Handle("/ledger/trial-balance", middleware.RequirePermission(middleware.PermViewLedger)(http.HandlerFunc(ledger.TrialBalance))).Methods("GET")
+	protected.Handle("/ledger/reconciliation", middleware.RequirePermission(middleware.PermViewLedger)(http.HandlerFunc(ledger.Reconciliation))).Methods("GET")
+	protected.HandleFunc("/fx/rates", fx.ListFXRates).Methods("GET")
+	protected.Handle("/fx/rates", middleware.RequirePermission(middleware.PermManageFX)(http.HandlerFunc(fx.SetFXRate))).Methods("PUT")
+
+	// Staff routes; each one is further limited to the roles holding its permission
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(authenticated)
+	admin.Use(middleware.RequireRole(models.RoleAdmin, models.RoleSupport, models.RoleCompliance, models.RoleAuditor))
+
+	allow := func(p middleware.Permission, h http.HandlerFunc) http.Handler {
+		return middleware.RequirePermission(p)(h)
+	}
+	admin.Handle("/users", allow(middleware.PermViewUsers, admins.ListUsers)).Methods("GET")
+	admin.Handle("/users/{id}", allow(middleware.PermViewUsers, admins.GetUser)).Methods("GET")
+	admin.Handle("/users/{id}/role", allow(middleware.PermManageRoles, admins.SetUserRole)).Methods("PUT")
+	admin.Handle("/accounts/{number}", allow(middleware.PermViewAccounts, admins.GetAnyAccount)).Methods("GET")
+	admin.Handle("/accounts/{number}/history", allow(middleware.PermViewAccounts, transactions.GetAnyAccountHistory)).Methods("GET")
+	admin.Handle("/accounts/{number}/freeze", allow(middleware.PermFreezeAccounts, admins.FreezeAccount)).Methods("POST")
+	admin.Handle("/accounts/{number}/unfreeze", allow(middleware.PermFreezeAccounts, admins.UnfreezeAccount)).Methods("POST")
+	admin.Handle("/kyc/queue", allow(middleware.PermReviewKYC, kyc.KYCQueue)).Methods("GET")
+	admin.Handle("/kyc/{id}/history", allow(middleware.PermReviewKYC, kyc.KYCHistory)).Methods("GET")
+	admin.Handle("/kyc/{id}/checks", allow(middleware.PermReviewKYC, kyc.KYCChecks)).Methods("GET")
+	admin.Handle("/kyc/{id}/document", allow(middleware.PermReviewKYC, kyc.NationalIDURL)).Methods("GET")
+	admin.Handle("/kyc/{id}/documents", allow(middleware.PermReviewKYC, kyc.UserKYCDocuments)).Methods("GET")
+	admin.Handle("/kyc/{id}/review", allow(middleware.PermReviewKYC, kyc.StartKYCReview)).Methods("POST")
+	admin.Handle("/kyc/{id}/approve", allow(middleware.PermReviewKYC, kyc.ApproveKYC)).Methods("POST")
+	admin.Handle("/kyc/{id}/reject", allow(middleware.PermReviewKYC, kyc.RejectKYC)).Methods("POST")
+	admin.Handle("/kyc/{id}/request-resubmission", allow(middleware.PermReviewKYC, kyc.RequestKYCResubmission)).Methods("POST")
 
 	return router
 }
@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"neobank-lite/config"
+	"neobank-lite/controllers"
+	"neobank-lite/kyc"
+	"neobank-lite/models"
+	"neobank-lite/repository"
+	"neobank-lite/utils"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// The staff routes check the role of the token before anything else, and
+// then the permission each route needs.
+func TestAdminRoutesRequireRole(t *testing.T) {
+	store := repository.NewMemoryStore()
+	cfg := &config.Config{Auth: config.Auth{JWTSecret: testSecret, AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour}}
+	transactions := controllers.NewTransactionHandler(store, cfg.Transactions)
+	router := SetupRouter(cfg, nil, store, nil, kyc.NewService(store, nil, nil), transactions)
+
+	routes := []struct {
+		method, path, body string
+	}{
+		{"GET", "/admin/users", ""},
+		{"PUT", "/admin/users/1/role", `{"role":"support"}`},
+		{"POST", "/admin/accounts/etb-a/freeze", `{"reason":"stolen phone"}`},
+		{"GET", "/admin/kyc/queue", ""},
+	}
+	tests := []struct {
+		role    string
+		allowed []bool // per route
+	}{
+		{models.RoleUser, []bool{false, false, false, false}},
+		{models.RoleAuditor, []bool{true, false, false, false}},
+		{models.RoleSupport, []bool{true, false, true, false}},
+		{models.RoleCompliance, []bool{true, false, true, true}},
+		{models.RoleAdmin, []bool{true, true, true, true}},
+	}
+	for _, tt := range tests {
+		token, err := utils.GenerateJWT([]byte(testSecret), 1, tt.role, "jti-"+tt.role, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, route := range routes {
+			r := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+			r.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if forbidden := w.Code == http.StatusForbidden; forbidden == tt.allowed[i] {
+				t.Errorf("%s %s as %s: got %d", route.method, route.path, tt.role, w.Code)
+			}
+			if w.Code == http.StatusUnauthorized {
+				t.Errorf("%s %s as %s: token rejected", route.method, route.path, tt.role)
+			}
+		}
+	}
+}
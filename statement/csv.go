@@ -0,0 +1,58 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvFormulaPrefixes make spreadsheet applications read a cell as a formula.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+var csvNumber = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// csvCell stops a spreadsheet from evaluating s, by prefixing a cell that
+// would start a formula with a quote. Plain numbers such as negative amounts
+// are left alone so they can still be summed.
+func csvCell(s string) string {
+	if s == "" || !strings.ContainsRune(csvFormulaPrefixes, rune(s[0])) || csvNumber.MatchString(s) {
+		return s
+	}
+	return "'" + s
+}
+
+// WriteCSV writes the statement as CSV. The opening and closing balances are
+// rows of their own so the running balance column reads top to bottom. Text
+// that a spreadsheet would run as a formula is quoted, see csvCell.
+func WriteCSV(w io.Writer, s Statement) error {
+	cw := csv.NewWriter(w)
+	number := s.Account.AccountNumber
+	currency := s.Account.Balance.Currency
+
+	records := [][]string{
+		{"date", "transaction_id", "type", "description", "amount", "balance", "currency"},
+		{s.From.UTC().Format(time.RFC3339), "", "", "Opening balance", "", s.Opening.Decimal(), currency},
+	}
+	for _, line := range s.Lines {
+		records = append(records, []string{
+			line.Transaction.Timestamp.UTC().Format(time.RFC3339),
+			strconv.Itoa(line.Transaction.ID),
+			line.Transaction.Type,
+			line.Description(number),
+			line.Amount.Decimal(),
+			line.Balance.Decimal(),
+			currency,
+		})
+	}
+	records = append(records, []string{s.To.UTC().Format(time.RFC3339), "", "", "Closing balance", "", s.Closing.Decimal(), currency})
+
+	for _, record := range records {
+		for i, cell := range record {
+			record[i] = csvCell(cell)
+		}
+	}
+	return cw.WriteAll(records)
+}
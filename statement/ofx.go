@@ -0,0 +1,156 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OFX 2.1.1 bank statement response, the subset that accounting tools need
+// to import transactions and the ledger balance.
+type ofxDocument struct {
+	XMLName xml.Name             `xml:"OFX"`
+	SignOn  ofxSignOn            `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxStatementResponse `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	DTServer string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxStatementResponse struct {
+	TrnUID string       `xml:"TRNUID"`
+	Status ofxStatus    `xml:"STATUS"`
+	Stmt   ofxStatement `xml:"STMTRS"`
+}
+
+type ofxStatement struct {
+	Currency  string      `xml:"CURDEF"`
+	Account   ofxAccount  `xml:"BANKACCTFROM"`
+	TranList  ofxTranList `xml:"BANKTRANLIST"`
+	LedgerBal ofxBalance  `xml:"LEDGERBAL"`
+}
+
+type ofxAccount struct {
+	BankID   string `xml:"BANKID"`
+	AcctID   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
+
+type ofxTranList struct {
+	DTStart      string         `xml:"DTSTART"`
+	DTEnd        string         `xml:"DTEND"`
+	Transactions []ofxTransLine `xml:"STMTTRN"`
+}
+
+type ofxTransLine struct {
+	Type     string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	Amount   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+// ofxBankID identifies this bank in the BANKACCTFROM aggregate.
+const ofxBankID = "NEOBANK"
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405") + "[0:GMT]"
+}
+
+// ofxAccountType maps our account types onto the ones OFX knows.
+func ofxAccountType(accountType string) string {
+	if strings.EqualFold(accountType, "savings") {
+		return "SAVINGS"
+	}
+	return "CHECKING"
+}
+
+func ofxTransactionType(line Line) string {
+	switch line.Transaction.Type {
+	case "deposit":
+		return "DEP"
+	case "withdraw":
+		return "CASH"
+	case "transfer":
+		return "XFER"
+	}
+	if line.Amount.IsNegative() {
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+// WriteOFX writes the statement as an OFX 2 document. Transaction IDs are
+// used as FITIDs so importing the same period twice does not duplicate lines.
+func WriteOFX(w io.Writer, s Statement) error {
+	number := s.Account.AccountNumber
+
+	lines := make([]ofxTransLine, 0, len(s.Lines))
+	for _, line := range s.Lines {
+		name := line.Description(number)
+		if r := []rune(name); len(r) > 32 { // NAME is limited to 32 characters
+			name = string(r[:32])
+		}
+		lines = append(lines, ofxTransLine{
+			Type:     ofxTransactionType(line),
+			DTPosted: ofxTime(line.Transaction.Timestamp),
+			Amount:   line.Amount.Decimal(),
+			FITID:    strconv.Itoa(line.Transaction.ID),
+			Name:     name,
+			Memo:     line.Transaction.Description,
+		})
+	}
+
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   ofxStatus{Code: 0, Severity: "INFO"},
+			DTServer: ofxTime(s.GeneratedAt),
+			Language: "ENG",
+		},
+		Bank: ofxStatementResponse{
+			TrnUID: "0",
+			Status: ofxStatus{Code: 0, Severity: "INFO"},
+			Stmt: ofxStatement{
+				Currency: s.Account.Balance.Currency,
+				Account: ofxAccount{
+					BankID:   ofxBankID,
+					AcctID:   number,
+					AcctType: ofxAccountType(s.Account.AccountType),
+				},
+				TranList: ofxTranList{
+					DTStart:      ofxTime(s.From),
+					DTEnd:        ofxTime(s.To),
+					Transactions: lines,
+				},
+				LedgerBal: ofxBalance{Amount: s.Closing.Decimal(), DTAsOf: ofxTime(s.To)},
+			},
+		},
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,192 @@
+package statement
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A4 in points, with the layout of the statement table.
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfLineHeight   = 12
+	pdfTableSize    = 8
+	pdfLinesPerPage = 56
+)
+
+// pdfFonts are the standard PDF fonts used by the statement; they need no
+// embedding. The table is set in Courier so columns line up by padding.
+var pdfFonts = []string{"Helvetica", "Helvetica-Bold", "Courier", "Courier-Bold"}
+
+const (
+	fontRegular  = "F1"
+	fontBold     = "F2"
+	fontMono     = "F3"
+	fontMonoBold = "F4"
+)
+
+// pdfPage collects the content stream of one page.
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+func (p *pdfPage) text(font string, size float64, x, y float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %g Tf %g %g Td (%s) Tj ET\n", font, size, x, y, pdfEscape(s))
+}
+
+func (p *pdfPage) rule(y float64) {
+	fmt.Fprintf(&p.content, "%g %g m %g %g l 0.5 w S\n", float64(pdfMargin), y, float64(pdfPageWidth-pdfMargin), y)
+}
+
+// pdfEscape makes s safe inside a PDF string literal. The standard fonts
+// use WinAnsiEncoding, so characters outside Latin-1 become "?".
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x80:
+			b.WriteRune(r)
+		case r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// tableRow formats one row of the statement table in fixed-width columns.
+// The ID column is idWidth characters wide.
+func tableRow(idWidth int, date, id, description, amount, balance string) string {
+	if r := []rune(description); len(r) > 40 {
+		description = string(r[:39]) + "~"
+	}
+	return fmt.Sprintf("%-16s %*s  %-40s %15s %15s", date, idWidth, id, description, amount, balance)
+}
+
+// WritePDF renders the statement as a PDF document.
+func WritePDF(w io.Writer, s Statement) error {
+	number := s.Account.AccountNumber
+	currency := s.Account.Balance.Currency
+
+	// The ID column is as wide as the largest ID.
+	idWidth := len("ID")
+	for _, line := range s.Lines {
+		idWidth = max(idWidth, len(strconv.Itoa(line.Transaction.ID)))
+	}
+
+	rows := []string{tableRow(idWidth, "", "", "Opening balance", "", s.Opening.Decimal())}
+	for _, line := range s.Lines {
+		rows = append(rows, tableRow(idWidth,
+			line.Transaction.Timestamp.UTC().Format("2006-01-02 15:04"),
+			strconv.Itoa(line.Transaction.ID),
+			line.Description(number),
+			line.Amount.Decimal(),
+			line.Balance.Decimal(),
+		))
+	}
+	rows = append(rows, tableRow(idWidth, "", "", "Closing balance", "", s.Closing.Decimal()))
+
+	var pages []*pdfPage
+	for len(rows) > 0 || len(pages) == 0 {
+		page := &pdfPage{}
+		y := float64(pdfPageHeight - pdfMargin)
+		perPage := pdfLinesPerPage
+		if len(pages) == 0 {
+			page.text(fontBold, 16, pdfMargin, y, "Account Statement")
+			y -= 24
+			for _, field := range []string{
+				"Account: " + number + " (" + s.Account.AccountType + ")",
+				"Currency: " + currency,
+				"Period: " + s.From.UTC().Format("2006-01-02 15:04") + " to " + s.To.UTC().Format("2006-01-02 15:04") + " UTC",
+				"Generated: " + s.GeneratedAt.UTC().Format("2006-01-02 15:04") + " UTC",
+				"Opening balance: " + s.Opening.String() + "    Closing balance: " + s.Closing.String(),
+			} {
+				page.text(fontRegular, 10, pdfMargin, y, field)
+				y -= 14
+			}
+			y -= 10
+			perPage -= 8
+		}
+
+		page.text(fontMonoBold, pdfTableSize, pdfMargin, y, tableRow(idWidth, "Date (UTC)", "ID", "Description", "Amount", "Balance"))
+		page.rule(y - 4)
+		y -= pdfLineHeight + 4
+
+		n := min(perPage, len(rows))
+		for _, row := range rows[:n] {
+			page.text(fontMono, pdfTableSize, pdfMargin, y, row)
+			y -= pdfLineHeight
+		}
+		rows = rows[n:]
+		pages = append(pages, page)
+	}
+
+	for i, page := range pages {
+		page.text(fontRegular, 8, pdfMargin, pdfMargin/2, fmt.Sprintf("%s - page %d of %d", number, i+1, len(pages)))
+	}
+	return writePDFDocument(w, pages)
+}
+
+// writePDFDocument writes the pages as a complete PDF file. Object numbers
+// are laid out as: catalog, page tree, fonts, then a page and its content
+// stream for every page.
+func writePDFDocument(w io.Writer, pages []*pdfPage) error {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	firstFont := 3
+	firstPage := firstFont + len(pdfFonts)
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+
+	var fonts []string
+	for i, name := range pdfFonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", i+1, firstFont+i))
+	}
+
+	for i, page := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, strings.Join(fonts, " "), firstPage+2*i+1))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		zw.Write(page.content.Bytes())
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := out.WriteTo(w)
+	return err
+}
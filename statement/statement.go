@@ -0,0 +1,107 @@
+// Package statement builds account statements and renders them as CSV, PDF
+// or OFX.
+package statement
+
+import (
+	"strconv"
+	"time"
+
+	"neobank-lite/ledger"
+	"neobank-lite/models"
+	"neobank-lite/money"
+	"neobank-lite/repository"
+)
+
+// Line is one transaction on a statement. Amount is signed from the
+// account holder's point of view: money in is positive, money out negative.
+type Line struct {
+	Transaction models.Transaction
+	Amount      money.Money
+	Balance     money.Money // running balance after this line
+}
+
+// Statement covers the period [From, To) of one account.
+type Statement struct {
+	Account     models.Account
+	From        time.Time
+	To          time.Time
+	GeneratedAt time.Time
+	Opening     money.Money
+	Closing     money.Money
+	Lines       []Line
+}
+
+// Build assembles the statement of account for [from, to).
+//
+// Balances come from the account's ledger entries rather than from the
+// transactions table, so failed transactions never show up and reversals
+// are counted exactly as they were posted.
+func Build(entries repository.LedgerRepository, account models.Account, from, to time.Time) (Statement, error) {
+	currency := account.Balance.Currency
+	code := ledger.CustomerAccount(account.AccountNumber)
+	s := Statement{
+		Account:     account,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now(),
+		Lines:       []Line{},
+	}
+
+	before, err := entries.Turnover(code, currency, from)
+	if err != nil {
+		return Statement{}, err
+	}
+	opening := net(before)
+	s.Opening = money.New(opening, currency)
+
+	movements, err := entries.Movements(code, currency, from, to)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	balance := opening
+	for _, m := range movements {
+		balance += net(m)
+		s.Lines = append(s.Lines, Line{
+			Transaction: m.Transaction,
+			Amount:      money.New(net(m), currency),
+			Balance:     money.New(balance, currency),
+		})
+	}
+	s.Closing = money.New(balance, currency)
+	return s, nil
+}
+
+// net turns a customer ledger movement into a balance change. Customer
+// accounts are liabilities, so credits increase the balance.
+func net(m repository.Movement) int64 {
+	return m.Credits - m.Debits
+}
+
+// Description is the human readable text shown for a line.
+func (l Line) Description(accountNumber string) string {
+	t := l.Transaction
+	switch t.Type {
+	case "deposit":
+		return "Deposit"
+	case "withdraw":
+		return "Withdrawal"
+	case "opening_balance":
+		return "Opening balance"
+	case "transfer":
+		if t.FromAccount == accountNumber {
+			return "Transfer to " + t.ToAccount
+		}
+		return "Transfer from " + t.FromAccount
+	case "reversal":
+		text := "Reversal"
+		if t.ReversalOf != nil {
+			text += " of #" + strconv.Itoa(*t.ReversalOf)
+		}
+		if t.Description != "" {
+			text += ": " + t.Description
+		}
+		return text
+	}
+	return t.Type
+}
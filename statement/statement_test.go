@@ -0,0 +1,177 @@
+package statement
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"neobank-lite/models"
+	"neobank-lite/money"
+)
+
+// testStatement returns a statement of account "a" with a deposit, a
+// transfer out and a reversal whose description tries to be a formula.
+func testStatement() Statement {
+	etb := func(minor int64) money.Money { return money.New(minor, "ETB") }
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 9, 30, 0, 0, time.UTC) }
+	reversed := 7
+	return Statement{
+		Account:     models.Account{AccountNumber: "a", AccountType: "savings", Balance: etb(0)},
+		From:        day(1),
+		To:          day(31),
+		GeneratedAt: day(31),
+		Opening:     etb(10000),
+		Closing:     etb(13500),
+		Lines: []Line{
+			{models.Transaction{ID: 7, Type: "deposit", FromAccount: "a", ToAccount: "a", Timestamp: day(2)}, etb(5000), etb(15000)},
+			{models.Transaction{ID: 8, Type: "transfer", FromAccount: "a", ToAccount: "b", Timestamp: day(3)}, etb(-2500), etb(12500)},
+			{models.Transaction{ID: 123456789, Type: "reversal", FromAccount: "b", ToAccount: "a", Timestamp: day(4),
+				ReversalOf: &reversed, Description: "=HYPERLINK(\"http://evil\")"}, etb(1000), etb(13500)},
+		},
+	}
+}
+
+func TestCSVCell(t *testing.T) {
+	for in, want := range map[string]string{
+		"Deposit":             "Deposit",
+		"":                    "",
+		"-25.00":              "-25.00",
+		"1500":                "1500",
+		"=1+1":                "'=1+1",
+		"+251911234567":       "'+251911234567",
+		"-2+3":                "'-2+3",
+		"@SUM(A1:A2)":         "'@SUM(A1:A2)",
+		"\t=cmd":              "'\t=cmd",
+		"Transfer from =evil": "Transfer from =evil",
+	} {
+		if got := csvCell(in); got != want {
+			t.Errorf("csvCell(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	s := testStatement()
+	s.Lines[1].Transaction.Type = "=cmd|' /C calc'!A0" // not a type we write, shown as is
+	var out bytes.Buffer
+	if err := WriteCSV(&out, s); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"date", "transaction_id", "type", "description", "amount", "balance", "currency"},
+		{"2024-03-01T09:30:00Z", "", "", "Opening balance", "", "100.00", "ETB"},
+		{"2024-03-02T09:30:00Z", "7", "deposit", "Deposit", "50.00", "150.00", "ETB"},
+		{"2024-03-03T09:30:00Z", "8", "'=cmd|' /C calc'!A0", "'=cmd|' /C calc'!A0", "-25.00", "125.00", "ETB"},
+		{"2024-03-04T09:30:00Z", "123456789", "reversal", "Reversal of #7: =HYPERLINK(\"http://evil\")", "10.00", "135.00", "ETB"},
+		{"2024-03-31T09:30:00Z", "", "", "Closing balance", "", "135.00", "ETB"},
+	}
+	if fmt.Sprint(records) != fmt.Sprint(want) {
+		t.Errorf("CSV is\n%q\nwant\n%q", records, want)
+	}
+}
+
+func TestWriteOFX(t *testing.T) {
+	s := testStatement()
+	s.Lines[1].Transaction.ToAccount = strings.Repeat("b", 40)
+	var out bytes.Buffer
+	if err := WriteOFX(&out, s); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), ofxHeader) {
+		t.Errorf("missing OFX header:\n%s", out.String())
+	}
+
+	var doc ofxDocument
+	if err := xml.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	stmt := doc.Bank.Stmt
+	if stmt.Currency != "ETB" || stmt.Account.AcctID != "a" || stmt.Account.AcctType != "SAVINGS" {
+		t.Errorf("statement of %+v in %s", stmt.Account, stmt.Currency)
+	}
+	if stmt.LedgerBal.Amount != "135.00" || stmt.TranList.DTStart != "20240301093000[0:GMT]" {
+		t.Errorf("balance %+v, period from %s", stmt.LedgerBal, stmt.TranList.DTStart)
+	}
+	lines := stmt.TranList.Transactions
+	if len(lines) != 3 {
+		t.Fatalf("got %d transactions, want 3", len(lines))
+	}
+	tests := []struct{ typ, amount, fitid, name string }{
+		{"DEP", "50.00", "7", "Deposit"},
+		{"XFER", "-25.00", "8", ("Transfer to " + strings.Repeat("b", 40))[:32]},
+		{"CREDIT", "10.00", "123456789", "Reversal of #7: =HYPERLINK(\"http"},
+	}
+	for i, tt := range tests {
+		l := lines[i]
+		if l.Type != tt.typ || l.Amount != tt.amount || l.FITID != tt.fitid || l.Name != tt.name {
+			t.Errorf("transaction %d is %+v, want %+v", i, l, tt)
+		}
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	s := testStatement()
+	for i := 0; i < 60; i++ {
+		s.Lines = append(s.Lines, s.Lines[0])
+	}
+	var out bytes.Buffer
+	if err := WritePDF(&out, s); err != nil {
+		t.Fatal(err)
+	}
+	pdf := out.Bytes()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("not a PDF file")
+	}
+	if !bytes.Contains(pdf, []byte("/Count 2")) {
+		t.Error("65 rows do not span two pages")
+	}
+
+	// Every cross-reference entry points at its object.
+	xref := pdf[bytes.LastIndex(pdf, []byte("\nxref\n"))+1:]
+	for n, offset := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(xref, -1) {
+		at, _ := strconv.Atoi(string(offset[1]))
+		if want := fmt.Sprintf("%d 0 obj", n+1); !bytes.HasPrefix(pdf[at:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", n+1, pdf[at:at+10])
+		}
+	}
+
+	var text strings.Builder
+	for _, stream := range regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(&text, zr)
+	}
+	rows := map[string]string{}
+	for _, m := range regexp.MustCompile(`\((.*)\) Tj`).FindAllStringSubmatch(text.String(), -1) {
+		row := strings.NewReplacer(`\(`, "(", `\)`, ")", `\\`, `\`).Replace(m[1])
+		if fields := strings.Fields(row); len(fields) > 2 {
+			rows[fields[2]] = row
+		}
+	}
+	header, long, short := rows["ID"], rows["123456789"], rows["7"]
+	if header == "" || long == "" || short == "" {
+		t.Fatalf("table rows missing from\n%s", text.String())
+	}
+	// The ID column fits the largest ID and keeps the columns aligned.
+	end := strings.Index(long, "123456789") + len("123456789")
+	if strings.Index(header, "ID")+len("ID") != end || strings.Index(short, " 7 ")+len(" 7") != end {
+		t.Errorf("ID column is not aligned:\n%s\n%s\n%s", header, short, long)
+	}
+	if len(header) != len(long) || len(short) != len(long) {
+		t.Errorf("rows differ in width:\n%s\n%s\n%s", header, short, long)
+	}
+}
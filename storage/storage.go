@@ -0,0 +1,245 @@
+// Package storage keeps uploaded documents such as national ID images.
+//
+// Documents are stored under keys generated here, never under names chosen
+// by the client. A Vault checks them for size and type on the way in,
+// encrypts them with AES-256-GCM before they reach the backend, and hands
+// them out through signed, time-limited URLs.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"time"
+
+	"neobank-lite/config"
+
+	"github.com/google/uuid"
+)
+
+// MaxDocumentSize is the largest document accepted.
+const MaxDocumentSize = 5 << 20
+
+var (
+	ErrTooLarge        = errors.New("document is too large")
+	ErrUnsupportedType = errors.New("unsupported document type")
+	ErrNotFound        = errors.New("document not found")
+	ErrInvalidURL      = errors.New("invalid or expired document URL")
+	ErrNotEncrypted    = errors.New("document is not encrypted")
+)
+
+// DocumentStore is where encrypted documents end up.
+type DocumentStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// allowedTypes maps the content types we accept to the extension used in keys.
+var allowedTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// Encrypted documents start with sealedMagic, followed by the GCM nonce and
+// the ciphertext. The key is used as additional data, so a blob copied to
+// another key does not decrypt.
+const sealedMagic = "NBDOC1"
+
+// Vault encrypts documents into a DocumentStore and signs URLs to them.
+type Vault struct {
+	store  DocumentStore
+	sealer cipher.AEAD
+	urlKey []byte
+}
+
+// New returns a Vault over the local or s3 document store named by
+// cfg.Store, encrypting with cfg.EncryptionKey.
+func New(cfg config.Documents) (*Vault, error) {
+	key, err := base64.StdEncoding.DecodeString(cfg.EncryptionKey.Reveal())
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("document encryption key must be 32 bytes, base64 encoded")
+	}
+
+	var s DocumentStore
+	switch cfg.Store {
+	case "local":
+		s = NewLocalStore(cfg.Dir)
+	case "s3":
+		s, err = NewS3Store(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey.Reveal())
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown document store %q", cfg.Store)
+	}
+	return NewVault(s, key)
+}
+
+// NewVault returns a Vault over s, encrypting with the 32 byte key.
+func NewVault(s DocumentStore, key []byte) (*Vault, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("document-url"))
+
+	return &Vault{store: s, sealer: aead, urlKey: mac.Sum(nil)}, nil
+}
+
+// Save checks and encrypts a document and stores it in folder under a new
+// key, which is returned.
+func (v *Vault) Save(ctx context.Context, r io.Reader, folder string) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxDocumentSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > MaxDocumentSize {
+		return "", ErrTooLarge
+	}
+	ext, ok := allowedTypes[http.DetectContentType(data)]
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+
+	key := folder + "/" + uuid.New().String() + ext
+	if err := v.store.Put(ctx, key, v.seal(key, data)); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Open returns the decrypted document stored under key. It refuses
+// documents that are not encrypted; EncryptLegacy takes care of those
+// uploaded before encryption was introduced.
+func (v *Vault) Open(ctx context.Context, key string) ([]byte, error) {
+	data, err := v.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte(sealedMagic)) {
+		return nil, ErrNotEncrypted
+	}
+	data = data[len(sealedMagic):]
+	n := v.sealer.NonceSize()
+	if len(data) < n {
+		return nil, errors.New("document is corrupt")
+	}
+	return v.sealer.Open(nil, data[:n], data[n:], []byte(key))
+}
+
+// generatedKey matches the keys Save generates. Documents uploaded before
+// encryption was introduced were stored under the client's file name.
+var generatedKey = regexp.MustCompile(`^[^/]+/[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\.[a-z]+$`)
+
+// EncryptLegacy encrypts in place those of the documents under keys that
+// were stored before encryption was introduced, and returns how many it
+// encrypted. Keys generated by Save, documents already encrypted and
+// documents that no longer exist are skipped, so it can run on every start.
+func (v *Vault) EncryptLegacy(ctx context.Context, keys []string) (int, error) {
+	encrypted := 0
+	for _, key := range keys {
+		if generatedKey.MatchString(key) {
+			continue
+		}
+		data, err := v.store.Get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		} else if err != nil {
+			return encrypted, fmt.Errorf("reading %s: %w", key, err)
+		}
+		if bytes.HasPrefix(data, []byte(sealedMagic)) {
+			continue
+		}
+		if err := v.store.Put(ctx, key, v.seal(key, data)); err != nil {
+			return encrypted, fmt.Errorf("encrypting %s: %w", key, err)
+		}
+		encrypted++
+	}
+	return encrypted, nil
+}
+
+// Delete removes a document.
+func (v *Vault) Delete(ctx context.Context, key string) error {
+	return v.store.Delete(ctx, key)
+}
+
+// Probe writes, reads back and deletes a small object, to check that the
+// store is usable.
+func (v *Vault) Probe(ctx context.Context) error {
+	key := "healthz/" + uuid.New().String()
+	if err := v.store.Put(ctx, key, []byte("ok")); err != nil {
+		return err
+	}
+	if _, err := v.store.Get(ctx, key); err != nil {
+		return err
+	}
+	return v.store.Delete(ctx, key)
+}
+
+// ContentType is the type of the document stored under key.
+func ContentType(key string) string {
+	for contentType, ext := range allowedTypes {
+		if path.Ext(key) == ext {
+			return contentType
+		}
+	}
+	return "application/octet-stream"
+}
+
+// SignURL returns a path under /documents/ that serves the document until
+// ttl has passed.
+func (v *Vault) SignURL(key string, ttl time.Duration) (string, time.Time) {
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	q.Set("signature", v.urlSignature(key, expires.Unix()))
+	return "/documents/" + (&url.URL{Path: key}).EscapedPath() + "?" + q.Encode(), expires
+}
+
+// VerifyURL checks the expires and signature parameters of a signed URL.
+func (v *Vault) VerifyURL(key, expires, signature string) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return ErrInvalidURL
+	}
+	if !hmac.Equal([]byte(signature), []byte(v.urlSignature(key, unix))) {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+func (v *Vault) urlSignature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, v.urlKey)
+	fmt.Fprintf(mac, "%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (v *Vault) seal(key string, data []byte) []byte {
+	nonce := make([]byte, v.sealer.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err) // crypto/rand does not fail on supported platforms
+	}
+	out := append([]byte(sealedMagic), nonce...)
+	return v.sealer.Seal(out, nonce, data, []byte(key))
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newTestVault(t *testing.T) (*Vault, *LocalStore) {
+	t.Helper()
+	s := NewLocalStore(t.TempDir())
+	v, err := NewVault(s, bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v, s
+}
+
+func TestSaveAndOpen(t *testing.T) {
+	v, s := newTestVault(t)
+	ctx := context.Background()
+
+	key, err := v.Save(ctx, bytes.NewReader(pngHeader), "national_ids")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !generatedKey.MatchString(key) || ContentType(key) != "image/png" {
+		t.Errorf("unexpected key %q", key)
+	}
+	raw, _ := s.Get(ctx, key)
+	if bytes.Contains(raw, pngHeader) {
+		t.Error("document is stored in plaintext")
+	}
+	data, err := v.Open(ctx, key)
+	if err != nil || !bytes.Equal(data, pngHeader) {
+		t.Errorf("Open = %q, %v", data, err)
+	}
+
+	// A blob copied to another key does not decrypt.
+	s.Put(ctx, "national_ids/copy.png", raw)
+	if _, err := v.Open(ctx, "national_ids/copy.png"); err == nil {
+		t.Error("Open decrypted a document stored under another key")
+	}
+}
+
+func TestSaveRejects(t *testing.T) {
+	v, _ := newTestVault(t)
+	ctx := context.Background()
+	if _, err := v.Save(ctx, bytes.NewReader([]byte("MZ\x90\x00 not an image")), "x"); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("executable: got %v", err)
+	}
+	big := append(append([]byte{}, pngHeader...), make([]byte, MaxDocumentSize)...)
+	if _, err := v.Save(ctx, bytes.NewReader(big), "x"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized document: got %v", err)
+	}
+}
+
+func TestEncryptLegacy(t *testing.T) {
+	v, s := newTestVault(t)
+	ctx := context.Background()
+
+	const legacy = "national_ids/Screenshot 2023-11-07 114430.png"
+	s.Put(ctx, legacy, pngHeader)
+	current, err := v.Save(ctx, bytes.NewReader(pngHeader), "national_ids")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := v.Open(ctx, legacy); !errors.Is(err, ErrNotEncrypted) {
+		t.Fatalf("Open of a plaintext document: got %v, want ErrNotEncrypted", err)
+	}
+
+	n, err := v.EncryptLegacy(ctx, []string{legacy, current, "national_ids/deleted.png"})
+	if err != nil || n != 1 {
+		t.Fatalf("EncryptLegacy = %d, %v; want 1 document encrypted", n, err)
+	}
+	raw, _ := s.Get(ctx, legacy)
+	if bytes.Contains(raw, pngHeader) {
+		t.Error("legacy document is still in plaintext")
+	}
+	for _, key := range []string{legacy, current} {
+		if data, err := v.Open(ctx, key); err != nil || !bytes.Equal(data, pngHeader) {
+			t.Errorf("v.Open(%s) = %q, %v", key, data, err)
+		}
+	}
+
+	if n, err := v.EncryptLegacy(ctx, []string{legacy}); err != nil || n != 0 {
+		t.Errorf("second EncryptLegacy = %d, %v; want nothing to do", n, err)
+	}
+}
+
+func TestSignedURL(t *testing.T) {
+	v, _ := newTestVault(t)
+	url, expires := v.SignURL("national_ids/a.png", time.Minute)
+	if time.Until(expires) <= 0 || !strings.HasPrefix(url, "/documents/national_ids/a.png?") {
+		t.Fatalf("SignURL = %s, %v", url, expires)
+	}
+	unix, past := expires.Unix(), time.Now().Add(-time.Minute).Unix()
+	valid := v.urlSignature("national_ids/a.png", unix)
+	tests := []struct {
+		name, key, signature string
+		expires              int64
+		ok                   bool
+	}{
+		{"valid", "national_ids/a.png", valid, unix, true},
+		{"other key", "national_ids/b.png", valid, unix, false},
+		{"extended", "national_ids/a.png", valid, unix + 3600, false},
+		{"expired", "national_ids/a.png", v.urlSignature("national_ids/a.png", past), past, false},
+	}
+	for _, tt := range tests {
+		if err := v.VerifyURL(tt.key, strconv.FormatInt(tt.expires, 10), tt.signature); (err == nil) != tt.ok {
+			t.Errorf("%s: VerifyURL = %v", tt.name, err)
+		}
+	}
+}
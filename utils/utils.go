@@ -1,8 +1,6 @@
 package utils
 
 import (
-	"io"
-	"os"
 	"strconv"
 	"time"
 
@@ -20,32 +18,16 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-func GenerateJWT(userID uint, role string) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
-
+// GenerateJWT issues an access token signed with secret. jti identifies the
+// token so it can be revoked before it expires.
+func GenerateJWT(secret []byte, userID uint, role string, jti string, ttl time.Duration) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": strconv.Itoa(int(userID)),
 		"role":    role, // ✅ Add role here
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
+		"jti":     jti,
+		"exp":     time.Now().Add(ttl).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
-}
-
-func CreateImageFile(path string, file io.Reader) error {
-	// Make sure the folder exists
-	err := os.MkdirAll("./uploads/national_ids", os.ModePerm)
-	if err != nil {
-		return err
-	}
-
-	dst, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer dst.Close()
-
-	_, err = io.Copy(dst, file)
-	return err
+	return token.SignedString(secret)
 }
@@ -0,0 +1,169 @@
+// Package validate checks request DTOs against the rules in their `validate`
+// struct tags, for example:
+//
+//	Email string `json:"email" validate:"required,email"`
+//
+// Rules are separated by commas and checked in order:
+//
+//	required     must not be empty
+//	omitempty    skip the remaining rules if the field is empty
+//	email        an email address such as jane@example.com
+//	password     8 to 72 characters with at least one letter and one digit
+//	e164         a phone number in E.164 form such as +251911234567
+//	oneof=a b c  one of the listed values
+//	min=n max=n  length bounds for strings
+//
+// Fields are reported by their JSON name so that clients can match errors
+// to what they sent.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"neobank-lite/problem"
+)
+
+// Field error codes, one per rule.
+const (
+	CodeRequired = "required"
+	CodeEmail    = "email"
+	CodePassword = "password"
+	CodeE164     = "e164"
+	CodeOneOf    = "oneof"
+	CodeMin      = "min"
+	CodeMax      = "max"
+)
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores anything longer
+)
+
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// Struct checks every tagged field of v, which must be a struct or a pointer
+// to one, and returns what is wrong with each. It panics on rules it does not
+// know, which is a programming error.
+func Struct(v interface{}) []problem.FieldError {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	rt := rv.Type()
+
+	var errs []problem.FieldError
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("validate")
+		if tag == "" || !sf.IsExported() {
+			continue
+		}
+		if err := field(fieldName(sf), rv.Field(i), tag); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	return errs
+}
+
+// field applies the rules of one field and returns the first one it breaks.
+func field(name string, v reflect.Value, tag string) *problem.FieldError {
+	empty := v.IsZero()
+	s := ""
+	if v.Kind() == reflect.String {
+		s = v.String()
+		empty = strings.TrimSpace(s) == ""
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		rule, arg, _ := strings.Cut(rule, "=")
+		switch rule {
+		case "required":
+			if empty {
+				return fieldError(name, CodeRequired, name+" is required")
+			}
+		case "omitempty":
+			if empty {
+				return nil
+			}
+		case "email":
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s || addr.Name != "" {
+				return fieldError(name, CodeEmail, name+" must be a valid email address")
+			}
+		case "password":
+			if !strongPassword(s) {
+				return fieldError(name, CodePassword, fmt.Sprintf(
+					"%s must be %d to %d characters long and contain a letter and a digit",
+					name, minPasswordLength, maxPasswordLength))
+			}
+		case "e164":
+			if !e164.MatchString(s) {
+				return fieldError(name, CodeE164, name+" must be a phone number in international format, such as +251911234567")
+			}
+		case "oneof":
+			allowed := strings.Fields(arg)
+			if !contains(allowed, s) {
+				return fieldError(name, CodeOneOf, name+" must be one of "+strings.Join(allowed, ", "))
+			}
+		case "min":
+			if n := mustAtoi(arg); utf8.RuneCountInString(s) < n {
+				return fieldError(name, CodeMin, fmt.Sprintf("%s must be at least %d characters long", name, n))
+			}
+		case "max":
+			if n := mustAtoi(arg); utf8.RuneCountInString(s) > n {
+				return fieldError(name, CodeMax, fmt.Sprintf("%s must be at most %d characters long", name, n))
+			}
+		default:
+			panic("validate: unknown rule " + rule)
+		}
+	}
+	return nil
+}
+
+func fieldError(name, code, message string) *problem.FieldError {
+	return &problem.FieldError{Field: name, Code: code, Message: message}
+}
+
+// fieldName is the name clients know the field by: its JSON name, or its
+// form name for multipart requests.
+func fieldName(sf reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+func strongPassword(s string) bool {
+	n := utf8.RuneCountInString(s)
+	if n < minPasswordLength || len(s) > maxPasswordLength {
+		return false
+	}
+	var letter, digit bool
+	for _, r := range s {
+		letter = letter || unicode.IsLetter(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	return letter && digit
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic("validate: bad rule argument " + s)
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+package validate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"neobank-lite/dto"
+	"neobank-lite/problem"
+)
+
+// codes returns the error code of each field that failed, by field name.
+func codes(errs []problem.FieldError) map[string]string {
+	out := map[string]string{}
+	for _, e := range errs {
+		out[e.Field] = e.Code
+	}
+	return out
+}
+
+func TestStruct(t *testing.T) {
+	validSignup := dto.SignupRequest{Name: "Abebe Kebede", Email: "abebe@example.com", Password: "s3cretpass"}
+	validAccount := dto.CreateAccountRequest{PhoneNumber: "+251911234567"}
+
+	tests := []struct {
+		name string
+		v    interface{}
+		want map[string]string // field to code, nothing if empty
+	}{
+		{"valid signup", validSignup, nil},
+		{"valid signup pointer", &validSignup, nil},
+		{"everything missing", dto.SignupRequest{}, map[string]string{"name": CodeRequired, "email": CodeRequired, "password": CodeRequired}},
+		{"blank name", dto.SignupRequest{Name: "   ", Email: "a@example.com", Password: "s3cretpass"}, map[string]string{"name": CodeRequired}},
+		{"long name", dto.SignupRequest{Name: strings.Repeat("a", 101), Email: "a@example.com", Password: "s3cretpass"}, map[string]string{"name": CodeMax}},
+		{"long name in runes", dto.SignupRequest{Name: strings.Repeat("አ", 100), Email: "a@example.com", Password: "s3cretpass"}, nil},
+
+		{"email without domain", dto.SignupRequest{Name: "A", Email: "abebe", Password: "s3cretpass"}, map[string]string{"email": CodeEmail}},
+		{"email with display name", dto.SignupRequest{Name: "A", Email: "Abebe <abebe@example.com>", Password: "s3cretpass"}, map[string]string{"email": CodeEmail}},
+		{"email with spaces", dto.SignupRequest{Name: "A", Email: " abebe@example.com", Password: "s3cretpass"}, map[string]string{"email": CodeEmail}},
+
+		{"short password", dto.SignupRequest{Name: "A", Email: "a@example.com", Password: "s3cret"}, map[string]string{"password": CodePassword}},
+		{"password without digit", dto.SignupRequest{Name: "A", Email: "a@example.com", Password: "secretpass"}, map[string]string{"password": CodePassword}},
+		{"password without letter", dto.SignupRequest{Name: "A", Email: "a@example.com", Password: "12345678"}, map[string]string{"password": CodePassword}},
+		{"password of 72 bytes", dto.SignupRequest{Name: "A", Email: "a@example.com", Password: "a1" + strings.Repeat("x", 70)}, nil},
+		{"password over 72 bytes", dto.SignupRequest{Name: "A", Email: "a@example.com", Password: "a1" + strings.Repeat("x", 71)}, map[string]string{"password": CodePassword}},
+		{"non-ASCII password", dto.SignupRequest{Name: "A", Email: "a@example.com", Password: "ፓስወርድ123"}, nil},
+
+		{"valid account", validAccount, nil},
+		{"savings account", dto.CreateAccountRequest{AccountType: "savings", PhoneNumber: "+251911234567"}, nil},
+		{"unknown account type", dto.CreateAccountRequest{AccountType: "Savings", PhoneNumber: "+251911234567"}, map[string]string{"account_type": CodeOneOf}},
+		{"missing phone number", dto.CreateAccountRequest{}, map[string]string{"phone_number": CodeRequired}},
+		{"local phone number", dto.CreateAccountRequest{PhoneNumber: "0911234567"}, map[string]string{"phone_number": CodeE164}},
+		{"phone number with leading zero", dto.CreateAccountRequest{PhoneNumber: "+0911234567"}, map[string]string{"phone_number": CodeE164}},
+		{"short phone number", dto.CreateAccountRequest{PhoneNumber: "+25191"}, map[string]string{"phone_number": CodeE164}},
+		{"long phone number", dto.CreateAccountRequest{PhoneNumber: "+2519112345678901"}, map[string]string{"phone_number": CodeE164}},
+		{"formatted phone number", dto.CreateAccountRequest{PhoneNumber: "+251 91 123 4567"}, map[string]string{"phone_number": CodeE164}},
+	}
+	for _, tt := range tests {
+		got := codes(Struct(tt.v))
+		if len(tt.want) == 0 && len(got) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStructMin(t *testing.T) {
+	var v struct {
+		Code string `json:"code" validate:"omitempty,min=4"`
+	}
+	if errs := Struct(v); len(errs) != 0 {
+		t.Errorf("empty optional field: got %v", errs)
+	}
+	v.Code = "abc"
+	if got := codes(Struct(v)); got["code"] != CodeMin {
+		t.Errorf("short field: got %v", got)
+	}
+}
+
+func TestStructUnknownRule(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("an unknown rule did not panic")
+		}
+	}()
+	Struct(struct {
+		Name string `validate:"required,shiny"`
+	}{Name: "x"})
+}